@@ -3,64 +3,167 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"regexp"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/auth"
 	"github.com/kiremitrov123/onboarding/commenting/model"
 	"github.com/kiremitrov123/onboarding/commenting/service"
+	"github.com/kiremitrov123/onboarding/commenting/webhooks"
 )
 
 type API struct {
-	Svc    *service.CommentService
-	Logger *slog.Logger
+	Svc      *service.CommentService
+	Webhooks *webhooks.Service
+	Logger   *slog.Logger
+
+	auth    Authenticator
+	limiter RateLimiter
+	limits  RateLimits
+
+	// threadsMu guards threads, the thread of each comment the rate limiter looked up
+	threadsMu sync.Mutex
+	threads   map[uuid.UUID]uuid.UUID
 
 	once sync.Once
 	mux  *http.ServeMux
 }
 
-func NewAPI(svc *service.CommentService, logger *slog.Logger) *API {
-	return &API{
-		Svc:    svc,
-		Logger: logger,
+func NewAPI(svc *service.CommentService, hooks *webhooks.Service, logger *slog.Logger, opts ...Option) *API {
+	a := &API{
+		Svc:      svc,
+		Webhooks: hooks,
+		Logger:   logger,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.once.Do(a.setupRoutes)
+	r, ok := a.authenticate(w, r)
+	if !ok {
+		return
+	}
 	a.mux.ServeHTTP(w, r)
 }
 
 func (a *API) setupRoutes() {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /comments", a.handleCreateComment)
+	mux.HandleFunc("POST /comments", limitBody(maxCommentBody, a.rateLimited(actionCreate, a.handleCreateComment)))
 	mux.HandleFunc("GET /comments", a.handleListComments)
+	mux.HandleFunc("GET /comments/search", a.handleSearchComments)
+
+	mux.HandleFunc("GET /comments/{id}", a.handleGetComment)
+	mux.HandleFunc("GET /comments/{id}/reactions", a.handleListReactions)
+	mux.HandleFunc("PATCH /comments/{id}", limitBody(maxCommentBody, a.handleUpdateComment))
+	mux.HandleFunc("DELETE /comments/{id}", a.handleDeleteComment)
+
+	mux.HandleFunc("POST /threads", a.handleCreateThread)
+	mux.HandleFunc("GET /threads", a.handleFindThread)
+	mux.HandleFunc("GET /threads/{id}", a.handleGetThread)
+	mux.HandleFunc("GET /threads/{id}/tree", a.handleThreadTree)
+	mux.HandleFunc("GET /threads/{id}/events", a.handleThreadEvents)
+
+	mux.HandleFunc("POST /comments/{id}/upvote", a.rateLimited(actionReact, a.handleReaction(a.Svc.Upvote)))
+	mux.HandleFunc("POST /comments/{id}/downvote", a.rateLimited(actionReact, a.handleReaction(a.Svc.Downvote)))
+	mux.HandleFunc("POST /comments/{id}/like", a.rateLimited(actionReact, a.handleReaction(a.Svc.Like)))
+	mux.HandleFunc("PUT /comments/{id}/vote", a.rateLimited(actionReact, a.handleVote))
+	mux.HandleFunc("POST /comments/{id}/report", a.handleReportComment)
+
+	mux.HandleFunc("GET /users/{id}/notifications", a.handleListNotifications)
+	mux.HandleFunc("POST /users/{id}/notifications/read", a.handleMarkNotificationsRead)
+
+	moderators := []string{auth.RoleModerator, auth.RoleAdmin}
+	mux.HandleFunc("GET /moderation/queue", a.requireRole(a.handleModerationQueue, moderators...))
+	mux.HandleFunc("POST /moderation/comments/{id}/{action}", a.requireRole(a.handleModerateComment, moderators...))
+	mux.HandleFunc("GET /moderation/comments/{id}/actions", a.requireRole(a.handleModerationActions, moderators...))
+	mux.HandleFunc("GET /comments/{id}/revisions", a.requireRole(a.handleListRevisions, moderators...))
+	mux.HandleFunc("PATCH /threads/{id}", a.requireRole(a.handleUpdateThread, moderators...))
+	mux.HandleFunc("PUT /threads/{id}/pins/{comment_id}", a.requireRole(a.handlePinComment, moderators...))
+	mux.HandleFunc("DELETE /threads/{id}/pins/{comment_id}", a.requireRole(a.handleUnpinComment, moderators...))
+
+	mux.HandleFunc("POST /webhooks", a.requireRole(a.handleCreateWebhook, auth.RoleAdmin))
+	mux.HandleFunc("GET /webhooks", a.requireRole(a.handleListWebhooks, auth.RoleAdmin))
+	mux.HandleFunc("GET /webhooks/{id}", a.requireRole(a.handleGetWebhook, auth.RoleAdmin))
+	mux.HandleFunc("PATCH /webhooks/{id}", a.requireRole(a.handleUpdateWebhook, auth.RoleAdmin))
+	mux.HandleFunc("DELETE /webhooks/{id}", a.requireRole(a.handleDeleteWebhook, auth.RoleAdmin))
+	mux.HandleFunc("GET /webhooks/{id}/dead-letters", a.requireRole(a.handleListDeadLetters, auth.RoleAdmin))
+	mux.HandleFunc("POST /webhooks/{id}/replay", a.requireRole(a.handleReplayWebhook, auth.RoleAdmin))
 
-	// TODO: Implement comment update (PATCH /comments/{id})
-	// mux.HandleFunc("PATCH /comments/{id}", a.handleUpdateComment)
-	// TODO: Implement comment delete (DELETE /comments/{id})
-	// mux.HandleFunc("DELETE /comments/{id}", a.handleDeleteComment)
+	a.mux = mux
+}
 
-	mux.HandleFunc("POST /comments/{id}/upvote", a.handleReaction(a.Svc.Upvote))
-	mux.HandleFunc("POST /comments/{id}/downvote", a.handleReaction(a.Svc.Downvote))
-	mux.HandleFunc("POST /comments/{id}/like", a.handleReaction(a.Svc.Like))
+// PolicyErrorResponse is the 422 body of a comment rejected by a content policy.
+type PolicyErrorResponse struct {
+	Error     string                  `json:"error"`
+	Violation service.PolicyViolation `json:"violation"`
+}
 
-	a.mux = mux
+type CreateCommentRequest struct {
+	UserID   string     `json:"user_id"`
+	ParentID *uuid.UUID `json:"parent_id"`
+	ThreadID uuid.UUID  `json:"thread_id"`
+	Content  string     `json:"content"`
+	Format   string     `json:"format"`
 }
 
 func (a *API) handleCreateComment(w http.ResponseWriter, r *http.Request) {
-	var c model.Comment
-	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+	var req CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if tooLarge(err) {
+			a.respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
 		a.Logger.Error("invalid input", slog.String("error", err.Error()))
 		a.respondError(w, http.StatusBadRequest, "invalid input")
 		return
 	}
+	c := model.Comment{
+		ParentID: req.ParentID,
+		ThreadID: req.ThreadID,
+		UserID:   a.caller(r, req.UserID),
+		Content:  req.Content,
+		Format:   req.Format,
+	}
 
-	if err := a.Svc.CreateComment(r.Context(), &c); err != nil {
+	err := a.Svc.CreateComment(r.Context(), &c)
+	var policyErr *service.PolicyError
+	if errors.As(err, &policyErr) {
+		a.Logger.Info("comment rejected by policy",
+			slog.String("user_id", c.UserID),
+			slog.String("policy", policyErr.Violation.Policy),
+			slog.String("code", policyErr.Violation.Code),
+		)
+		a.respond(w, http.StatusUnprocessableEntity, PolicyErrorResponse{
+			Error:     policyErr.Violation.Message,
+			Violation: policyErr.Violation,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidFormat) {
+		a.respondError(w, http.StatusBadRequest, "format must be markdown or plain")
+		return
+	}
+	if errors.Is(err, model.ErrThreadLocked) {
+		a.respondError(w, http.StatusConflict, "thread is locked")
+		return
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		a.respondError(w, http.StatusNotFound, "thread or parent comment not found")
+		return
+	}
+	if err != nil {
 		a.Logger.Error("failed to create comment",
 			slog.String("user_id", c.UserID),
 			slog.String("content", c.Content),
@@ -105,24 +208,16 @@ func (a *API) handleListComments(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cursorStr := r.URL.Query().Get("cursor")
-	cursor, err := strconv.ParseInt(cursorStr, 10, 64)
-	if cursorStr != "" && err != nil {
+	cursor, err := model.DecodeCursor(cursorStr)
+	if err != nil {
 		a.Logger.Warn("invalid cursor", slog.String("cursor", cursorStr))
 		a.respondError(w, http.StatusBadRequest, "invalid cursor value")
 		return
 	}
 
-	var comments []model.Comment
-
-	switch sort {
-	case "upvotes":
-		comments, err = a.Svc.ListByUpvotes(r.Context(), tid, cursor, limit)
-	case "replies":
-		comments, err = a.Svc.ListByReplies(r.Context(), tid, cursor, limit)
-	default:
-		comments, err = a.Svc.ListByDate(r.Context(), tid, cursor, limit)
-	}
+	viewerID := a.caller(r, r.URL.Query().Get("viewer_id"))
 
+	page, err := a.Svc.ListComments(r.Context(), tid, sort, cursor, limit, viewerID)
 	if err != nil {
 		a.Logger.Error("failed to list comments",
 			slog.String("thread_id", tid.String()),
@@ -136,26 +231,363 @@ func (a *API) handleListComments(w http.ResponseWriter, r *http.Request) {
 	a.Logger.Info("listed comments",
 		slog.String("thread_id", tid.String()),
 		slog.String("sort", sort),
-		slog.Int("count", len(comments)),
+		slog.Int("count", len(page.Comments)),
 	)
 
-	// Include next cursor in response
-	var nextCursor int64
-	if len(comments) > 0 {
-		last := comments[len(comments)-1]
-		switch sort {
-		case "upvotes":
-			nextCursor = int64(last.Upvotes)
-		case "replies":
-			nextCursor = int64(last.ReplyCount)
-		default:
-			nextCursor = last.CreatedAt.UnixNano()
+	a.respond(w, http.StatusOK, page)
+}
+
+func (a *API) handleGetComment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	comment, err := a.Svc.GetCommentByID(r.Context(), commentID)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to get comment",
+			slog.String("comment_id", commentID.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to get comment")
+		return
+	}
+
+	a.respond(w, http.StatusOK, comment)
+}
+
+func (a *API) handleListReactions(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	reactionType := r.URL.Query().Get("type")
+
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = min(parsed, 100)
 		}
 	}
 
+	cursorStr := r.URL.Query().Get("cursor")
+	cursor, err := model.DecodeCursor(cursorStr)
+	if err != nil {
+		a.Logger.Warn("invalid cursor", slog.String("cursor", cursorStr))
+		a.respondError(w, http.StatusBadRequest, "invalid cursor value")
+		return
+	}
+
+	page, err := a.Svc.ListReactions(r.Context(), commentID, reactionType, cursor, limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidType):
+		a.respondError(w, http.StatusBadRequest, "invalid reaction type")
+		return
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to list reactions",
+			slog.String("comment_id", commentID.String()),
+			slog.String("type", reactionType),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to list reactions")
+		return
+	}
+
+	a.respond(w, http.StatusOK, page)
+}
+
+const defaultTreeDepth = 3
+
+func (a *API) handleThreadTree(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	threadID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid thread_id", slog.String("thread_id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid thread_id format")
+		return
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "date"
+	}
+
+	depth := defaultTreeDepth
+	if d := r.URL.Query().Get("depth"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 0 {
+			a.Logger.Warn("invalid depth", slog.String("depth", d))
+			a.respondError(w, http.StatusBadRequest, "invalid depth value")
+			return
+		}
+		depth = min(parsed, service.MaxTreeDepth)
+	}
+
+	tree, truncated, err := a.Svc.GetThreadTree(r.Context(), threadID, sort, depth)
+	if errors.Is(err, service.ErrInvalidSort) {
+		a.respondError(w, http.StatusBadRequest, "invalid sort value")
+		return
+	}
+	if err != nil {
+		a.Logger.Error("failed to load thread tree",
+			slog.String("thread_id", threadID.String()),
+			slog.String("sort", sort),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to load thread tree")
+		return
+	}
+
+	a.Logger.Info("loaded thread tree",
+		slog.String("thread_id", threadID.String()),
+		slog.String("sort", sort),
+		slog.Int("depth", depth),
+	)
+
 	a.respond(w, http.StatusOK, map[string]interface{}{
-		"comments":    comments,
-		"next_cursor": nextCursor,
+		"comments":  tree,
+		"truncated": truncated,
+	})
+}
+
+// heartbeatInterval is how often an idle event stream sends a comment line to keep the connection open.
+const heartbeatInterval = 15 * time.Second
+
+// streamIDPattern matches Redis stream IDs, which are used as SSE event IDs.
+var streamIDPattern = regexp.MustCompile(`^[0-9]+-[0-9]+$`)
+
+func (a *API) handleThreadEvents(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	threadID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid thread_id", slog.String("thread_id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid thread_id format")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID != "" && !streamIDPattern.MatchString(lastEventID) {
+		a.Logger.Warn("invalid Last-Event-ID", slog.String("last_event_id", lastEventID))
+		a.respondError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		a.respondError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	ch := make(chan model.StreamEvent, 16)
+	err = a.Svc.StreamEvents(r.Context(), threadID, lastEventID, ch)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "thread not found")
+		return
+	case errors.Is(err, service.ErrStreamUnavailable):
+		a.respondError(w, http.StatusServiceUnavailable, "event stream unavailable")
+		return
+	case err != nil:
+		a.Logger.Error("failed to stream thread events",
+			slog.String("thread_id", threadID.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to stream thread events")
+		return
+	}
+
+	// The server's write timeout would cut the stream off, so lift it for this response
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(": connected\n\n"))
+	flusher.Flush()
+
+	a.Logger.Info("thread event stream opened",
+		slog.String("thread_id", threadID.String()),
+		slog.String("last_event_id", lastEventID),
+	)
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				// Reading failed or the client left; clients reconnect with Last-Event-ID
+				a.Logger.Info("thread event stream closed", slog.String("thread_id", threadID.String()))
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			_, _ = w.Write([]byte(": heartbeat\n\n"))
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one event in the SSE format, with its stream position as the event ID.
+func writeEvent(w http.ResponseWriter, e model.StreamEvent) error {
+	data, err := json.Marshal(e.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.StreamID, e.Event.Type, data)
+	return err
+}
+
+type UpdateCommentRequest struct {
+	UserID  string `json:"user_id"`
+	Content string `json:"content"`
+}
+
+func (a *API) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	var body UpdateCommentRequest
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if tooLarge(err) {
+		a.respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	body.UserID = a.caller(r, body.UserID)
+	if err != nil || body.UserID == "" || body.Content == "" {
+		a.Logger.Warn("invalid update payload")
+		a.respondError(w, http.StatusBadRequest, "missing or invalid user_id/content")
+		return
+	}
+
+	comment, err := a.Svc.UpdateComment(r.Context(), commentID, body.UserID, body.Content)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case errors.Is(err, model.ErrForbidden):
+		a.Logger.Warn("comment update forbidden",
+			slog.String("comment_id", commentID.String()),
+			slog.String("user_id", body.UserID),
+		)
+		a.respondError(w, http.StatusForbidden, "only the author can edit a comment")
+		return
+	case err != nil:
+		a.Logger.Error("failed to update comment",
+			slog.String("comment_id", commentID.String()),
+			slog.String("user_id", body.UserID),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to update comment")
+		return
+	}
+
+	a.Logger.Info("comment updated",
+		slog.String("id", comment.ID.String()),
+		slog.String("user_id", comment.UserID),
+		slog.Int("revision", comment.Revision),
+	)
+
+	a.respond(w, http.StatusOK, comment)
+}
+
+func (a *API) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	var body ReactionRequest
+	err = json.NewDecoder(r.Body).Decode(&body)
+	body.UserID = a.caller(r, body.UserID)
+	if err != nil || body.UserID == "" {
+		a.Logger.Warn("invalid delete payload")
+		a.respondError(w, http.StatusBadRequest, "missing or invalid user_id")
+		return
+	}
+
+	err = a.Svc.DeleteComment(r.Context(), commentID, body.UserID)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case errors.Is(err, model.ErrForbidden):
+		a.Logger.Warn("comment delete forbidden",
+			slog.String("comment_id", commentID.String()),
+			slog.String("user_id", body.UserID),
+		)
+		a.respondError(w, http.StatusForbidden, "only the author can delete a comment")
+		return
+	case err != nil:
+		a.Logger.Error("failed to delete comment",
+			slog.String("comment_id", commentID.String()),
+			slog.String("user_id", body.UserID),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to delete comment")
+		return
+	}
+
+	a.Logger.Info("comment deleted",
+		slog.String("id", commentID.String()),
+		slog.String("user_id", body.UserID),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) handleListRevisions(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	revisions, err := a.Svc.ListRevisions(r.Context(), commentID)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to list revisions",
+			slog.String("comment_id", commentID.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to list revisions")
+		return
+	}
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"revisions": revisions,
 	})
 }
 
@@ -174,13 +606,20 @@ func (a *API) handleReaction(action func(context.Context, uuid.UUID, string) err
 		}
 
 		var body ReactionRequest
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.UserID == "" {
+		err = json.NewDecoder(r.Body).Decode(&body)
+		body.UserID = a.caller(r, body.UserID)
+		if err != nil || body.UserID == "" {
 			a.Logger.Warn("invalid reaction payload")
 			a.respondError(w, http.StatusBadRequest, "missing or invalid thread_id/user_id")
 			return
 		}
 
-		if err := action(r.Context(), commentID, body.UserID); err != nil {
+		err = action(r.Context(), commentID, body.UserID)
+		if errors.Is(err, model.ErrNotFound) {
+			a.respondError(w, http.StatusNotFound, "comment not found")
+			return
+		}
+		if err != nil {
 			a.Logger.Error("reaction failed",
 				slog.String("comment_id", commentID.String()),
 				slog.String("user_id", body.UserID),
@@ -201,12 +640,81 @@ func (a *API) handleReaction(action func(context.Context, uuid.UUID, string) err
 	}
 }
 
+type VoteRequest struct {
+	UserID string `json:"user_id"`
+	Value  *int   `json:"value"`
+}
+
+func (a *API) handleVote(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	var body VoteRequest
+	err = json.NewDecoder(r.Body).Decode(&body)
+	body.UserID = a.caller(r, body.UserID)
+	if err != nil || body.UserID == "" || body.Value == nil {
+		a.Logger.Warn("invalid vote payload")
+		a.respondError(w, http.StatusBadRequest, "missing or invalid user_id/value")
+		return
+	}
+
+	err = a.Svc.Vote(r.Context(), commentID, body.UserID, *body.Value)
+	switch {
+	case errors.Is(err, service.ErrInvalidVote):
+		a.respondError(w, http.StatusBadRequest, "value must be -1, 0 or 1")
+		return
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("vote failed",
+			slog.String("comment_id", commentID.String()),
+			slog.String("user_id", body.UserID),
+			slog.Int("value", *body.Value),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "action failed")
+		return
+	}
+
+	a.Logger.Info("vote set",
+		slog.String("comment_id", commentID.String()),
+		slog.String("user_id", body.UserID),
+		slog.Int("value", *body.Value),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (a *API) respond(w http.ResponseWriter, status int, body any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(body)
 }
 
+// maxCommentBody caps the body of comment writes. Content is limited to 10000 characters,
+// which stays below it even with every character escaped in the JSON.
+const maxCommentBody = 128 << 10
+
+// limitBody fails reads of a request body past n bytes, for the handler to answer 413.
+func limitBody(n int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+		next(w, r)
+	}
+}
+
+// tooLarge reports whether reading the request body failed because it exceeded its limit.
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
 func (a *API) respondError(w http.ResponseWriter, status int, message string) {
 	type response struct {
 		Error string `json:"error"`
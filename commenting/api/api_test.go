@@ -0,0 +1,600 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kiremitrov123/onboarding/commenting/api"
+	apimocks "github.com/kiremitrov123/onboarding/commenting/api/mocks"
+	"github.com/kiremitrov123/onboarding/commenting/auth"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/service"
+	"github.com/kiremitrov123/onboarding/commenting/service/mocks"
+)
+
+func newTestAPI(repo *mocks.CommentRepoMock, cache *mocks.CommentCacheMock) *api.API {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return api.NewAPI(service.NewCommentService(repo, cache), nil, logger)
+}
+
+func TestGetComment_FallsBackToDB(t *testing.T) {
+	commentID := uuid.New()
+
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return nil, errors.New("cache miss")
+		},
+	}
+	repo := &mocks.CommentRepoMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, ThreadID: id, UserID: "alice", Content: "From DB"}, nil
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/comments/"+commentID.String(), nil)
+	rr := httptest.NewRecorder()
+	newTestAPI(repo, cache).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var comment model.Comment
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&comment))
+	require.Equal(t, commentID, comment.ID)
+	require.Equal(t, "From DB", comment.Content)
+}
+
+func TestGetComment_NotFound(t *testing.T) {
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return nil, errors.New("cache miss")
+		},
+	}
+	repo := &mocks.CommentRepoMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return nil, model.ErrNotFound
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/comments/"+uuid.New().String(), nil)
+	rr := httptest.NewRecorder()
+	newTestAPI(repo, cache).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestListReactions_Pages(t *testing.T) {
+	commentID := uuid.New()
+	now := time.Now()
+	reactions := []model.Reaction{
+		{ID: uuid.New(), CommentID: commentID, UserID: "bob", Type: "like", CreatedAt: now},
+		{ID: uuid.New(), CommentID: commentID, UserID: "carol", Type: "like", CreatedAt: now.Add(-time.Minute)},
+	}
+
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id}, nil
+		},
+	}
+	repo := &mocks.CommentRepoMock{
+		ListReactionsFunc: func(ctx context.Context, id uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error) {
+			require.Equal(t, commentID, id)
+			require.Equal(t, "like", reactionType)
+			require.Equal(t, 2, limit)
+			return reactions, nil
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/comments/"+commentID.String()+"/reactions?type=like&limit=2", nil)
+	rr := httptest.NewRecorder()
+	newTestAPI(repo, cache).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var page model.ReactionPage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	require.Len(t, page.Reactions, 2)
+
+	next, err := model.DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, reactions[1].ID, next.ID)
+}
+
+func TestListReactions_CapsLimit(t *testing.T) {
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id}, nil
+		},
+	}
+	repo := &mocks.CommentRepoMock{
+		ListReactionsFunc: func(ctx context.Context, id uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error) {
+			return []model.Reaction{}, nil
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/comments/"+uuid.NewString()+"/reactions?limit=100000", nil)
+	rr := httptest.NewRecorder()
+	newTestAPI(repo, cache).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, repo.ListReactionsCalls(), 1)
+	require.Equal(t, 100, repo.ListReactionsCalls()[0].Limit)
+}
+
+func TestListReactions_InvalidType(t *testing.T) {
+	req := httptest.NewRequest("GET", "/comments/"+uuid.New().String()+"/reactions?type=love", nil)
+	rr := httptest.NewRecorder()
+	newTestAPI(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{}).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestThreadEvents_StreamsWithReplay(t *testing.T) {
+	threadID := uuid.New()
+	event := model.Event{ID: uuid.New(), Type: model.EventCommentCreated, ThreadID: threadID, CommentID: uuid.New()}
+
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, ThreadID: id}, nil
+		},
+	}
+	events := &mocks.EventStreamMock{
+		SubscribeFunc: func(ctx context.Context, tid uuid.UUID, lastEventID string) (<-chan model.StreamEvent, error) {
+			require.Equal(t, "1700000000000-0", lastEventID)
+			// The stream ends after the replayed event, which closes the response
+			ch := make(chan model.StreamEvent, 1)
+			ch <- model.StreamEvent{StreamID: "1700000000001-0", Event: event}
+			close(ch)
+			return ch, nil
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// A thread from before the threads table existed is found through its root comment
+	repo := &mocks.CommentRepoMock{
+		GetThreadFunc: func(ctx context.Context, id uuid.UUID) (*model.Thread, error) {
+			return nil, model.ErrNotFound
+		},
+	}
+	handler := api.NewAPI(service.NewCommentService(repo, cache, service.WithEventStream(events)), nil, logger)
+
+	req := httptest.NewRequest("GET", "/threads/"+threadID.String()+"/events", nil)
+	req.Header.Set("Last-Event-ID", "1700000000000-0")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	require.Contains(t, rr.Body.String(), "id: 1700000000001-0\nevent: comment.created\ndata: ")
+	require.Contains(t, rr.Body.String(), event.CommentID.String())
+}
+
+func TestThreadEvents_InvalidLastEventID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/threads/"+uuid.New().String()+"/events", nil)
+	req.Header.Set("Last-Event-ID", "bogus")
+	rr := httptest.NewRecorder()
+	newTestAPI(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{}).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCreateComment_PolicyRejection(t *testing.T) {
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache, service.WithContentPolicies(
+		service.LengthPolicy{Min: 1, Max: 10},
+	))
+	a := api.NewAPI(svc, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	body := `{"user_id":"alice","content":"far too long for this thread"}`
+	req := httptest.NewRequest("POST", "/comments", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	var resp api.PolicyErrorResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Equal(t, "length", resp.Violation.Policy)
+	require.Equal(t, "too_long", resp.Violation.Code)
+	require.Empty(t, repo.CreateCommentCalls())
+}
+
+func TestCreateComment_IgnoresServerFields(t *testing.T) {
+	repo := &mocks.CommentRepoMock{
+		CreateCommentFunc: func(ctx context.Context, c *model.Comment, reports []model.Report) error { return nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	a := newTestAPI(repo, cache)
+
+	forgedID := uuid.New()
+	body := `{"id":"` + forgedID.String() + `","user_id":"alice","content":"hi",` +
+		`"created_at":"2001-01-01T00:00:00Z","upvotes":500,"likes":20,"reply_count":7}`
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("POST", "/comments", strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	calls := repo.CreateCommentCalls()
+	require.Len(t, calls, 1)
+	stored := calls[0].Comment
+	require.NotEqual(t, forgedID, stored.ID)
+	require.WithinDuration(t, time.Now(), stored.CreatedAt, time.Minute)
+	require.Zero(t, stored.Upvotes)
+	require.Zero(t, stored.Likes)
+	require.Zero(t, stored.ReplyCount)
+}
+
+func TestCreateComment_BodyTooLarge(t *testing.T) {
+	repo := &mocks.CommentRepoMock{}
+	limiter := &apimocks.RateLimiterMock{}
+	a := api.NewAPI(service.NewCommentService(repo, &mocks.CommentCacheMock{}), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithRateLimit(limiter, api.RateLimits{}))
+
+	body := `{"user_id":"alice","content":"` + strings.Repeat("a", 200<<10) + `"}`
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("POST", "/comments", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	require.Empty(t, limiter.AllowRequestCalls())
+	require.Empty(t, repo.CreateCommentCalls())
+}
+
+func TestRateLimit_RejectsWithHeaders(t *testing.T) {
+	limiter := &apimocks.RateLimiterMock{
+		AllowRequestFunc: func(ctx context.Context, budgets []model.RateBudget) (model.RateLimitResult, error) {
+			return model.RateLimitResult{Limit: 10, RetryAfter: 5500 * time.Millisecond, ResetAfter: time.Minute}, nil
+		},
+	}
+	limits := api.RateLimits{CreatePerUser: model.RateLimit{Limit: 10, Period: time.Minute}}
+	a := api.NewAPI(service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{}), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithRateLimit(limiter, limits))
+
+	req := httptest.NewRequest("POST", "/comments", strings.NewReader(`{"user_id":"alice","content":"hi"}`))
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "6", rr.Header().Get("Retry-After"))
+	require.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "60", rr.Header().Get("RateLimit-Reset"))
+
+	calls := limiter.AllowRequestCalls()
+	require.Len(t, calls, 1)
+	// Without authentication the claimed user_id isn't trusted, so the client's address is limited
+	require.Equal(t, []model.RateBudget{{Key: "create:ip:192.0.2.1", Limit: limits.CreatePerUser}}, calls[0].Budgets)
+}
+
+func TestRateLimit_KeysOnTokenSubject(t *testing.T) {
+	limiter := &apimocks.RateLimiterMock{
+		AllowRequestFunc: func(ctx context.Context, budgets []model.RateBudget) (model.RateLimitResult, error) {
+			return model.RateLimitResult{Limit: 10}, nil
+		},
+	}
+	limits := api.RateLimits{CreatePerUser: model.RateLimit{Limit: 10, Period: time.Minute}}
+	a := api.NewAPI(service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{}), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithAuth(newAuthenticator()), api.WithRateLimit(limiter, limits))
+
+	req := httptest.NewRequest("POST", "/comments", strings.NewReader(`{"user_id":"mallory","content":"hi"}`))
+	req.Header.Set("Authorization", "Bearer alice-token")
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	calls := limiter.AllowRequestCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, []model.RateBudget{{Key: "create:user:alice", Limit: limits.CreatePerUser}}, calls[0].Budgets)
+}
+
+func TestRateLimit_RejectsInvalidBody(t *testing.T) {
+	limiter := &apimocks.RateLimiterMock{}
+	repo := &mocks.CommentRepoMock{}
+	a := api.NewAPI(service.NewCommentService(repo, &mocks.CommentCacheMock{}), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithRateLimit(limiter, api.RateLimits{}))
+
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("POST", "/comments", strings.NewReader(`{"content":`)))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Empty(t, limiter.AllowRequestCalls())
+	require.Empty(t, repo.CreateCommentCalls())
+}
+
+func TestRateLimit_TopLevelCommentUsesThreadBudget(t *testing.T) {
+	threadID := uuid.New()
+	limiter := &apimocks.RateLimiterMock{
+		AllowRequestFunc: func(ctx context.Context, budgets []model.RateBudget) (model.RateLimitResult, error) {
+			return model.RateLimitResult{Limit: 10}, nil
+		},
+	}
+	limits := api.RateLimits{
+		CreatePerUser:   model.RateLimit{Limit: 10, Period: time.Minute},
+		CreatePerThread: model.RateLimit{Limit: 100, Period: time.Minute},
+	}
+	a := api.NewAPI(service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{}), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithRateLimit(limiter, limits))
+
+	for range 2 {
+		body := `{"thread_id":"` + threadID.String() + `","content":"hi"}`
+		rr := httptest.NewRecorder()
+		a.ServeHTTP(rr, httptest.NewRequest("POST", "/comments", strings.NewReader(body)))
+		require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	}
+
+	calls := limiter.AllowRequestCalls()
+	require.Len(t, calls, 2)
+	for _, call := range calls {
+		require.Equal(t, []model.RateBudget{
+			{Key: "create:ip:192.0.2.1", Limit: limits.CreatePerUser},
+			{Key: "create:thread:" + threadID.String(), Limit: limits.CreatePerThread},
+		}, call.Budgets)
+	}
+}
+
+func TestRateLimit_ReactionUsesThreadBudget(t *testing.T) {
+	commentID := uuid.New()
+	threadID := uuid.New()
+
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, ThreadID: threadID}, nil
+		},
+		UpdateCommentScoreFunc: func(ctx context.Context, id uuid.UUID, field string, delta int) error { return nil },
+	}
+	repo := &mocks.CommentRepoMock{
+		ToggleReactionFunc: func(ctx context.Context, r *model.Reaction, field string) (bool, error) { return true, nil },
+	}
+	limiter := &apimocks.RateLimiterMock{
+		AllowRequestFunc: func(ctx context.Context, budgets []model.RateBudget) (model.RateLimitResult, error) {
+			return model.RateLimitResult{Allowed: true, Limit: 30, Remaining: 29, ResetAfter: 2 * time.Second}, nil
+		},
+	}
+	limits := api.RateLimits{
+		ReactPerUser:   model.RateLimit{Limit: 30, Period: time.Minute},
+		ReactPerThread: model.RateLimit{Limit: 300, Period: time.Minute},
+	}
+	a := api.NewAPI(service.NewCommentService(repo, cache), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithRateLimit(limiter, limits))
+
+	for range 2 {
+		req := httptest.NewRequest("POST", "/comments/"+commentID.String()+"/like", strings.NewReader(`{"user_id":"bob"}`))
+		rr := httptest.NewRecorder()
+		a.ServeHTTP(rr, req)
+
+		// The handler still sees the body the middleware read
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Equal(t, "29", rr.Header().Get("RateLimit-Remaining"))
+	}
+	require.Len(t, repo.ToggleReactionCalls(), 2)
+
+	calls := limiter.AllowRequestCalls()
+	require.Len(t, calls, 2)
+	require.Equal(t, []model.RateBudget{
+		{Key: "react:ip:192.0.2.1", Limit: limits.ReactPerUser},
+		{Key: "react:thread:" + threadID.String(), Limit: limits.ReactPerThread},
+	}, calls[1].Budgets)
+
+	// The comment's thread is looked up once
+	require.Len(t, cache.GetCommentByIDCalls(), 1)
+}
+
+func TestReactions_MissingComment(t *testing.T) {
+	repo := &mocks.CommentRepoMock{
+		ToggleReactionFunc: func(ctx context.Context, r *model.Reaction, field string) (bool, error) {
+			return false, model.ErrNotFound
+		},
+		VoteFunc: func(ctx context.Context, id uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+			return 0, 0, model.ErrNotFound
+		},
+	}
+	a := newTestAPI(repo, &mocks.CommentCacheMock{})
+	commentID := uuid.NewString()
+
+	for _, route := range []string{"POST /comments/" + commentID + "/like", "PUT /comments/" + commentID + "/vote"} {
+		method, path, _ := strings.Cut(route, " ")
+		rr := httptest.NewRecorder()
+		a.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(`{"user_id":"bob","value":0}`)))
+		require.Equal(t, http.StatusNotFound, rr.Code, route)
+	}
+}
+
+func newAuthenticator() *apimocks.AuthenticatorMock {
+	return &apimocks.AuthenticatorMock{
+		VerifyFunc: func(token string) (auth.Identity, error) {
+			switch token {
+			case "alice-token":
+				return auth.Identity{UserID: "alice"}, nil
+			case "mod-token":
+				return auth.Identity{UserID: "mia", Roles: []string{auth.RoleModerator}}, nil
+			}
+			return auth.Identity{}, auth.ErrInvalidToken
+		},
+	}
+}
+
+func TestAuth_ActsAsTokenSubject(t *testing.T) {
+	commentID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{
+		ToggleReactionFunc: func(ctx context.Context, r *model.Reaction, field string) (bool, error) { return true, nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		UpdateCommentScoreFunc: func(ctx context.Context, id uuid.UUID, field string, delta int) error { return nil },
+	}
+	a := api.NewAPI(service.NewCommentService(repo, cache), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithAuth(newAuthenticator()))
+
+	// No token
+	req := httptest.NewRequest("POST", "/comments/"+commentID.String()+"/like", strings.NewReader(`{"user_id":"bob"}`))
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+
+	// Invalid token
+	req = httptest.NewRequest("POST", "/comments/"+commentID.String()+"/like", strings.NewReader(`{"user_id":"bob"}`))
+	req.Header.Set("Authorization", "Bearer forged")
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// The body's user_id is ignored in favour of the token's subject
+	req = httptest.NewRequest("POST", "/comments/"+commentID.String()+"/like", strings.NewReader(`{"user_id":"bob"}`))
+	req.Header.Set("Authorization", "Bearer alice-token")
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	calls := repo.ToggleReactionCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, "alice", calls[0].Reaction.UserID)
+}
+
+func TestAuth_ModerationRequiresRole(t *testing.T) {
+	repo := &mocks.CommentRepoMock{
+		ListModerationQueueFunc: func(ctx context.Context, limit int) ([]model.QueueItem, error) {
+			return []model.QueueItem{}, nil
+		},
+	}
+	a := api.NewAPI(service.NewCommentService(repo, &mocks.CommentCacheMock{}), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithAuth(newAuthenticator()))
+
+	for token, status := range map[string]int{
+		"":            http.StatusUnauthorized,
+		"alice-token": http.StatusForbidden,
+		"mod-token":   http.StatusOK,
+	} {
+		req := httptest.NewRequest("GET", "/moderation/queue", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		a.ServeHTTP(rr, req)
+		require.Equal(t, status, rr.Code, token)
+	}
+}
+
+func TestAuth_PrivilegedRoutesDisabledWithoutAuth(t *testing.T) {
+	a := newTestAPI(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{})
+
+	for _, route := range []string{"GET /moderation/queue", "GET /comments/" + uuid.NewString() + "/revisions", "PATCH /threads/" + uuid.NewString(), "GET /webhooks"} {
+		method, path, _ := strings.Cut(route, " ")
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"locked": true}`))
+		rr := httptest.NewRecorder()
+		a.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusForbidden, rr.Code, route)
+	}
+}
+
+func TestNotifications_OnlyOwnInbox(t *testing.T) {
+	repo := &mocks.CommentRepoMock{
+		ListNotificationsFunc: func(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error) {
+			return []model.Notification{{ID: uuid.New(), UserID: userID, Type: model.NotificationMention}}, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		GetUnreadCountFunc: func(ctx context.Context, userID string) (int, error) { return 1, nil },
+	}
+	a := api.NewAPI(service.NewCommentService(repo, cache), nil,
+		slog.New(slog.NewTextHandler(io.Discard, nil)), api.WithAuth(newAuthenticator()))
+
+	req := httptest.NewRequest("GET", "/users/bob/notifications", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("GET", "/users/alice/notifications", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var page model.NotificationPage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	require.Len(t, page.Notifications, 1)
+	require.Equal(t, 1, page.UnreadCount)
+}
+
+func TestSearchComments(t *testing.T) {
+	repo := &mocks.CommentRepoMock{
+		SearchCommentsFunc: func(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error) {
+			require.Nil(t, threadID)
+			require.Equal(t, 20, limit)
+			return []model.SearchResult{{Comment: model.Comment{ID: uuid.New(), Content: "go is great"}, Rank: 0.1}}, nil
+		},
+	}
+	a := newTestAPI(repo, &mocks.CommentCacheMock{})
+
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("GET", "/comments/search?q=", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("GET", "/comments/search?q=go&thread_id=nope", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("GET", "/comments/search?q=great", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var page model.SearchPage
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	require.Len(t, page.Results, 1)
+	require.Equal(t, "go is <mark>great</mark>", page.Results[0].Snippet)
+	require.Empty(t, page.NextCursor)
+}
+
+func TestCreateComment_LockedThread(t *testing.T) {
+	threadID := uuid.New()
+	repo := &mocks.CommentRepoMock{
+		GetThreadFunc: func(ctx context.Context, id uuid.UUID) (*model.Thread, error) {
+			return &model.Thread{ID: id, Locked: true}, nil
+		},
+	}
+
+	body := `{"thread_id":"` + threadID.String() + `","user_id":"alice","content":"hello"}`
+	rr := httptest.NewRecorder()
+	newTestAPI(repo, &mocks.CommentCacheMock{}).ServeHTTP(rr, httptest.NewRequest("POST", "/comments", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+	require.Empty(t, repo.CreateCommentCalls())
+}
+
+func TestCreateThread_ReturnsExisting(t *testing.T) {
+	existing := model.Thread{ID: uuid.New(), SubjectKey: "https://example.com/post", Title: "Post", CommentCount: 4}
+	repo := &mocks.CommentRepoMock{
+		CreateThreadFunc: func(ctx context.Context, thread *model.Thread) (bool, error) {
+			require.Equal(t, "https://example.com/post", thread.SubjectKey)
+			*thread = existing
+			return false, nil
+		},
+	}
+	a := newTestAPI(repo, &mocks.CommentCacheMock{})
+
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("POST", "/threads", strings.NewReader(`{"subject_key":"  "}`)))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, httptest.NewRequest("POST", "/threads", strings.NewReader(`{"subject_key":"https://example.com/post","title":"Other"}`)))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "/threads/"+existing.ID.String(), rr.Header().Get("Location"))
+
+	var thread model.Thread
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&thread))
+	require.Equal(t, "Post", thread.Title)
+	require.Equal(t, 4, thread.CommentCount)
+}
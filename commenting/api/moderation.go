@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/service"
+)
+
+type ReportRequest struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type ModerationRequest struct {
+	ModeratorID string `json:"moderator_id"`
+	Note        string `json:"note"`
+}
+
+func (a *API) handleReportComment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	var body ReportRequest
+	err = json.NewDecoder(r.Body).Decode(&body)
+	body.UserID = a.caller(r, body.UserID)
+	if err != nil || body.UserID == "" {
+		a.Logger.Warn("invalid report payload")
+		a.respondError(w, http.StatusBadRequest, "missing or invalid user_id/reason")
+		return
+	}
+
+	err = a.Svc.ReportComment(r.Context(), commentID, body.UserID, body.Reason)
+	switch {
+	case errors.Is(err, service.ErrInvalidReason):
+		a.respondError(w, http.StatusBadRequest, "reason must be between 1 and 500 characters")
+		return
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to report comment",
+			slog.String("comment_id", commentID.String()),
+			slog.String("user_id", body.UserID),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to report comment")
+		return
+	}
+
+	a.Logger.Info("comment reported",
+		slog.String("comment_id", commentID.String()),
+		slog.String("user_id", body.UserID),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) handleModerationQueue(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = min(parsed, 200)
+		}
+	}
+
+	items, err := a.Svc.ListModerationQueue(r.Context(), limit)
+	if err != nil {
+		a.Logger.Error("failed to load moderation queue", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusInternalServerError, "failed to load moderation queue")
+		return
+	}
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"items": items,
+	})
+}
+
+func (a *API) handleModerateComment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+	action := r.PathValue("action")
+
+	var body ModerationRequest
+	err = json.NewDecoder(r.Body).Decode(&body)
+	body.ModeratorID = a.caller(r, body.ModeratorID)
+	if err != nil || body.ModeratorID == "" {
+		a.Logger.Warn("invalid moderation payload")
+		a.respondError(w, http.StatusBadRequest, "missing or invalid moderator_id")
+		return
+	}
+
+	comment, err := a.Svc.ModerateComment(r.Context(), commentID, body.ModeratorID, action, body.Note)
+	switch {
+	case errors.Is(err, service.ErrInvalidAction):
+		a.respondError(w, http.StatusBadRequest, "action must be approve, hide or remove")
+		return
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to moderate comment",
+			slog.String("comment_id", commentID.String()),
+			slog.String("moderator_id", body.ModeratorID),
+			slog.String("action", action),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to moderate comment")
+		return
+	}
+
+	a.Logger.Info("comment moderated",
+		slog.String("comment_id", commentID.String()),
+		slog.String("moderator_id", body.ModeratorID),
+		slog.String("action", action),
+	)
+
+	a.respond(w, http.StatusOK, comment)
+}
+
+func (a *API) handleModerationActions(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	commentID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return
+	}
+
+	actions, err := a.Svc.ListModerationActions(r.Context(), commentID)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "comment not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to list moderation actions",
+			slog.String("comment_id", commentID.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to list moderation actions")
+		return
+	}
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"actions": actions,
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+type MarkReadRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+func (a *API) handleListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.inboxOwner(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = min(parsed, 100)
+		}
+	}
+
+	cursorStr := r.URL.Query().Get("cursor")
+	cursor, err := model.DecodeCursor(cursorStr)
+	if err != nil {
+		a.Logger.Warn("invalid cursor", slog.String("cursor", cursorStr))
+		a.respondError(w, http.StatusBadRequest, "invalid cursor value")
+		return
+	}
+
+	page, err := a.Svc.ListNotifications(r.Context(), userID, cursor, limit)
+	if err != nil {
+		a.Logger.Error("failed to list notifications",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to list notifications")
+		return
+	}
+
+	a.respond(w, http.StatusOK, page)
+}
+
+func (a *API) handleMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.inboxOwner(w, r)
+	if !ok {
+		return
+	}
+
+	// An empty body marks every notification read
+	var body MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		a.Logger.Warn("invalid mark-read payload")
+		a.respondError(w, http.StatusBadRequest, "invalid ids")
+		return
+	}
+
+	unread, err := a.Svc.MarkNotificationsRead(r.Context(), userID, body.IDs)
+	if err != nil {
+		a.Logger.Error("failed to mark notifications read",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to mark notifications read")
+		return
+	}
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"unread_count": unread,
+	})
+}
+
+// inboxOwner returns the user whose inbox the request addresses. With authentication on,
+// only that user may read or clear it.
+func (a *API) inboxOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := r.PathValue("id")
+	caller := a.caller(r, userID)
+	switch {
+	case caller == "":
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		a.respondError(w, http.StatusUnauthorized, "missing bearer token")
+		return "", false
+	case caller != userID:
+		a.respondError(w, http.StatusForbidden, "cannot access another user's notifications")
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/auth"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// RateLimiter counts a request against a set of budgets.
+type RateLimiter interface {
+	AllowRequest(ctx context.Context, budgets []model.RateBudget) (model.RateLimitResult, error)
+}
+
+// RateLimits are the budgets for comment creation and reactions, per user and per thread.
+type RateLimits struct {
+	CreatePerUser   model.RateLimit
+	CreatePerThread model.RateLimit
+	ReactPerUser    model.RateLimit
+	ReactPerThread  model.RateLimit
+}
+
+// Rate-limited actions, used in the budget keys.
+const (
+	actionCreate = "create"
+	actionReact  = "react"
+)
+
+// maxLimitedBody caps how much of the request body the middleware buffers to find the parent comment.
+const maxLimitedBody = 1 << 20
+
+// maxRememberedThreads caps how many comments' threads the rate limiter keeps in memory.
+const maxRememberedThreads = 10000
+
+// Option configures an optional dependency of the API.
+type Option func(*API)
+
+// WithRateLimit enables rate limiting of comment creation and reactions.
+func WithRateLimit(limiter RateLimiter, limits RateLimits) Option {
+	return func(a *API) {
+		a.limiter = limiter
+		a.limits = limits
+	}
+}
+
+// rateLimited wraps a create or react handler with the caller's and the thread's budgets for the action.
+// The caller is the token subject, or the client IP when authentication is off, since the user_id of
+// the body is then whatever the client claims. The body is buffered and handed on to the handler
+// unchanged; one that isn't JSON is rejected before it reaches the limiter.
+// A limiter failure lets the request through rather than taking the API down with Redis.
+func (a *API) rateLimited(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.limiter == nil {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxLimitedBody))
+		if tooLarge(err) {
+			a.respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			a.respondError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var payload struct {
+			ParentID *uuid.UUID `json:"parent_id"`
+			ThreadID *uuid.UUID `json:"thread_id"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			a.respondError(w, http.StatusBadRequest, "invalid input")
+			return
+		}
+
+		subject := rateSubject(r)
+		budgets := a.budgets(r, action, subject, payload.ParentID, payload.ThreadID)
+		result, err := a.limiter.AllowRequest(r.Context(), budgets)
+		if err != nil {
+			a.Logger.Warn("rate limiter unavailable",
+				slog.String("subject", subject),
+				slog.String("error", err.Error()),
+			)
+			next(w, r)
+			return
+		}
+
+		if result.Limit > 0 {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(result.ResetAfter))
+		}
+		if !result.Allowed {
+			a.Logger.Info("rate limited",
+				slog.String("subject", subject),
+				slog.String("action", action),
+			)
+			w.Header().Set("Retry-After", seconds(result.RetryAfter))
+			a.respondError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// rateSubject names who a request is counted against: "user:{id}" for an authenticated caller,
+// otherwise "ip:{address}".
+func rateSubject(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok {
+		return "user:" + id.UserID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// budgets returns the caller's budget for the action and, when the thread is known, the thread's.
+// A reaction's thread is that of the comment in the path and a reply's that of its parent;
+// a new top-level comment names its thread_id, which the handler checks exists.
+func (a *API) budgets(r *http.Request, action, subject string, parentID, threadID *uuid.UUID) []model.RateBudget {
+	userLimit, threadLimit := a.limits.CreatePerUser, a.limits.CreatePerThread
+	commentID := parentID
+	if action == actionReact {
+		userLimit, threadLimit = a.limits.ReactPerUser, a.limits.ReactPerThread
+		if id, err := uuid.Parse(r.PathValue("id")); err == nil {
+			commentID = &id
+		}
+	}
+
+	budgets := []model.RateBudget{{Key: action + ":" + subject, Limit: userLimit}}
+	if threadLimit.Limit == 0 {
+		return budgets
+	}
+
+	if commentID != nil {
+		// An unknown comment is left to the handler to report
+		id, ok := a.threadOf(r.Context(), *commentID)
+		if !ok {
+			return budgets
+		}
+		threadID = &id
+	}
+	if threadID == nil {
+		return budgets
+	}
+	return append(budgets, model.RateBudget{Key: action + ":thread:" + threadID.String(), Limit: threadLimit})
+}
+
+// threadOf returns the thread of a comment. A comment never moves to another thread, so each one
+// is looked up once and remembered, until maxRememberedThreads are and they are all forgotten.
+func (a *API) threadOf(ctx context.Context, commentID uuid.UUID) (uuid.UUID, bool) {
+	a.threadsMu.Lock()
+	threadID, ok := a.threads[commentID]
+	a.threadsMu.Unlock()
+	if ok {
+		return threadID, true
+	}
+
+	comment, err := a.Svc.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	a.threadsMu.Lock()
+	defer a.threadsMu.Unlock()
+	if a.threads == nil || len(a.threads) >= maxRememberedThreads {
+		a.threads = make(map[uuid.UUID]uuid.UUID)
+	}
+	a.threads[commentID] = comment.ThreadID
+	return comment.ThreadID, true
+}
+
+// seconds formats a duration as whole seconds, rounded up, for the rate limit headers.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
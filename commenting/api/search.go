@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/service"
+)
+
+func (a *API) handleSearchComments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+
+	var threadID *uuid.UUID
+	if tidStr := r.URL.Query().Get("thread_id"); tidStr != "" {
+		tid, err := uuid.Parse(tidStr)
+		if err != nil {
+			a.Logger.Warn("invalid thread_id", slog.String("thread_id", tidStr))
+			a.respondError(w, http.StatusBadRequest, "invalid thread_id format")
+			return
+		}
+		threadID = &tid
+	}
+
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = min(parsed, 100)
+		}
+	}
+
+	cursorStr := r.URL.Query().Get("cursor")
+	cursor, err := model.DecodeCursor(cursorStr)
+	if err != nil {
+		a.Logger.Warn("invalid cursor", slog.String("cursor", cursorStr))
+		a.respondError(w, http.StatusBadRequest, "invalid cursor value")
+		return
+	}
+
+	page, err := a.Svc.SearchComments(r.Context(), query, threadID, cursor, limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidQuery):
+		a.respondError(w, http.StatusBadRequest, "q must be between 1 and 200 characters")
+		return
+	case err != nil:
+		a.Logger.Error("failed to search comments",
+			slog.String("query", query),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to search comments")
+		return
+	}
+
+	a.Logger.Info("searched comments",
+		slog.String("query", query),
+		slog.Int("count", len(page.Results)),
+	)
+
+	a.respond(w, http.StatusOK, page)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/service"
+)
+
+type CreateThreadRequest struct {
+	SubjectKey string `json:"subject_key"`
+	Title      string `json:"title"`
+}
+
+type UpdateThreadRequest struct {
+	Title  *string `json:"title"`
+	Locked *bool   `json:"locked"`
+}
+
+func (a *API) handleCreateThread(w http.ResponseWriter, r *http.Request) {
+	var body CreateThreadRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.Logger.Warn("invalid thread payload", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+
+	thread := &model.Thread{SubjectKey: body.SubjectKey, Title: body.Title}
+	created, err := a.Svc.CreateThread(r.Context(), thread)
+	switch {
+	case errors.Is(err, service.ErrInvalidThread):
+		a.respondError(w, http.StatusBadRequest, "subject_key is required and title must be at most 300 characters")
+		return
+	case err != nil:
+		a.Logger.Error("failed to create thread",
+			slog.String("subject_key", body.SubjectKey),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to create thread")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/threads/%s", thread.ID))
+	// The subject already has a thread, which is returned as is
+	if !created {
+		a.respond(w, http.StatusOK, thread)
+		return
+	}
+
+	a.Logger.Info("thread created",
+		slog.String("id", thread.ID.String()),
+		slog.String("subject_key", thread.SubjectKey),
+	)
+	a.respond(w, http.StatusCreated, thread)
+}
+
+func (a *API) handleFindThread(w http.ResponseWriter, r *http.Request) {
+	subjectKey := r.URL.Query().Get("subject_key")
+	if subjectKey == "" {
+		a.respondError(w, http.StatusBadRequest, "missing subject_key")
+		return
+	}
+
+	thread, err := a.Svc.GetThreadBySubject(r.Context(), subjectKey)
+	a.respondThread(w, thread, err, "find")
+}
+
+func (a *API) handleGetThread(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.threadID(w, r)
+	if !ok {
+		return
+	}
+
+	thread, err := a.Svc.GetThread(r.Context(), id)
+	a.respondThread(w, thread, err, "get")
+}
+
+func (a *API) handleUpdateThread(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.threadID(w, r)
+	if !ok {
+		return
+	}
+
+	var body UpdateThreadRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.Logger.Warn("invalid thread payload", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+
+	thread, err := a.Svc.UpdateThread(r.Context(), id, body.Title, body.Locked)
+	if errors.Is(err, service.ErrInvalidThread) {
+		a.respondError(w, http.StatusBadRequest, "title must be at most 300 characters")
+		return
+	}
+	a.respondThread(w, thread, err, "update")
+	if err == nil {
+		a.Logger.Info("thread updated",
+			slog.String("id", id.String()),
+			slog.Bool("locked", thread.Locked),
+		)
+	}
+}
+
+func (a *API) handlePinComment(w http.ResponseWriter, r *http.Request) {
+	id, commentID, ok := a.pinIDs(w, r)
+	if !ok {
+		return
+	}
+
+	thread, err := a.Svc.PinComment(r.Context(), id, commentID)
+	if errors.Is(err, model.ErrTooManyPins) {
+		a.respondError(w, http.StatusConflict, fmt.Sprintf("a thread pins at most %d comments", model.MaxPinnedComments))
+		return
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		a.respondError(w, http.StatusNotFound, "thread or comment not found")
+		return
+	}
+	a.respondThread(w, thread, err, "pin comment on")
+}
+
+func (a *API) handleUnpinComment(w http.ResponseWriter, r *http.Request) {
+	id, commentID, ok := a.pinIDs(w, r)
+	if !ok {
+		return
+	}
+
+	thread, err := a.Svc.UnpinComment(r.Context(), id, commentID)
+	a.respondThread(w, thread, err, "unpin comment on")
+}
+
+// respondThread writes a thread, or the error an operation on it failed with.
+func (a *API) respondThread(w http.ResponseWriter, thread *model.Thread, err error, op string) {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "thread not found")
+	case err != nil:
+		a.Logger.Error("failed to "+op+" thread", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusInternalServerError, "failed to "+op+" thread")
+	default:
+		a.respond(w, http.StatusOK, thread)
+	}
+}
+
+func (a *API) threadID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid thread ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (a *API) pinIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	id, ok := a.threadID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	commentStr := r.PathValue("comment_id")
+	commentID, err := uuid.Parse(commentStr)
+	if err != nil {
+		a.Logger.Warn("invalid comment ID", slog.String("id", commentStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, commentID, true
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/webhooks"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
+
+type ReplayRequest struct {
+	DeadLetterIDs []uuid.UUID `json:"dead_letter_ids"`
+}
+
+func (a *API) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var body CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.Logger.Warn("invalid webhook payload", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+
+	sub := &model.WebhookSubscription{
+		URL:        body.URL,
+		Secret:     body.Secret,
+		EventTypes: body.EventTypes,
+	}
+	err := a.Webhooks.CreateSubscription(r.Context(), sub)
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidSubscription):
+		a.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		a.Logger.Error("failed to create webhook",
+			slog.String("url", body.URL),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to create webhook")
+		return
+	}
+
+	a.Logger.Info("webhook created",
+		slog.String("id", sub.ID.String()),
+		slog.String("url", sub.URL),
+	)
+
+	w.Header().Set("Location", fmt.Sprintf("/webhooks/%s", sub.ID))
+	a.respond(w, http.StatusCreated, sub)
+}
+
+func (a *API) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := a.Webhooks.ListSubscriptions(r.Context())
+	if err != nil {
+		a.Logger.Error("failed to list webhooks", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"webhooks": subs,
+	})
+}
+
+func (a *API) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := a.Webhooks.GetSubscription(r.Context(), id)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "webhook not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to get webhook",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to get webhook")
+		return
+	}
+
+	a.respond(w, http.StatusOK, sub)
+}
+
+func (a *API) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var body UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.Logger.Warn("invalid webhook payload", slog.String("error", err.Error()))
+		a.respondError(w, http.StatusBadRequest, "invalid input")
+		return
+	}
+
+	sub, err := a.Webhooks.UpdateSubscription(r.Context(), id, body.URL, body.EventTypes, body.Active)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "webhook not found")
+		return
+	case errors.Is(err, webhooks.ErrInvalidSubscription):
+		a.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		a.Logger.Error("failed to update webhook",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to update webhook")
+		return
+	}
+
+	a.Logger.Info("webhook updated",
+		slog.String("id", sub.ID.String()),
+		slog.Bool("active", sub.Active),
+	)
+
+	a.respond(w, http.StatusOK, sub)
+}
+
+func (a *API) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	err := a.Webhooks.DeleteSubscription(r.Context(), id)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "webhook not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to delete webhook",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+
+	a.Logger.Info("webhook deleted", slog.String("id", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	deadLetters, err := a.Webhooks.ListDeadLetters(r.Context(), id)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "webhook not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to list dead letters",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to list dead letters")
+		return
+	}
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"dead_letters": deadLetters,
+	})
+}
+
+func (a *API) handleReplayWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; without it every dead letter is replayed
+	var body ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			a.Logger.Warn("invalid replay payload", slog.String("error", err.Error()))
+			a.respondError(w, http.StatusBadRequest, "invalid input")
+			return
+		}
+	}
+
+	replayed, err := a.Webhooks.ReplayDeadLetters(r.Context(), id, body.DeadLetterIDs)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		a.respondError(w, http.StatusNotFound, "webhook not found")
+		return
+	case err != nil:
+		a.Logger.Error("failed to replay dead letters",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+		a.respondError(w, http.StatusInternalServerError, "failed to replay dead letters")
+		return
+	}
+
+	a.Logger.Info("dead letters replayed",
+		slog.String("id", id.String()),
+		slog.Int("replayed", replayed),
+	)
+
+	a.respond(w, http.StatusOK, map[string]interface{}{
+		"replayed": replayed,
+	})
+}
+
+// webhookID parses the subscription ID from the path, responding with 400 when it is malformed.
+func (a *API) webhookID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.Logger.Warn("invalid webhook ID", slog.String("id", idStr))
+		a.respondError(w, http.StatusBadRequest, "invalid UUID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Roles granted through the roles claim.
+const (
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	UserID string
+	Roles  []string
+}
+
+// HasRole reports whether the identity holds any of the given roles.
+func (id Identity) HasRole(roles ...string) bool {
+	for _, r := range roles {
+		if slices.Contains(id.Roles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the identity.
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, badly signed, expired or not meant for this API.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+const defaultLeeway = time.Minute
+
+// jwk is a JSON Web Key: an "oct" key carries the HMAC secret in k, an "RSA" key its modulus and exponent.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type key struct {
+	id     string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// Verifier validates HS256 and RS256 JWTs against a fixed key set. Tokens name their key with kid;
+// a token without one may only be checked against a single-key set. Issuer and Audience are
+// checked when set, and Leeway absorbs clock skew in exp and nbf.
+type Verifier struct {
+	keys []key
+
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// LoadJWKS reads a JWKS file ({"keys": [...]}) and returns a verifier for its keys.
+func LoadJWKS(path string) (*Verifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS returns a verifier for the keys of a JWKS document.
+func ParseJWKS(data []byte) (*Verifier, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.New("parse jwks: no keys")
+	}
+
+	v := &Verifier{Leeway: defaultLeeway}
+	for _, k := range set.Keys {
+		parsed, err := parseKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwks: key %q: %w", k.Kid, err)
+		}
+		v.keys = append(v.keys, parsed)
+	}
+	return v, nil
+}
+
+func parseKey(k jwk) (key, error) {
+	switch k.Kty {
+	case "oct":
+		if k.Alg != "" && k.Alg != AlgHS256 {
+			return key{}, fmt.Errorf("unsupported alg %q", k.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return key{}, errors.New("invalid k")
+		}
+		return key{id: k.Kid, alg: AlgHS256, secret: secret}, nil
+	case "RSA":
+		if k.Alg != "" && k.Alg != AlgRS256 {
+			return key{}, fmt.Errorf("unsupported alg %q", k.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return key{}, errors.New("invalid n")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return key{}, errors.New("invalid e")
+		}
+		pub := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return key{id: k.Kid, alg: AlgRS256, public: pub}, nil
+	default:
+		return key{}, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Roles     []string `json:"roles"`
+}
+
+// audience accepts the aud claim as either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks the token's signature and claims and returns the identity it carries:
+// the sub claim as the user and the roles claim as its roles.
+func (v *Verifier) Verify(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Identity{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	k, err := v.key(h)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if !k.verify(parts[0]+"."+parts[1], sig) {
+		return Identity{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Identity{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.validate(c, time.Now()); err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: c.Subject, Roles: c.Roles}, nil
+}
+
+// key finds the key named by the header. The algorithm must match the key's,
+// so an RSA public key can never be used as an HMAC secret.
+func (v *Verifier) key(h header) (key, error) {
+	if h.Alg != AlgHS256 && h.Alg != AlgRS256 {
+		return key{}, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+	}
+	for _, k := range v.keys {
+		named := h.Kid != "" && h.Kid == k.id
+		only := h.Kid == "" && len(v.keys) == 1
+		if (named || only) && h.Alg == k.alg {
+			return k, nil
+		}
+	}
+	return key{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, h.Kid)
+}
+
+func (k key) verify(signed string, sig []byte) bool {
+	switch k.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(signed))
+		return hmac.Equal(mac.Sum(nil), sig)
+	case AlgRS256:
+		digest := sha256.Sum256([]byte(signed))
+		return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
+
+func (v *Verifier) validate(c claims, now time.Time) error {
+	if c.Subject == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(v.Leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if c.NotBefore != nil && now.Before(time.Unix(*c.NotBefore, 0).Add(-v.Leeway)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.Audience != "" {
+		for _, aud := range c.Audience {
+			if aud == v.Audience {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+func decodeSegment(seg string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
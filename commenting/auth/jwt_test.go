@@ -0,0 +1,119 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kiremitrov123/onboarding/commenting/auth"
+	"github.com/stretchr/testify/require"
+)
+
+var b64 = base64.RawURLEncoding
+
+func encode(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b64.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, kid string, claims map[string]any) string {
+	signed := encode(t, map[string]string{"alg": "HS256", "kid": kid}) + "." + encode(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + b64.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := encode(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encode(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "alice",
+		"aud":   []string{"commenting"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{auth.RoleModerator},
+	}
+}
+
+func TestVerify_HS256AndRS256(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "oct", "kid": "hmac", "k": %q},
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "n": %q, "e": %q}
+	]}`, b64.EncodeToString(secret),
+		b64.EncodeToString(rsaKey.N.Bytes()),
+		b64.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()))
+
+	v, err := auth.ParseJWKS([]byte(jwks))
+	require.NoError(t, err)
+	v.Audience = "commenting"
+
+	id, err := v.Verify(signHS256(t, secret, "hmac", validClaims()))
+	require.NoError(t, err)
+	require.Equal(t, "alice", id.UserID)
+	require.True(t, id.HasRole(auth.RoleModerator, auth.RoleAdmin))
+	require.False(t, id.HasRole(auth.RoleAdmin))
+
+	id, err = v.Verify(signRS256(t, rsaKey, "rsa", validClaims()))
+	require.NoError(t, err)
+	require.Equal(t, "alice", id.UserID)
+
+	// A token can't pick a different algorithm than its key's
+	_, err = v.Verify(signHS256(t, secret, "rsa", validClaims()))
+	require.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestVerify_RejectsInvalidTokens(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	v, err := auth.ParseJWKS([]byte(fmt.Sprintf(`{"keys": [{"kty": "oct", "k": %q}]}`, b64.EncodeToString(secret))))
+	require.NoError(t, err)
+	v.Audience = "commenting"
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	otherAudience := validClaims()
+	otherAudience["aud"] = "billing"
+
+	noSubject := validClaims()
+	delete(noSubject, "sub")
+
+	tampered := signHS256(t, secret, "", validClaims())
+	tampered = tampered[:len(tampered)-2] + "AA"
+
+	for name, token := range map[string]string{
+		"expired":       signHS256(t, secret, "", expired),
+		"audience":      signHS256(t, secret, "", otherAudience),
+		"no subject":    signHS256(t, secret, "", noSubject),
+		"wrong secret":  signHS256(t, []byte("another-secret"), "", validClaims()),
+		"tampered":      tampered,
+		"malformed":     "not-a-jwt",
+		"unknown kid":   signHS256(t, secret, "missing", validClaims()),
+		"unsigned body": encode(t, map[string]string{"alg": "none"}) + "." + encode(t, validClaims()) + ".",
+	} {
+		_, err := v.Verify(token)
+		require.ErrorIs(t, err, auth.ErrInvalidToken, name)
+	}
+
+	// Within the leeway an expired token is still accepted
+	expired["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	_, err = v.Verify(signHS256(t, secret, "", expired))
+	require.NoError(t, err)
+}
@@ -2,31 +2,104 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/kiremitrov123/onboarding/commenting/api"
+	"github.com/kiremitrov123/onboarding/commenting/auth"
 	"github.com/kiremitrov123/onboarding/commenting/db"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/outbox"
+	"github.com/kiremitrov123/onboarding/commenting/reconcile"
 	"github.com/kiremitrov123/onboarding/commenting/redis"
 	"github.com/kiremitrov123/onboarding/commenting/service"
+	"github.com/kiremitrov123/onboarding/commenting/stream"
+	"github.com/kiremitrov123/onboarding/commenting/votes"
+	"github.com/kiremitrov123/onboarding/commenting/webhooks"
 )
 
 type Config struct {
-	DBURL     string
-	RedisAddr string
-	HTTPAddr  string
+	DBURL       string
+	RedisAddr   string
+	HTTPAddr    string
+	BannedWords []string
+	RateLimits  api.RateLimits
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+	// AuthInsecure allows running without JWKSFile for local development: the API then trusts the
+	// user_id of requests and disables the moderation and webhook management endpoints
+	AuthInsecure bool
+	SchemaCheck  bool
+	// ReconcileInterval is how often the cache is checked for drift from the database, 0 to never
+	ReconcileInterval time.Duration
+	MetricsAddr       string
+	// VoteWriteBehind records reaction counter deltas beside the reactions and flushes them every VoteFlushInterval
+	VoteWriteBehind   bool
+	VoteFlushInterval time.Duration
 }
 
-func loadConfig() Config {
-	return Config{
-		DBURL:     getEnv("DATABASE_URL", "postgresql://root@localhost:26257/commenting?sslmode=disable"),
-		RedisAddr: getEnv("REDIS_ADDR", "redis:6379"),
-		HTTPAddr:  getEnv("HTTP_ADDR", ":8080"),
+func loadConfig() (Config, error) {
+	cfg := Config{
+		DBURL:       getEnv("DATABASE_URL", "postgresql://root@localhost:26257/commenting?sslmode=disable"),
+		RedisAddr:   getEnv("REDIS_ADDR", "redis:6379"),
+		HTTPAddr:    getEnv("HTTP_ADDR", ":8080"),
+		BannedWords: strings.Split(getEnv("BANNED_WORDS", ""), ","),
+		JWKSFile:    getEnv("JWKS_FILE", ""),
+		JWTIssuer:   getEnv("JWT_ISSUER", ""),
+		JWTAudience: getEnv("JWT_AUDIENCE", ""),
+
+		AuthInsecure: getEnv("AUTH_INSECURE", "false") == "true",
+		SchemaCheck:  getEnv("SCHEMA_CHECK", "true") == "true",
+		MetricsAddr:  getEnv("METRICS_ADDR", ":9090"),
+
+		VoteWriteBehind: getEnv("VOTE_WRITE_BEHIND", "false") == "true",
+	}
+
+	interval, err := time.ParseDuration(getEnv("RECONCILE_INTERVAL", "5m"))
+	if err != nil {
+		return Config{}, fmt.Errorf("RECONCILE_INTERVAL: %w", err)
 	}
+	cfg.ReconcileInterval = interval
+
+	flushInterval, err := time.ParseDuration(getEnv("VOTE_FLUSH_INTERVAL", "250ms"))
+	if err != nil {
+		return Config{}, fmt.Errorf("VOTE_FLUSH_INTERVAL: %w", err)
+	}
+	if flushInterval <= 0 {
+		return Config{}, fmt.Errorf("VOTE_FLUSH_INTERVAL: must be positive")
+	}
+	cfg.VoteFlushInterval = flushInterval
+
+	if cfg.JWKSFile == "" && !cfg.AuthInsecure {
+		return Config{}, fmt.Errorf("JWKS_FILE: required unless AUTH_INSECURE=true")
+	}
+
+	// Budgets are written as "{limit}/{period}"; a limit of 0 turns a budget off
+	limits := []struct {
+		key, fallback string
+		dst           *model.RateLimit
+	}{
+		{"RATE_LIMIT_CREATE_USER", "10/1m", &cfg.RateLimits.CreatePerUser},
+		{"RATE_LIMIT_CREATE_THREAD", "120/1m", &cfg.RateLimits.CreatePerThread},
+		{"RATE_LIMIT_REACT_USER", "60/1m", &cfg.RateLimits.ReactPerUser},
+		{"RATE_LIMIT_REACT_THREAD", "600/1m", &cfg.RateLimits.ReactPerThread},
+	}
+	for _, l := range limits {
+		limit, err := model.ParseRateLimit(getEnv(l.key, l.fallback))
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", l.key, err)
+		}
+		*l.dst = limit
+	}
+
+	return cfg, nil
 }
 
 func getEnv(key, fallback string) string {
@@ -41,9 +114,18 @@ func main() {
 	defer stop()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	cfg := loadConfig()
+	cfg, err := loadConfig()
+	if err != nil {
+		logger.Error("invalid configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
 
-	pg, err := db.NewPostgres(ctx, cfg.DBURL)
+	// Refuse to serve against a schema that cmd/migrate hasn't brought up to date
+	var pgOpts []db.PostgresOption
+	if cfg.SchemaCheck {
+		pgOpts = append(pgOpts, db.WithSchemaCheck())
+	}
+	pg, err := db.NewPostgres(ctx, cfg.DBURL, pgOpts...)
 	if err != nil {
 		logger.Error("failed to connect to database", slog.Any("error", err))
 		os.Exit(1)
@@ -56,8 +138,72 @@ func main() {
 	}
 
 	repo := db.NewRepo(pg.DB())
-	svc := service.NewCommentService(repo, redisCache)
-	apiHandler := api.NewAPI(svc, logger)
+
+	// The hub follows the thread streams the relay publishes to and fans them out to live clients
+	hub := stream.NewHub(redisCache, logger)
+	go hub.Run(ctx)
+
+	hooks := webhooks.NewService(repo, &http.Client{Timeout: 10 * time.Second}, logger)
+	svcOpts := []service.Option{
+		service.WithLogger(logger),
+		service.WithEventStream(hub),
+		service.WithContentPolicies(
+			service.LengthPolicy{Min: 1, Max: 10000},
+			service.NewBannedWordsPolicy(cfg.BannedWords, service.VerdictReject),
+			service.LinkPolicy{Max: 10, Flag: 3},
+			service.DuplicatePolicy{Recent: repo.ListRecentUserComments, Window: 10 * time.Minute, Limit: 20},
+		),
+	}
+	if cfg.VoteWriteBehind {
+		svcOpts = append(svcOpts, service.WithWriteBehindVotes())
+	}
+	svc := service.NewCommentService(repo, redisCache, svcOpts...)
+	apiOpts := []api.Option{api.WithRateLimit(redisCache, cfg.RateLimits)}
+
+	// Without a key set (AUTH_INSECURE) the API falls back to trusting the user_id of request bodies
+	if cfg.JWKSFile != "" {
+		verifier, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			logger.Error("failed to load JWKS", slog.Any("error", err))
+			os.Exit(1)
+		}
+		verifier.Issuer = cfg.JWTIssuer
+		verifier.Audience = cfg.JWTAudience
+		apiOpts = append(apiOpts, api.WithAuth(verifier))
+	} else {
+		logger.Warn("AUTH_INSECURE set, requests are not authenticated and privileged endpoints are disabled")
+	}
+	apiHandler := api.NewAPI(svc, hooks, logger, apiOpts...)
+
+	// The relay drains the outbox into Redis, publishes the events and queues them for webhooks
+	relay := outbox.NewRelay(repo, redisCache, redisCache, hooks, logger)
+	go relay.Run(ctx)
+
+	// The flusher writes the counters recorded in write-behind mode to the database. It also runs with
+	// the mode off, so that votes still recorded from before it was turned off are counted.
+	flusher := votes.NewFlusher(repo, logger)
+	flusher.Interval = cfg.VoteFlushInterval
+	go flusher.Run(ctx)
+
+	// The dispatcher delivers queued events to webhook subscribers
+	go hooks.Run(ctx)
+
+	// The reconciler repairs cached comments that drifted from the database
+	reconciler := reconcile.NewReconciler(repo, redisCache, logger)
+	if cfg.ReconcileInterval > 0 {
+		reconciler.Interval = cfg.ReconcileInterval
+		go reconciler.Run(ctx)
+	}
+
+	// Metrics are served apart from the API, so they don't go through its authentication
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("GET /metrics", reconciler.Metrics)
+	metricsServer := &http.Server{Addr: cfg.MetricsAddr, Handler: metricsMux, ReadTimeout: 5 * time.Second}
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("could not start metrics server", slog.Any("error", err))
+		}
+	}()
 
 	httpServer := &http.Server{
 		Addr:         cfg.HTTPAddr,
@@ -72,6 +218,7 @@ func main() {
 		logger.Info("shutting down gracefully")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
+		_ = metricsServer.Shutdown(shutdownCtx)
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			logger.Error("forced shutdown", slog.Any("error", err))
 			os.Exit(1)
@@ -0,0 +1,119 @@
+// Command migrate applies, reverts and lists the commenting schema migrations.
+//
+//	migrate up          apply every pending migration
+//	migrate down [n]    revert the last n applied migrations (default 1)
+//	migrate status      list the migrations and when they were applied
+//
+// It connects to DATABASE_URL, like the API.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kiremitrov123/onboarding/commenting/db"
+)
+
+var errUsage = errors.New("usage: migrate up | down [n] | status")
+
+func getEnv(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := run(ctx, logger, os.Args[1:]); err != nil {
+		logger.Error("migrate failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, logger *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return errUsage
+		}
+	case "down":
+		if len(args) > 2 {
+			return errUsage
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("down needs a positive number of migrations, got %q", args[1])
+			}
+			steps = n
+		}
+	default:
+		return errUsage
+	}
+
+	pg, err := db.NewPostgres(ctx, getEnv("DATABASE_URL", "postgresql://root@localhost:26257/commenting?sslmode=disable"))
+	if err != nil {
+		return err
+	}
+	defer pg.DB().Close()
+
+	migrator, err := db.NewMigrator(pg.DB())
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			logger.Info("applied migration", slog.Int("version", m.Version), slog.String("name", m.Name))
+		}
+		if err != nil {
+			return err
+		}
+		logger.Info("schema is up to date", slog.Int("version", migrator.Latest()))
+
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		for _, m := range done {
+			logger.Info("reverted migration", slog.Int("version", m.Version), slog.String("name", m.Name))
+		}
+		if err != nil {
+			return err
+		}
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Command reconcile runs one pass of the cache reconciler and prints its report as JSON.
+//
+//	reconcile [-sample n] [-batch n] [-dry-run]
+//
+// By default it scans every cached comment. It connects to DATABASE_URL and REDIS_ADDR, like the API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kiremitrov123/onboarding/commenting/db"
+	"github.com/kiremitrov123/onboarding/commenting/reconcile"
+	"github.com/kiremitrov123/onboarding/commenting/redis"
+)
+
+func getEnv(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+func main() {
+	sample := flag.Int("sample", 0, "check at most this many cached comments, 0 for all of them")
+	batch := flag.Int("batch", 100, "cached comments read per SCAN")
+	dryRun := flag.Bool("dry-run", false, "report drift without repairing it")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	pg, err := db.NewPostgres(ctx, getEnv("DATABASE_URL", "postgresql://root@localhost:26257/commenting?sslmode=disable"))
+	if err != nil {
+		logger.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer pg.DB().Close()
+
+	redisCache, err := redis.NewCache(ctx, getEnv("REDIS_ADDR", "redis:6379"))
+	if err != nil {
+		logger.Error("failed to connect to Redis", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	reconciler := reconcile.NewReconciler(db.NewRepo(pg.DB()), redisCache, logger)
+	reconciler.SampleSize = *sample
+	reconciler.BatchSize = *batch
+	reconciler.DryRun = *dryRun
+
+	report, err := reconciler.RunOnce(ctx)
+	// Print what was done even if the pass stopped early
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+	if err != nil {
+		logger.Error("reconciliation failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,382 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// migrationFS holds the schema migrations, compiled into every binary that uses the database.
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// ErrSchemaOutdated is returned when the database schema is older than the binary expects.
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+const (
+	// migrationLockTTL is how long a migration lock holds without being refreshed,
+	// so that a migrator that died while holding it doesn't block migrations for good.
+	migrationLockTTL = 5 * time.Minute
+	// migrationLockPoll is how often a migrator waiting for the lock tries again.
+	migrationLockPoll = time.Second
+)
+
+// migrationFile matches migration file names: {version}_{name}.{up|down}.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one versioned change to the schema, with the SQL that applies and reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, or nil if it is pending.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads the migrations in dir of fsys, ordered by version.
+// Every version needs both an up and a down file, and versions count up from 1 without gaps.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			return nil, fmt.Errorf("unexpected file %s in migrations", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+
+		data, err := fs.ReadFile(fsys, dir+"/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrator applies and reverts the embedded migrations, recording them in the schema_migrations table.
+// Up and Down hold a lock in the schema_migrations_lock table, so concurrent migrators take turns.
+type Migrator struct {
+	db         *bun.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *bun.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest migration, the version a fully migrated schema is at.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the newest applied migration, or 0 for a database that was never migrated.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// CheckVersion fails with ErrSchemaOutdated unless every migration of this binary has been applied.
+// A newer schema is accepted, so that binaries keep running while a migration rolls out.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: at version %d, expected %d", ErrSchemaOutdated, version, m.Latest())
+	}
+	return nil
+}
+
+// Status lists every migration with when it was applied, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := MigrationStatus{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			status.AppliedAt = &at
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
+
+// Up applies every pending migration in order and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, mig, true); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts up to steps of the applied migrations, newest first, and returns the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, mig, false); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// apply runs the up or down SQL of a migration one statement at a time, then records the change.
+//
+// CockroachDB doesn't apply schema changes atomically with the rest of a transaction, so the statements
+// are not wrapped in one: each commits on its own, and a backfill only runs once the columns it fills
+// have been added. A migration that fails part way is left unrecorded and runs again from its first
+// statement, so every statement must be safe to repeat, with IF [NOT] EXISTS on schema changes and
+// backfills that skip the rows they already changed.
+func (m *Migrator) apply(ctx context.Context, mig Migration, up bool) error {
+	script := mig.Down
+	if up {
+		script = mig.Up
+	}
+
+	for i, stmt := range splitStatements(script) {
+		// Without arguments the statement is sent as is
+		if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %d_%s, statement %d: %w", mig.Version, mig.Name, i+1, err)
+		}
+	}
+
+	var err error
+	if up {
+		_, err = m.db.NewInsert().
+			Model(&SchemaMigrationEntity{Version: mig.Version, Name: mig.Name}).
+			Exec(ctx)
+	} else {
+		_, err = m.db.NewDelete().
+			Model((*SchemaMigrationEntity)(nil)).
+			Where("version = ?", mig.Version).
+			Exec(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// splitStatements splits a migration script into its statements, each ending with a semicolon at the end
+// of a line. Comments are kept with the statement that follows them; trailing comments are dropped.
+func splitStatements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	hasCode := false
+
+	for _, line := range strings.Split(script, "\n") {
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		hasCode = true
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(current.String()))
+			current.Reset()
+			hasCode = false
+		}
+	}
+	if hasCode {
+		stmts = append(stmts, strings.TrimSpace(current.String()))
+	}
+	return stmts
+}
+
+// applied returns when each applied migration was applied, by version.
+// A database without a schema_migrations table has none applied.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	var entities []SchemaMigrationEntity
+	err := m.db.NewSelect().
+		Model(&entities).
+		Scan(ctx)
+	if isUndefinedTable(err) {
+		return map[int]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[int]time.Time, len(entities))
+	for _, e := range entities {
+		out[e.Version] = e.AppliedAt
+	}
+	return out, nil
+}
+
+// withLock runs fn while holding the migration lock, creating the bookkeeping tables first.
+// It waits while another migrator holds the lock, and keeps refreshing the lock while fn runs.
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	for _, table := range []any{(*SchemaMigrationEntity)(nil), (*MigrationLockEntity)(nil)} {
+		if _, err := m.db.NewCreateTable().Model(table).IfNotExists().Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	owner := uuid.NewString()
+	for {
+		locked, err := m.tryLock(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+
+	// Refresh the lock until fn returns, so a long migration doesn't lose it
+	refreshCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		ticker := time.NewTicker(migrationLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-refreshCtx.Done():
+				return
+			case <-ticker.C:
+				_, _ = m.tryLock(refreshCtx, owner)
+			}
+		}
+	}()
+
+	defer func() {
+		// Release even if ctx was cancelled mid-migration
+		_, _ = m.db.NewDelete().
+			Model((*MigrationLockEntity)(nil)).
+			Where("id = 1").
+			Where("owner = ?", owner).
+			Exec(context.WithoutCancel(ctx))
+	}()
+
+	return fn(ctx)
+}
+
+// tryLock takes or refreshes the migration lock for owner. A lock held by someone else is only
+// taken over once it has gone unrefreshed for migrationLockTTL.
+func (m *Migrator) tryLock(ctx context.Context, owner string) (bool, error) {
+	res, err := m.db.NewInsert().
+		Model(&MigrationLockEntity{ID: 1, Owner: owner, LockedAt: time.Now().UTC()}).
+		On("CONFLICT (id) DO UPDATE").
+		Set("owner = EXCLUDED.owner").
+		Set("locked_at = EXCLUDED.locked_at").
+		Where("schema_migrations_lock.owner = ? OR schema_migrations_lock.locked_at < ?", owner, time.Now().UTC().Add(-migrationLockTTL)).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+// isUndefinedTable reports whether err is a query on a table that doesn't exist.
+func isUndefinedTable(err error) bool {
+	var pgErr pgdriver.Error
+	return errors.As(err, &pgErr) && pgErr.Field('C') == "42P01"
+}
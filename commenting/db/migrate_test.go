@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFS, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	require.Equal(t, 1, migrations[0].Version)
+	require.Equal(t, "initial", migrations[0].Name)
+	require.Contains(t, migrations[0].Up, "CREATE TABLE IF NOT EXISTS comments")
+	// The initial migration is the schema from before migrations, later columns come in their own
+	require.NotContains(t, migrations[0].Up, "deleted_at")
+	require.Greater(t, len(migrations), 1)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"m/0001_a.up.sql": file("SELECT 1"),
+		},
+		"gap": {
+			"m/0001_a.up.sql": file("SELECT 1"), "m/0001_a.down.sql": file("SELECT 1"),
+			"m/0003_c.up.sql": file("SELECT 1"), "m/0003_c.down.sql": file("SELECT 1"),
+		},
+		"two names": {
+			"m/0001_a.up.sql": file("SELECT 1"), "m/0001_b.down.sql": file("SELECT 1"),
+		},
+		"stray file": {
+			"m/0001_a.up.sql": file("SELECT 1"), "m/0001_a.down.sql": file("SELECT 1"),
+			"m/README.md": file("notes"),
+		},
+	}
+	for name, fsys := range cases {
+		_, err := LoadMigrations(fsys, "m")
+		require.Error(t, err, name)
+	}
+
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"m/0002_b.up.sql": file("UP 2"), "m/0002_b.down.sql": file("DOWN 2"),
+		"m/0001_a.up.sql": file("UP 1"), "m/0001_a.down.sql": file("DOWN 1"),
+	}, "m")
+	require.NoError(t, err)
+	require.Equal(t, []Migration{
+		{Version: 1, Name: "a", Up: "UP 1", Down: "DOWN 1"},
+		{Version: 2, Name: "b", Up: "UP 2", Down: "DOWN 2"},
+	}, migrations)
+}
+
+func TestMigrator_UpIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	migrator, err := NewMigrator(testRepo.DB)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	// Everything is applied now, so a second run has nothing to do
+	done, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, done)
+	require.NoError(t, migrator.CheckVersion(ctx))
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range status {
+		require.NotNil(t, s.AppliedAt, "migration %d", s.Version)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Adds a column
+ALTER TABLE t ADD COLUMN IF NOT EXISTS a INT;
+
+-- Backfills it
+UPDATE t
+SET a = 1
+WHERE a IS NULL;
+SELECT 'x;y'
+-- trailing note
+`
+	require.Equal(t, []string{
+		"-- Adds a column\nALTER TABLE t ADD COLUMN IF NOT EXISTS a INT;",
+		"-- Backfills it\nUPDATE t\nSET a = 1\nWHERE a IS NULL;",
+		"SELECT 'x;y'\n-- trailing note",
+	}, splitStatements(script))
+	require.Empty(t, splitStatements("-- nothing to run\n"))
+}
+
+func TestMigrator_RerunsFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	const version = 9001
+
+	t.Cleanup(func() {
+		_, _ = testRepo.DB.ExecContext(ctx, "DROP TABLE IF EXISTS migrate_rerun_test")
+		_, _ = testRepo.DB.NewDelete().Model((*SchemaMigrationEntity)(nil)).Where("version = ?", version).Exec(ctx)
+	})
+
+	failing := Migration{
+		Version: version,
+		Name:    "rerun",
+		Up: `CREATE TABLE IF NOT EXISTS migrate_rerun_test (id INT PRIMARY KEY, n INT NOT NULL DEFAULT 0);
+INSERT INTO migrate_rerun_test (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+SELECT 1 / 0;`,
+		Down: "DROP TABLE IF EXISTS migrate_rerun_test;",
+	}
+	migrator := &Migrator{db: testRepo.DB, migrations: []Migration{failing}}
+
+	// The statements before the failing one stay applied, but the migration isn't recorded
+	_, err := migrator.Up(ctx)
+	require.Error(t, err)
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Nil(t, status[0].AppliedAt)
+
+	// Once fixed, the migration runs again from the top without repeating its effects
+	fixed := failing
+	fixed.Up = strings.Replace(failing.Up, "SELECT 1 / 0;", "UPDATE migrate_rerun_test SET n = n + 1 WHERE n = 0;", 1)
+	migrator.migrations = []Migration{fixed}
+	done, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+
+	var rows []struct {
+		ID int
+		N  int
+	}
+	require.NoError(t, testRepo.DB.NewSelect().Table("migrate_rerun_test").Scan(ctx, &rows))
+	require.Len(t, rows, 1)
+	require.Equal(t, 1, rows[0].N)
+}
+
+func TestMigration_ExclusiveVotesKeepsNewestVote(t *testing.T) {
+	ctx := context.Background()
+	const version = 9002
+
+	migrations, err := LoadMigrations(migrationFS, "migrations")
+	require.NoError(t, err)
+	exclusive := migrations[5]
+	require.Equal(t, "exclusive_votes", exclusive.Name)
+
+	t.Cleanup(func() {
+		_, _ = testRepo.DB.NewDelete().Model((*SchemaMigrationEntity)(nil)).Where("version = ?", version).Exec(ctx)
+	})
+
+	// Recreate data from before votes were exclusive: one user both upvoted and then downvoted
+	_, err = testRepo.DB.ExecContext(ctx, "DROP INDEX IF EXISTS comment_reactions@idx_comment_reactions_vote CASCADE")
+	require.NoError(t, err)
+	comment := insertTestComment(t, uuid.New(), 1)
+	_, err = testRepo.DB.NewUpdate().Model((*CommentEntity)(nil)).Set("downvotes = 1").Where("id = ?", comment.ID).Exec(ctx)
+	require.NoError(t, err)
+	now := time.Now()
+	votes := []ReactionEntity{
+		{ID: uuid.New(), CommentID: comment.ID, UserID: "voter", Type: "upvote", CreatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), CommentID: comment.ID, UserID: "voter", Type: "downvote", CreatedAt: now},
+	}
+	_, err = testRepo.DB.NewInsert().Model(&votes).Exec(ctx)
+	require.NoError(t, err)
+
+	migrator := &Migrator{db: testRepo.DB, migrations: []Migration{{Version: version, Name: exclusive.Name, Up: exclusive.Up}}}
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	var kept []ReactionEntity
+	require.NoError(t, testRepo.DB.NewSelect().Model(&kept).Where("comment_id = ?", comment.ID).Scan(ctx))
+	require.Len(t, kept, 1)
+	require.Equal(t, "downvote", kept[0].Type)
+
+	stored, err := testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, stored.Upvotes)
+	require.Equal(t, 1, stored.Downvotes)
+}
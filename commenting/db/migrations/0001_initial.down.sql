@@ -0,0 +1,3 @@
+-- Drops every table of the initial schema, dependents first
+DROP TABLE IF EXISTS comment_reactions;
+DROP TABLE IF EXISTS comments;
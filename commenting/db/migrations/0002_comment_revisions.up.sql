@@ -0,0 +1,15 @@
+-- Revision counter and edit time of each comment
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+
+-- Revisions table, one row per previous version of an edited comment
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    comment_id  UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    revision    INT NOT NULL,
+    user_id     TEXT NOT NULL,
+    content     TEXT NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT current_timestamp,
+
+    UNIQUE (comment_id, revision)
+);
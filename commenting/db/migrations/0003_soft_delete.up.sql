@@ -0,0 +1,6 @@
+-- Deleted comments stay behind as tombstones
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Replies outlive their parent's tombstone, so deleting a parent no longer cascades to them
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS comments_parent_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES comments(id);
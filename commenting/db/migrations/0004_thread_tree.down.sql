@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS comments@idx_comments_thread_path;
+ALTER TABLE comments DROP COLUMN IF EXISTS path;
+ALTER TABLE comments DROP COLUMN IF EXISTS depth;
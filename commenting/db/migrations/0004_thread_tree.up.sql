@@ -0,0 +1,18 @@
+-- Depth and materialized path of each comment: the path segments of its ancestors and itself, see model.PathSegment
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth INT NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS path STRING NOT NULL DEFAULT '';
+
+-- Materialized path index for serving a whole reply tree in path order
+CREATE INDEX IF NOT EXISTS idx_comments_thread_path ON comments(thread_id, path);
+
+-- Backfill the path and depth of existing comments by walking down from the top-level comments.
+-- Only rows still without a path are written, so rerunning it is harmless.
+WITH RECURSIVE tree (id, path, depth) AS (
+    SELECT id, id::STRING || '/', 0 FROM comments WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, tree.path || c.id::STRING || '/', tree.depth + 1
+    FROM comments c JOIN tree ON c.parent_id = tree.id
+)
+UPDATE comments SET path = tree.path, depth = tree.depth
+FROM tree
+WHERE comments.id = tree.id AND comments.path = '';
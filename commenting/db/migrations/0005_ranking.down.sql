@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS comments@idx_comments_thread_hot;
+DROP INDEX IF EXISTS comments@idx_comments_thread_controversy;
+DROP INDEX IF EXISTS comments@idx_comments_thread_best;
+DROP INDEX IF EXISTS comments@idx_comments_thread_score;
+DROP INDEX IF EXISTS comments@idx_comments_thread_likes;
+ALTER TABLE comments DROP COLUMN IF EXISTS hot;
+ALTER TABLE comments DROP COLUMN IF EXISTS controversy;
+ALTER TABLE comments DROP COLUMN IF EXISTS best;
+ALTER TABLE comments DROP COLUMN IF EXISTS score;
@@ -0,0 +1,26 @@
+-- Derived rankings, kept by the database so listings can page through them by index
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS score INT AS (upvotes - downvotes) STORED;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS best FLOAT8 AS (
+    CASE WHEN upvotes + downvotes = 0 THEN 0
+    ELSE (upvotes::FLOAT8 / (upvotes + downvotes) + 1.9208 / (upvotes + downvotes)
+          - 1.96 * sqrt(upvotes::FLOAT8 * downvotes / (upvotes + downvotes) + 0.9604) / (upvotes + downvotes))
+         / (1 + 3.8416 / (upvotes + downvotes))
+    END
+) STORED;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS controversy FLOAT8 AS (
+    CASE WHEN upvotes <= 0 OR downvotes <= 0 THEN 0
+    ELSE pow((upvotes + downvotes)::FLOAT8,
+             CASE WHEN upvotes > downvotes THEN downvotes::FLOAT8 / upvotes ELSE upvotes::FLOAT8 / downvotes END)
+    END
+) STORED;
+-- Time-decayed ranking, computed only here; see model.NetScore
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS hot FLOAT8 AS (
+    sign(upvotes - downvotes)::FLOAT8 * log(greatest(abs(upvotes - downvotes), 1)::FLOAT8)
+    + extract(epoch FROM (created_at - '2005-12-08 07:46:43+00'::TIMESTAMPTZ)) / 45000
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_comments_thread_likes ON comments(thread_id, likes DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_thread_score ON comments(thread_id, score DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_thread_best ON comments(thread_id, best DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_thread_controversy ON comments(thread_id, controversy DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_thread_hot ON comments(thread_id, hot DESC);
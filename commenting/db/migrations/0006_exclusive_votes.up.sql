@@ -0,0 +1,34 @@
+-- Before votes were exclusive a user could both upvote and downvote a comment. Only their newest vote
+-- is kept: first the counters of the affected comments are set to what the kept votes add up to, then
+-- the older votes are deleted. Both steps give the same result when rerun.
+WITH ranked AS (
+    SELECT comment_id, type,
+           row_number() OVER (PARTITION BY comment_id, user_id ORDER BY created_at DESC, id DESC) AS n
+    FROM comment_reactions
+    WHERE type IN ('upvote', 'downvote')
+),
+kept AS (
+    SELECT comment_id,
+           count(*) FILTER (WHERE type = 'upvote') AS upvotes,
+           count(*) FILTER (WHERE type = 'downvote') AS downvotes
+    FROM ranked
+    WHERE n = 1 AND comment_id IN (SELECT comment_id FROM ranked WHERE n > 1)
+    GROUP BY comment_id
+)
+UPDATE comments SET upvotes = kept.upvotes, downvotes = kept.downvotes
+FROM kept
+WHERE comments.id = kept.comment_id;
+
+DELETE FROM comment_reactions
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (PARTITION BY comment_id, user_id ORDER BY created_at DESC, id DESC) AS n
+        FROM comment_reactions
+        WHERE type IN ('upvote', 'downvote')
+    ) AS ranked
+    WHERE n > 1
+);
+
+-- Up and down votes are mutually exclusive: at most one vote per user per comment
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reactions_vote ON comment_reactions(comment_id, user_id)
+    WHERE type IN ('upvote', 'downvote');
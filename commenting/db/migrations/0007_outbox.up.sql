@@ -0,0 +1,15 @@
+-- Outbox table, written in the same transaction as each comment or reaction mutation
+-- and drained by the relay into the cache and the event channel
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id            UUID PRIMARY KEY,
+    type          TEXT NOT NULL,
+    thread_id     UUID NOT NULL,
+    comment_id    UUID NOT NULL,
+    payload       JSONB NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    processed_at  TIMESTAMPTZ
+);
+
+-- Index to quickly fetch pending events in order
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(created_at, id)
+    WHERE processed_at IS NULL;
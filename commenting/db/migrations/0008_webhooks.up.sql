@@ -0,0 +1,37 @@
+-- Webhook subscriptions, an empty event_types receives every event
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url          TEXT NOT NULL,
+    secret       TEXT NOT NULL,
+    event_types  TEXT[] NOT NULL DEFAULT '{}',
+    active       BOOLEAN NOT NULL DEFAULT true,
+    created_at   TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- Pending webhook deliveries, one row per event and subscription
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id  UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event            JSONB NOT NULL,
+    attempts         INT NOT NULL DEFAULT 0,
+    last_error       TEXT NOT NULL DEFAULT '',
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    created_at       TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- Index to quickly fetch the deliveries that are due
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at);
+
+-- Deliveries that ran out of attempts, kept until they are replayed
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id               UUID PRIMARY KEY,
+    subscription_id  UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event            JSONB NOT NULL,
+    attempts         INT NOT NULL,
+    last_error       TEXT NOT NULL,
+    failed_at        TIMESTAMPTZ DEFAULT current_timestamp,
+    created_at       TIMESTAMPTZ NOT NULL
+);
+
+-- Index to quickly fetch dead letters per subscription
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_subscription ON webhook_dead_letters(subscription_id);
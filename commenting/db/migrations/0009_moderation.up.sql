@@ -0,0 +1,29 @@
+-- Hidden comments stay in place but show placeholder content
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMPTZ;
+
+-- User reports on comments, open until a moderator acts on the comment
+CREATE TABLE IF NOT EXISTS comment_reports (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    comment_id   UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id      TEXT NOT NULL,
+    reason       TEXT NOT NULL,
+    resolved_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- At most one open report per user and comment, also used to build the moderation queue
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reports_open ON comment_reports(comment_id, user_id)
+    WHERE resolved_at IS NULL;
+
+-- Audit trail of moderator decisions
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    comment_id    UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    moderator_id  TEXT NOT NULL,
+    action        TEXT NOT NULL,
+    note          TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- Index to quickly fetch the audit trail per comment
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_comment ON moderation_actions(comment_id);
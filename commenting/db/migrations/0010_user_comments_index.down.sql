@@ -0,0 +1 @@
+DROP INDEX IF EXISTS comments@idx_comments_user_created;
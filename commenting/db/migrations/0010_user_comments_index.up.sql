@@ -0,0 +1,2 @@
+-- Index for a user's recent comments, used by the duplicate-content policy
+CREATE INDEX IF NOT EXISTS idx_comments_user_created ON comments(user_id, created_at DESC);
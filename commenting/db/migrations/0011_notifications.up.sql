@@ -0,0 +1,17 @@
+-- Per-user inbox of mentions and replies
+CREATE TABLE IF NOT EXISTS notifications (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     TEXT NOT NULL,
+    type        TEXT NOT NULL,
+    actor_id    TEXT NOT NULL,
+    comment_id  UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    thread_id   UUID NOT NULL,
+    read_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- Index for paging a user's inbox newest first
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC, id DESC);
+
+-- Partial index for counting and clearing unread notifications
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
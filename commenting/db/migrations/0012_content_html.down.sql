@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN IF EXISTS format;
+ALTER TABLE comments DROP COLUMN IF EXISTS content_html;
@@ -0,0 +1,3 @@
+-- Sanitized HTML rendering of each comment and the format it was written in
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS format TEXT NOT NULL DEFAULT 'markdown';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS comments@idx_comments_content_tsv;
+ALTER TABLE comments DROP COLUMN IF EXISTS content_tsv;
@@ -0,0 +1,5 @@
+-- Search document for full-text search, see db.SearchComments
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_tsv TSVECTOR AS (to_tsvector('english', content)) STORED;
+
+-- Inverted index for full-text search
+CREATE INVERTED INDEX IF NOT EXISTS idx_comments_content_tsv ON comments(content_tsv);
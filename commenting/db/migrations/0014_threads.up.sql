@@ -0,0 +1,11 @@
+-- Threads, one per external subject such as an article URL or product ID. A comment's thread_id
+-- is a thread from this table, or the root comment's ID for comments created before threads existed.
+CREATE TABLE IF NOT EXISTS threads (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subject_key    TEXT NOT NULL UNIQUE,
+    title          TEXT NOT NULL DEFAULT '',
+    comment_count  INT NOT NULL DEFAULT 0,
+    locked         BOOLEAN NOT NULL DEFAULT false,
+    pinned_ids     UUID[] NOT NULL DEFAULT '{}',
+    created_at     TIMESTAMPTZ DEFAULT current_timestamp
+);
@@ -0,0 +1,17 @@
+-- Counter changes of write-behind reactions, written in the reaction's transaction together with its
+-- reaction.changed event. The flusher adds them to the comments in batches and deletes them.
+CREATE TABLE IF NOT EXISTS vote_deltas (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    comment_id  UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    upvotes     INT NOT NULL DEFAULT 0,
+    downvotes   INT NOT NULL DEFAULT 0,
+    likes       INT NOT NULL DEFAULT 0,
+    event       JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+-- Index for draining the oldest deltas first
+CREATE INDEX IF NOT EXISTS idx_vote_deltas_created ON vote_deltas(created_at, id);
+
+-- Index for finding the comments with deltas still to be flushed
+CREATE INDEX IF NOT EXISTS idx_vote_deltas_comment ON vote_deltas(comment_id);
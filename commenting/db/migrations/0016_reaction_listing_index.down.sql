@@ -0,0 +1 @@
+DROP INDEX IF EXISTS comment_reactions@idx_comment_reactions_listing;
@@ -0,0 +1,2 @@
+-- Keyset index for listing a comment's reactions newest first, see db.ListReactions
+CREATE INDEX IF NOT EXISTS idx_comment_reactions_listing ON comment_reactions(comment_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS outbox_events@idx_outbox_events_processed;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS claimed_until;
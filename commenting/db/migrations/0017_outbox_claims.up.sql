@@ -0,0 +1,6 @@
+-- Lease on a pending outbox event, so that only one relay handles it at a time
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
+
+-- Index to quickly find processed events past their retention
+CREATE INDEX IF NOT EXISTS idx_outbox_events_processed ON outbox_events(processed_at)
+    WHERE processed_at IS NOT NULL;
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,19 +9,48 @@ import (
 	"github.com/uptrace/bun"
 )
 
+type ThreadEntity struct {
+	bun.BaseModel `bun:"table:threads"`
+
+	ID           uuid.UUID `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	SubjectKey   string    `bun:",notnull"`
+	Title        string    `bun:",notnull"`
+	CommentCount int       `bun:",notnull,default:0"`
+	Locked       bool      `bun:",notnull"`
+	PinnedIDs    []string  `bun:",array"`
+	CreatedAt    time.Time `bun:",nullzero,default::now()"`
+}
+
+// commentColumns returns the columns CommentEntity maps. RETURNING * would also return content_tsv,
+// which the entity has no field for.
+const commentColumns = "?Columns"
+
 type CommentEntity struct {
 	bun.BaseModel `bun:"table:comments"`
 
-	ID         uuid.UUID  `bun:",pk,type:uuid,default:gen_random_uuid()"`
-	ParentID   *uuid.UUID `bun:",nullzero"`
-	ThreadID   uuid.UUID  `bun:",notnull"`
-	UserID     string     `bun:",notnull"`
-	Content    string     `bun:",notnull"`
-	ReplyCount int        `bun:",notnull,default:0"`
-	Upvotes    int        `bun:",notnull,default:0"`
-	Downvotes  int        `bun:",notnull,default:0"`
-	Likes      int        `bun:",notnull,default:0"`
-	CreatedAt  time.Time  `bun:",nullzero,default::now()"`
+	ID          uuid.UUID  `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	ParentID    *uuid.UUID `bun:",nullzero"`
+	ThreadID    uuid.UUID  `bun:",notnull"`
+	UserID      string     `bun:",notnull"`
+	Content     string     `bun:",notnull"`
+	ContentHTML string     `bun:",notnull"`
+	Format      string     `bun:",notnull"`
+	ReplyCount  int        `bun:",notnull,default:0"`
+	Upvotes     int        `bun:",notnull,default:0"`
+	Downvotes   int        `bun:",notnull,default:0"`
+	Likes       int        `bun:",notnull,default:0"`
+	// Ranking columns computed by the database, see model.SortScore
+	Score       int        `bun:",scanonly"`
+	Best        float64    `bun:",scanonly"`
+	Controversy float64    `bun:",scanonly"`
+	Hot         float64    `bun:",scanonly"`
+	Depth       int        `bun:",notnull,default:0"`
+	Path        string     `bun:",notnull"`
+	Revision    int        `bun:",notnull,default:0"`
+	EditedAt    *time.Time `bun:",nullzero"`
+	DeletedAt   *time.Time `bun:",nullzero"`
+	HiddenAt    *time.Time `bun:",nullzero"`
+	CreatedAt   time.Time  `bun:",nullzero,default::now()"`
 }
 
 type ReactionEntity struct {
@@ -33,18 +63,171 @@ type ReactionEntity struct {
 	CreatedAt time.Time `bun:",nullzero,default::now()"`
 }
 
+type RevisionEntity struct {
+	bun.BaseModel `bun:"table:comment_revisions"`
+
+	ID        uuid.UUID `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	CommentID uuid.UUID `bun:",notnull"`
+	Revision  int       `bun:",notnull"`
+	UserID    string    `bun:",notnull"`
+	Content   string    `bun:",notnull"`
+	CreatedAt time.Time `bun:",nullzero,default::now()"`
+}
+
+type ReportEntity struct {
+	bun.BaseModel `bun:"table:comment_reports"`
+
+	ID         uuid.UUID  `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	CommentID  uuid.UUID  `bun:",notnull"`
+	UserID     string     `bun:",notnull"`
+	Reason     string     `bun:",notnull"`
+	ResolvedAt *time.Time `bun:",nullzero"`
+	CreatedAt  time.Time  `bun:",nullzero,default::now()"`
+}
+
+type ModerationActionEntity struct {
+	bun.BaseModel `bun:"table:moderation_actions"`
+
+	ID          uuid.UUID `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	CommentID   uuid.UUID `bun:",notnull"`
+	ModeratorID string    `bun:",notnull"`
+	Action      string    `bun:",notnull"`
+	Note        string    `bun:",notnull"`
+	CreatedAt   time.Time `bun:",nullzero,default::now()"`
+}
+
+type NotificationEntity struct {
+	bun.BaseModel `bun:"table:notifications"`
+
+	ID        uuid.UUID  `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	UserID    string     `bun:",notnull"`
+	Type      string     `bun:",notnull"`
+	ActorID   string     `bun:",notnull"`
+	CommentID uuid.UUID  `bun:",notnull"`
+	ThreadID  uuid.UUID  `bun:",notnull"`
+	ReadAt    *time.Time `bun:",nullzero"`
+	CreatedAt time.Time  `bun:",nullzero,default::now()"`
+}
+
+type OutboxEntity struct {
+	bun.BaseModel `bun:"table:outbox_events"`
+
+	ID           uuid.UUID       `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	Type         string          `bun:",notnull"`
+	ThreadID     uuid.UUID       `bun:",notnull"`
+	CommentID    uuid.UUID       `bun:",notnull"`
+	Payload      json.RawMessage `bun:"type:jsonb,notnull"`
+	CreatedAt    time.Time       `bun:",nullzero,default::now()"`
+	ProcessedAt  *time.Time      `bun:",nullzero"`
+	ClaimedUntil *time.Time      `bun:",nullzero"`
+}
+
+type WebhookSubscriptionEntity struct {
+	bun.BaseModel `bun:"table:webhook_subscriptions"`
+
+	ID         uuid.UUID `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	URL        string    `bun:",notnull"`
+	Secret     string    `bun:",notnull"`
+	EventTypes []string  `bun:",array"`
+	Active     bool      `bun:",notnull"`
+	CreatedAt  time.Time `bun:",nullzero,default::now()"`
+}
+
+type WebhookDeliveryEntity struct {
+	bun.BaseModel `bun:"table:webhook_deliveries"`
+
+	ID             uuid.UUID   `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	SubscriptionID uuid.UUID   `bun:",notnull"`
+	Event          model.Event `bun:"type:jsonb,notnull"`
+	Attempts       int         `bun:",notnull,default:0"`
+	LastError      string      `bun:",notnull,default:''"`
+	NextAttemptAt  time.Time   `bun:",nullzero,default::now()"`
+	CreatedAt      time.Time   `bun:",nullzero,default::now()"`
+}
+
+type WebhookDeadLetterEntity struct {
+	bun.BaseModel `bun:"table:webhook_dead_letters"`
+
+	ID             uuid.UUID   `bun:",pk,type:uuid"`
+	SubscriptionID uuid.UUID   `bun:",notnull"`
+	Event          model.Event `bun:"type:jsonb,notnull"`
+	Attempts       int         `bun:",notnull"`
+	LastError      string      `bun:",notnull"`
+	FailedAt       time.Time   `bun:",nullzero,default::now()"`
+	CreatedAt      time.Time   `bun:",notnull"`
+}
+
+type SchemaMigrationEntity struct {
+	bun.BaseModel `bun:"table:schema_migrations"`
+
+	Version   int       `bun:",pk"`
+	Name      string    `bun:",notnull"`
+	AppliedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// MigrationLockEntity is the single row of the migration lock, present while a migrator holds it.
+type MigrationLockEntity struct {
+	bun.BaseModel `bun:"table:schema_migrations_lock"`
+
+	ID       int       `bun:",pk"`
+	Owner    string    `bun:",notnull"`
+	LockedAt time.Time `bun:",notnull"`
+}
+
+// VoteDeltaEntity is the counter change of a write-behind reaction, waiting to be flushed to its comment.
+type VoteDeltaEntity struct {
+	bun.BaseModel `bun:"table:vote_deltas"`
+
+	ID        uuid.UUID       `bun:",pk,type:uuid,default:gen_random_uuid()"`
+	CommentID uuid.UUID       `bun:",notnull,type:uuid"`
+	Upvotes   int             `bun:",notnull"`
+	Downvotes int             `bun:",notnull"`
+	Likes     int             `bun:",notnull"`
+	Event     json.RawMessage `bun:"type:jsonb,notnull"`
+	CreatedAt time.Time       `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+func (t ThreadEntity) APIThread() model.Thread {
+	pinned := make([]uuid.UUID, 0, len(t.PinnedIDs))
+	for _, s := range t.PinnedIDs {
+		if id, err := uuid.Parse(s); err == nil {
+			pinned = append(pinned, id)
+		}
+	}
+	return model.Thread{
+		ID:               t.ID,
+		SubjectKey:       t.SubjectKey,
+		Title:            t.Title,
+		CommentCount:     t.CommentCount,
+		Locked:           t.Locked,
+		PinnedCommentIDs: pinned,
+		CreatedAt:        t.CreatedAt,
+	}
+}
+
 func (c CommentEntity) APIComment() model.Comment {
 	return model.Comment{
-		ID:         c.ID,
-		ParentID:   c.ParentID,
-		ThreadID:   c.ThreadID,
-		UserID:     c.UserID,
-		Content:    c.Content,
-		ReplyCount: c.ReplyCount,
-		Upvotes:    c.Upvotes,
-		Downvotes:  c.Downvotes,
-		Likes:      c.Likes,
-		CreatedAt:  c.CreatedAt,
+		ID:          c.ID,
+		ParentID:    c.ParentID,
+		ThreadID:    c.ThreadID,
+		UserID:      c.UserID,
+		Content:     c.Content,
+		ContentHTML: c.ContentHTML,
+		Format:      c.Format,
+		ReplyCount:  c.ReplyCount,
+		Upvotes:     c.Upvotes,
+		Downvotes:   c.Downvotes,
+		Likes:       c.Likes,
+		Best:        c.Best,
+		Controversy: c.Controversy,
+		Hot:         c.Hot,
+		Depth:       c.Depth,
+		Path:        c.Path,
+		Revision:    c.Revision,
+		EditedAt:    c.EditedAt,
+		DeletedAt:   c.DeletedAt,
+		HiddenAt:    c.HiddenAt,
+		CreatedAt:   c.CreatedAt,
 	}
 }
 
@@ -57,3 +240,85 @@ func (r ReactionEntity) APIReaction() model.Reaction {
 		CreatedAt: r.CreatedAt,
 	}
 }
+
+func (r RevisionEntity) APIRevision() model.Revision {
+	return model.Revision{
+		ID:        r.ID,
+		CommentID: r.CommentID,
+		Revision:  r.Revision,
+		UserID:    r.UserID,
+		Content:   r.Content,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+func (o OutboxEntity) APIEvent() model.Event {
+	return model.Event{
+		ID:        o.ID,
+		Type:      o.Type,
+		ThreadID:  o.ThreadID,
+		CommentID: o.CommentID,
+		Payload:   o.Payload,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+func (w WebhookSubscriptionEntity) APISubscription() model.WebhookSubscription {
+	return model.WebhookSubscription{
+		ID:         w.ID,
+		URL:        w.URL,
+		Secret:     w.Secret,
+		EventTypes: w.EventTypes,
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+func (d WebhookDeliveryEntity) APIDelivery() model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		Event:          d.Event,
+		Attempts:       d.Attempts,
+		LastError:      d.LastError,
+		NextAttemptAt:  d.NextAttemptAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+func (d WebhookDeadLetterEntity) APIDelivery() model.WebhookDelivery {
+	failedAt := d.FailedAt
+	return model.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		Event:          d.Event,
+		Attempts:       d.Attempts,
+		LastError:      d.LastError,
+		FailedAt:       &failedAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+func (m ModerationActionEntity) APIModerationAction() model.ModerationAction {
+	return model.ModerationAction{
+		ID:          m.ID,
+		CommentID:   m.CommentID,
+		ModeratorID: m.ModeratorID,
+		Action:      m.Action,
+		Note:        m.Note,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func (n NotificationEntity) APINotification() model.Notification {
+	return model.Notification{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		Type:      n.Type,
+		ActorID:   n.ActorID,
+		CommentID: n.CommentID,
+		ThreadID:  n.ThreadID,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+)
+
+// CreateReport flags a comment for moderation. A user has at most one open report per comment,
+// so reporting the same comment again before a moderator acts is a no-op.
+func (r *Repo) CreateReport(ctx context.Context, report *model.Report) error {
+	return insertReports(ctx, r.DB, []model.Report{*report})
+}
+
+// insertReports files reports, skipping those whose reporter already has an open report on the comment.
+func insertReports(ctx context.Context, db bun.IDB, reports []model.Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	entities := make([]ReportEntity, 0, len(reports))
+	for _, report := range reports {
+		entities = append(entities, ReportEntity{
+			ID:        report.ID,
+			CommentID: report.CommentID,
+			UserID:    report.UserID,
+			Reason:    report.Reason,
+			CreatedAt: report.CreatedAt,
+		})
+	}
+	_, err := db.NewInsert().
+		Model(&entities).
+		On("CONFLICT (comment_id, user_id) WHERE resolved_at IS NULL DO NOTHING").
+		Exec(ctx)
+	return err
+}
+
+// ListModerationQueue returns the comments with open reports, most reported first.
+func (r *Repo) ListModerationQueue(ctx context.Context, limit int) ([]model.QueueItem, error) {
+	var rows []struct {
+		CommentID      uuid.UUID `bun:"comment_id"`
+		ReportCount    int       `bun:"report_count"`
+		Reasons        []string  `bun:"reasons,array"`
+		LastReportedAt time.Time `bun:"last_reported_at"`
+	}
+	err := r.DB.NewSelect().
+		Model((*ReportEntity)(nil)).
+		Column("comment_id").
+		ColumnExpr("count(*) AS report_count").
+		ColumnExpr("array_agg(DISTINCT reason) AS reasons").
+		ColumnExpr("max(created_at) AS last_reported_at").
+		Where("resolved_at IS NULL").
+		Group("comment_id").
+		OrderExpr("report_count DESC, last_reported_at DESC").
+		Limit(limit).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []model.QueueItem{}, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.CommentID)
+	}
+
+	var entities []CommentEntity
+	err = r.DB.NewSelect().
+		Model(&entities).
+		Where("id IN (?)", bun.In(ids)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make(map[uuid.UUID]model.Comment, len(entities))
+	for _, e := range entities {
+		comments[e.ID] = e.APIComment()
+	}
+
+	out := make([]model.QueueItem, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, model.QueueItem{
+			Comment:        comments[row.CommentID],
+			ReportCount:    row.ReportCount,
+			Reasons:        row.Reasons,
+			LastReportedAt: row.LastReportedAt,
+		})
+	}
+	return out, nil
+}
+
+// ModerateComment applies a moderator's decision in one transaction: approve clears hidden_at, hide sets it
+// and remove soft-deletes the comment. The comment's open reports are resolved, the action is recorded in
+// the audit table and an outbox event is written if the comment changed. It reports whether it changed.
+func (r *Repo) ModerateComment(ctx context.Context, commentID uuid.UUID, moderatorID, action, note string) (*model.Comment, bool, error) {
+	var entity CommentEntity
+	var changed bool
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		changed = false
+
+		err := tx.NewSelect().
+			Model(&entity).
+			Where("id = ?", commentID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case action == model.ModerationHide && entity.HiddenAt == nil:
+			err = setHidden(ctx, tx, &entity, "now()")
+			changed = true
+		case action == model.ModerationApprove && entity.HiddenAt != nil && entity.DeletedAt == nil:
+			err = setHidden(ctx, tx, &entity, "NULL")
+			changed = true
+		case action == model.ModerationRemove && entity.DeletedAt == nil:
+			err = softDelete(ctx, tx, &entity)
+			changed = true
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*ReportEntity)(nil)).
+			Set("resolved_at = now()").
+			Where("comment_id = ?", commentID).
+			Where("resolved_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		audit := ModerationActionEntity{
+			CommentID:   commentID,
+			ModeratorID: moderatorID,
+			Action:      action,
+			Note:        note,
+		}
+		_, err = tx.NewInsert().Model(&audit).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	comment := entity.APIComment()
+	return &comment, changed, nil
+}
+
+// setHidden sets a comment's hidden_at to the SQL expression value and records a comment.updated event.
+func setHidden(ctx context.Context, tx bun.Tx, entity *CommentEntity, value string) error {
+	_, err := tx.NewUpdate().
+		Model(entity).
+		Set("hidden_at = ?", bun.Safe(value)).
+		Where("id = ?", entity.ID).
+		Returning(commentColumns).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return insertCommentUpdated(ctx, tx, entity)
+}
+
+// ListModerationActions returns the audit trail of a comment, newest first.
+func (r *Repo) ListModerationActions(ctx context.Context, commentID uuid.UUID) ([]model.ModerationAction, error) {
+	var entities []ModerationActionEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Where("comment_id = ?", commentID).
+		Order("created_at DESC", "id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.ModerationAction, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIModerationAction())
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+)
+
+// CreateNotifications stores a batch of notifications.
+func (r *Repo) CreateNotifications(ctx context.Context, notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	entities := make([]NotificationEntity, 0, len(notifications))
+	for _, n := range notifications {
+		entities = append(entities, NotificationEntity{
+			ID:        n.ID,
+			UserID:    n.UserID,
+			Type:      n.Type,
+			ActorID:   n.ActorID,
+			CommentID: n.CommentID,
+			ThreadID:  n.ThreadID,
+			CreatedAt: n.CreatedAt,
+		})
+	}
+	_, err := r.DB.NewInsert().Model(&entities).Exec(ctx)
+	return err
+}
+
+// ListExistingUsers reports which of the given users exist, as far as the database knows them:
+// users are only known from the comments they have written.
+func (r *Repo) ListExistingUsers(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	out := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+
+	var users []string
+	err := r.DB.NewSelect().
+		Model((*CommentEntity)(nil)).
+		Column("user_id").
+		Where("user_id IN (?)", bun.In(userIDs)).
+		Group("user_id").
+		Scan(ctx, &users)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		out[user] = true
+	}
+	return out, nil
+}
+
+// ListNotifications returns up to limit of a user's notifications after the cursor, newest first.
+func (r *Repo) ListNotifications(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error) {
+	var entities []NotificationEntity
+
+	q := r.DB.NewSelect().
+		Model(&entities).
+		Where("user_id = ?", userID)
+
+	if cursor != nil {
+		q = q.Where("(created_at, id) < (?, ?)", cursor.Time(), cursor.ID)
+	}
+
+	err := q.
+		Order("created_at DESC", "id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.Notification, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APINotification())
+	}
+	return out, nil
+}
+
+// CountUnreadNotifications returns how many of a user's notifications are unread.
+func (r *Repo) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	return r.DB.NewSelect().
+		Model((*NotificationEntity)(nil)).
+		Where("user_id = ?", userID).
+		Where("read_at IS NULL").
+		Count(ctx)
+}
+
+// MarkNotificationsRead marks the given unread notifications of a user as read, or all of them
+// when ids is empty, and returns how many changed.
+func (r *Repo) MarkNotificationsRead(ctx context.Context, userID string, ids []uuid.UUID) (int, error) {
+	q := r.DB.NewUpdate().
+		Model((*NotificationEntity)(nil)).
+		Set("read_at = now()").
+		Where("user_id = ?", userID).
+		Where("read_at IS NULL")
+
+	if len(ids) > 0 {
+		q = q.Where("id IN (?)", bun.In(ids))
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+)
+
+// insertEvent writes an event to the outbox. It is called inside the transaction
+// of the mutation it describes, so the event exists if and only if the change committed.
+func insertEvent(ctx context.Context, db bun.IDB, eventType string, threadID, commentID uuid.UUID, payload any) error {
+	event, err := model.NewEvent(eventType, threadID, commentID, payload)
+	if err != nil {
+		return err
+	}
+	return insertEvents(ctx, db, event)
+}
+
+// insertCommentUpdated records a comment.updated event for a comment. The payload leaves out
+// the content of a hidden comment, as it is delivered to SSE clients and webhook subscribers.
+func insertCommentUpdated(ctx context.Context, db bun.IDB, entity *CommentEntity) error {
+	comment := entity.APIComment()
+	comment.MaskHidden()
+	return insertEvent(ctx, db, model.EventCommentUpdated, entity.ThreadID, entity.ID, comment)
+}
+
+// insertEvents writes already built events to the outbox.
+func insertEvents(ctx context.Context, db bun.IDB, events ...model.Event) error {
+	entities := make([]OutboxEntity, 0, len(events))
+	for _, event := range events {
+		entities = append(entities, OutboxEntity{
+			ID:        event.ID,
+			Type:      event.Type,
+			ThreadID:  event.ThreadID,
+			CommentID: event.CommentID,
+			Payload:   event.Payload,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	_, err := db.NewInsert().Model(&entities).Exec(ctx)
+	return err
+}
+
+// ClaimPendingEvents returns up to limit unprocessed outbox events, oldest first, that no other relay
+// holds, and leases them for the given duration. Events a relay fails to process are claimed again
+// once their lease runs out.
+func (r *Repo) ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+	pending := r.DB.NewSelect().
+		Model((*OutboxEntity)(nil)).
+		Column("id").
+		Where("processed_at IS NULL").
+		Where("claimed_until IS NULL OR claimed_until <= now()").
+		Order("created_at ASC", "id ASC").
+		Limit(limit)
+
+	var entities []OutboxEntity
+	_, err := r.DB.NewUpdate().
+		Model(&entities).
+		Set("claimed_until = now() + ?::INTERVAL", lease.String()).
+		Where("id IN (?)", pending).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(entities, func(i, j int) bool {
+		if !entities[i].CreatedAt.Equal(entities[j].CreatedAt) {
+			return entities[i].CreatedAt.Before(entities[j].CreatedAt)
+		}
+		return bytes.Compare(entities[i].ID[:], entities[j].ID[:]) < 0
+	})
+
+	out := make([]model.Event, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIEvent())
+	}
+	return out, nil
+}
+
+// MarkEventsProcessed acknowledges outbox events so the relay doesn't pick them up again.
+func (r *Repo) MarkEventsProcessed(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.DB.NewUpdate().
+		Model((*OutboxEntity)(nil)).
+		Set("processed_at = now()").
+		Where("id IN (?)", bun.In(ids)).
+		Exec(ctx)
+	return err
+}
+
+// PurgeProcessedEvents deletes up to limit events that were processed before the given time
+// and returns how many were deleted.
+func (r *Repo) PurgeProcessedEvents(ctx context.Context, before time.Time, limit int) (int, error) {
+	expired := r.DB.NewSelect().
+		Model((*OutboxEntity)(nil)).
+		Column("id").
+		Where("processed_at < ?", before).
+		Limit(limit)
+
+	res, err := r.DB.NewDelete().
+		Model((*OutboxEntity)(nil)).
+		Where("id IN (?)", expired).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
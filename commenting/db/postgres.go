@@ -21,14 +21,44 @@ func (p *Postgres) DB() *bun.DB {
 	return p.bun
 }
 
+// PostgresOption configures an optional check of NewPostgres.
+type PostgresOption func(*postgresOptions)
+
+type postgresOptions struct {
+	checkSchema bool
+}
+
+// WithSchemaCheck makes NewPostgres fail with ErrSchemaOutdated
+// unless every migration built into the binary has been applied.
+func WithSchemaCheck() PostgresOption {
+	return func(o *postgresOptions) { o.checkSchema = true }
+}
+
 // NewPostgres connects to the database via pgdriver
 // pings to ensure the connection is up and running
-func NewPostgres(ctx context.Context, dsn string) (*Postgres, error) {
+func NewPostgres(ctx context.Context, dsn string, opts ...PostgresOption) (*Postgres, error) {
+	var o postgresOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	db, err := waitForDB(ctx, dsn, 10, 2*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
+	if o.checkSchema {
+		migrator, err := NewMigrator(db)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		if err := migrator.CheckVersion(ctx); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("check schema: %w", err)
+		}
+	}
+
 	return &Postgres{
 		bun: db,
 	}, nil
@@ -2,7 +2,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,22 +21,58 @@ func NewRepo(db *bun.DB) *Repo {
 	return &Repo{DB: db}
 }
 
-// CreateComment inserts a new comment into the database.
-func (r *Repo) CreateComment(ctx context.Context, comment *model.Comment) error {
+// CreateComment inserts a new comment, bumps the parent's reply count for replies and the thread's
+// comment count, files the given reports on it and records a comment.created outbox event, all in
+// one transaction. It fails with model.ErrThreadLocked if the thread is locked, and with model.ErrNotFound
+// if a top-level comment joins a thread that doesn't exist rather than starting its own.
+func (r *Repo) CreateComment(ctx context.Context, comment *model.Comment, reports []model.Report) error {
 	entity := CommentEntity{
-		ID:         comment.ID,
-		ParentID:   comment.ParentID,
-		ThreadID:   comment.ThreadID,
-		UserID:     comment.UserID,
-		Content:    comment.Content,
-		ReplyCount: comment.ReplyCount,
-		Upvotes:    comment.Upvotes,
-		Downvotes:  comment.Downvotes,
-		Likes:      comment.Likes,
-		CreatedAt:  comment.CreatedAt,
-	}
-	_, err := r.DB.NewInsert().Model(&entity).Exec(ctx)
-	return err
+		ID:          comment.ID,
+		ParentID:    comment.ParentID,
+		ThreadID:    comment.ThreadID,
+		UserID:      comment.UserID,
+		Content:     comment.Content,
+		ContentHTML: comment.ContentHTML,
+		Format:      comment.Format,
+		ReplyCount:  comment.ReplyCount,
+		Upvotes:     comment.Upvotes,
+		Downvotes:   comment.Downvotes,
+		Likes:       comment.Likes,
+		Depth:       comment.Depth,
+		Path:        comment.Path,
+		CreatedAt:   comment.CreatedAt,
+	}
+
+	return r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		// The rankings are computed by the database, so they are read back with the row
+		if _, err := tx.NewInsert().Model(&entity).Returning("best, controversy, hot").Exec(ctx); err != nil {
+			return err
+		}
+		comment.Best, comment.Controversy, comment.Hot = entity.Best, entity.Controversy, entity.Hot
+
+		if comment.ParentID != nil {
+			if err := incrementReplyCount(ctx, tx, *comment.ParentID); err != nil {
+				return err
+			}
+		}
+
+		// Checking the lock in the same transaction keeps a concurrent lock from letting a comment through
+		found, locked, err := adjustCommentCount(ctx, tx, comment.ThreadID, +1)
+		if err != nil {
+			return err
+		}
+		if !found && comment.ParentID == nil && comment.ThreadID != comment.ID {
+			return model.ErrNotFound
+		}
+		if locked {
+			return model.ErrThreadLocked
+		}
+
+		if err := insertReports(ctx, tx, reports); err != nil {
+			return err
+		}
+		return insertEvent(ctx, tx, model.EventCommentCreated, comment.ThreadID, comment.ID, comment)
+	})
 }
 
 // GetCommentByID retrieves a comment record from the database.
@@ -44,27 +83,183 @@ func (r *Repo) GetCommentByID(ctx context.Context, commentID uuid.UUID) (*model.
 		Where("id = ?", commentID).
 		Limit(1).
 		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.Comment{
-		ID:         entity.ID,
-		ParentID:   entity.ParentID,
-		ThreadID:   entity.ThreadID,
-		UserID:     entity.UserID,
-		Content:    entity.Content,
-		ReplyCount: entity.ReplyCount,
-		Upvotes:    entity.Upvotes,
-		Downvotes:  entity.Downvotes,
-		Likes:      entity.Likes,
-		CreatedAt:  entity.CreatedAt,
-	}, nil
+	comment := entity.APIComment()
+	return &comment, nil
 }
 
-// IncrementReplyCount increases the reply count by 1 for a parent comment.
-func (r *Repo) IncrementReplyCount(ctx context.Context, parentID uuid.UUID) error {
-	_, err := r.DB.NewUpdate().
+// ListRecentUserComments returns up to limit of a user's comments created since the given time, newest first.
+func (r *Repo) ListRecentUserComments(ctx context.Context, userID string, since time.Time, limit int) ([]model.Comment, error) {
+	var entities []CommentEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Where("user_id = ?", userID).
+		Where("created_at >= ?", since).
+		Order("created_at DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.Comment, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIComment())
+	}
+	return out, nil
+}
+
+// UpdateComment replaces the content of a comment and its rendering, keeps the previous version as a revision
+// and files the given reports on it, in one transaction.
+func (r *Repo) UpdateComment(ctx context.Context, commentID uuid.UUID, content, contentHTML string, reports []model.Report) (*model.Comment, error) {
+	var entity CommentEntity
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().
+			Model(&entity).
+			Where("id = ?", commentID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// The revision keeps the content as it was before this edit
+		revision := RevisionEntity{
+			CommentID: entity.ID,
+			Revision:  entity.Revision,
+			UserID:    entity.UserID,
+			Content:   entity.Content,
+			CreatedAt: entity.CreatedAt,
+		}
+		if entity.EditedAt != nil {
+			revision.CreatedAt = *entity.EditedAt
+		}
+		if _, err := tx.NewInsert().Model(&revision).Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model(&entity).
+			Set("content = ?", content).
+			Set("content_html = ?", contentHTML).
+			Set("revision = revision + 1").
+			Set("edited_at = now()").
+			Where("id = ?", commentID).
+			Returning(commentColumns).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := insertReports(ctx, tx, reports); err != nil {
+			return err
+		}
+		return insertCommentUpdated(ctx, tx, &entity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comment := entity.APIComment()
+	return &comment, nil
+}
+
+// DeleteComment soft-deletes a comment: the content is replaced with a tombstone and deleted_at is set.
+// Replies are kept and the parent's reply count is decremented. Deleting twice is a no-op.
+func (r *Repo) DeleteComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, bool, error) {
+	var entity CommentEntity
+	var deleted bool
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		deleted = false
+		err := tx.NewSelect().
+			Model(&entity).
+			Where("id = ?", commentID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		if err != nil || entity.DeletedAt != nil {
+			return err
+		}
+		deleted = true
+		return softDelete(ctx, tx, &entity)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	comment := entity.APIComment()
+	return &comment, deleted, nil
+}
+
+// softDelete tombstones a comment locked by the caller's transaction, decrements the parent's
+// reply count and the thread's comment count, and records the comment.deleted event.
+// The entity is refreshed with the new row.
+func softDelete(ctx context.Context, tx bun.Tx, entity *CommentEntity) error {
+	_, err := tx.NewUpdate().
+		Model(entity).
+		Set("content = ?", model.DeletedContent).
+		Set("content_html = ?", model.DeletedContentHTML).
+		Set("deleted_at = now()").
+		Where("id = ?", entity.ID).
+		Returning(commentColumns).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if entity.ParentID != nil {
+		_, err = tx.NewUpdate().
+			Model((*CommentEntity)(nil)).
+			Where("id = ?", *entity.ParentID).
+			Set("reply_count = reply_count - 1").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := adjustCommentCount(ctx, tx, entity.ThreadID, -1); err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, tx, model.EventCommentDeleted, entity.ThreadID, entity.ID, entity.APIComment())
+}
+
+// ListRevisions returns the previous versions of a comment, newest first.
+func (r *Repo) ListRevisions(ctx context.Context, commentID uuid.UUID) ([]model.Revision, error) {
+	var entities []RevisionEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Where("comment_id = ?", commentID).
+		Order("revision DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.Revision, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIRevision())
+	}
+	return out, nil
+}
+
+// incrementReplyCount increases the reply count by 1 for a parent comment.
+func incrementReplyCount(ctx context.Context, db bun.IDB, parentID uuid.UUID) error {
+	_, err := db.NewUpdate().
 		Model((*CommentEntity)(nil)).
 		Where("id = ?", parentID).
 		Set("reply_count = reply_count + 1").
@@ -72,8 +267,25 @@ func (r *Repo) IncrementReplyCount(ctx context.Context, parentID uuid.UUID) erro
 	return err
 }
 
+// floatSortFields are the computed ranking columns stored as FLOAT8; every other sort column is an INT.
+var floatSortFields = map[string]bool{
+	"best":        true,
+	"controversy": true,
+	"hot":         true,
+}
+
+// scoreArg converts a cursor score to the type of the sort column it is compared against.
+func scoreArg(sortField string, score float64) any {
+	if floatSortFields[sortField] {
+		return score
+	}
+	return int64(score)
+}
+
 // ListCommentsSorted fetches comments by thread ID sorted by the specified field.
-func (r *Repo) ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor int64, limit int) ([]model.Comment, error) {
+// Pages are keyed by (field, created_at, id) so comments sharing a score are never skipped.
+// A backward cursor returns the page right before the cursor, still in descending order.
+func (r *Repo) ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor *model.Cursor, limit int) ([]model.Comment, error) {
 	if limit == 0 {
 		return []model.Comment{}, nil
 	}
@@ -82,21 +294,31 @@ func (r *Repo) ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortF
 
 	q := r.DB.NewSelect().
 		Model(&entities).
-		Where("thread_id = ?", threadID)
+		Where("thread_id = ?", threadID).
+		Where("deleted_at IS NULL").
+		Where("hidden_at IS NULL")
+
+	backward := cursor != nil && cursor.Backward
+	cmp, dir := "<", "DESC"
+	if backward {
+		cmp, dir = ">", "ASC"
+	}
 
 	// Pagination cursor
-	if cursor > 0 {
+	if cursor != nil {
 		if sortField == "created_at" {
-			t := time.Unix(0, cursor)
-			q = q.Where("created_at < ?", t)
+			q = q.Where(fmt.Sprintf("(created_at, id) %s (?, ?)", cmp), cursor.Time(), cursor.ID)
 		} else {
-			q = q.Where(fmt.Sprintf("%s < ?", sortField), cursor)
+			q = q.Where(fmt.Sprintf("(%s, created_at, id) %s (?, ?, ?)", sortField, cmp), scoreArg(sortField, cursor.Score), cursor.Time(), cursor.ID)
 		}
 	}
 
 	// Order + Limit
+	if sortField != "created_at" {
+		q = q.Order(fmt.Sprintf("%s %s", sortField, dir))
+	}
 	err := q.
-		Order(fmt.Sprintf("%s DESC", sortField)).
+		Order("created_at "+dir, "id "+dir).
 		Limit(limit).
 		Scan(ctx)
 
@@ -108,11 +330,276 @@ func (r *Repo) ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortF
 	for _, e := range entities {
 		out = append(out, e.APIComment())
 	}
+	if backward {
+		slices.Reverse(out)
+	}
 	return out, nil
 }
 
-// AddReaction stores a new reaction in the database.
-func (r *Repo) AddReaction(ctx context.Context, reaction *model.Reaction) (bool, error) {
+// ListThreadTree fetches up to limit comments of a thread down to maxDepth, ordered by materialized path,
+// so that parents always come before their replies. It also returns, for each comment at maxDepth,
+// how many descendants were cut off. Both are answered with a single query each.
+func (r *Repo) ListThreadTree(ctx context.Context, threadID uuid.UUID, maxDepth, limit int) ([]model.Comment, map[uuid.UUID]int, error) {
+	var entities []CommentEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Where("thread_id = ?", threadID).
+		Where("depth <= ?", maxDepth).
+		Order("path ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Every path segment is a UUID plus a slash, so the path prefix of a
+	// comment at maxDepth has a fixed length.
+	prefixLen := len(model.PathSegment(uuid.Nil)) * (maxDepth + 1)
+
+	var hidden []struct {
+		Prefix string `bun:"prefix"`
+		Count  int    `bun:"count"`
+	}
+	err = r.DB.NewSelect().
+		Model((*CommentEntity)(nil)).
+		ColumnExpr("substr(path, 1, ?) AS prefix", prefixLen).
+		ColumnExpr("count(*) AS count").
+		Where("thread_id = ?", threadID).
+		Where("depth > ?", maxDepth).
+		GroupExpr("prefix").
+		Scan(ctx, &hidden)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	segmentLen := len(model.PathSegment(uuid.Nil))
+	hiddenCounts := make(map[uuid.UUID]int, len(hidden))
+	for _, h := range hidden {
+		if len(h.Prefix) < segmentLen {
+			continue
+		}
+		id, err := uuid.Parse(h.Prefix[len(h.Prefix)-segmentLen : len(h.Prefix)-1])
+		if err != nil {
+			continue
+		}
+		hiddenCounts[id] = h.Count
+	}
+
+	out := make([]model.Comment, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIComment())
+	}
+	return out, hiddenCounts, nil
+}
+
+// ToggleReaction adds the reaction if the user hasn't reacted this way yet, or removes it otherwise,
+// and adjusts the comment's counter field in the same transaction, so the counters always
+// match the reaction rows. It reports whether the reaction was toggled on.
+func (r *Repo) ToggleReaction(ctx context.Context, reaction *model.Reaction, field string) (bool, error) {
+	return r.toggleReaction(ctx, reaction, field, true)
+}
+
+// RecordReaction toggles a reaction like ToggleReaction, but leaves the counter and the outbox to a
+// write-behind flush: the delta and the reaction.changed event are recorded in vote_deltas instead,
+// in the same transaction as the reaction row.
+func (r *Repo) RecordReaction(ctx context.Context, reaction *model.Reaction, field string) (bool, error) {
+	return r.toggleReaction(ctx, reaction, field, false)
+}
+
+// toggleReaction toggles a reaction and records its event. With apply set, the counter is adjusted
+// and the event written to the outbox, otherwise both are recorded as a vote delta.
+func (r *Repo) toggleReaction(ctx context.Context, reaction *model.Reaction, field string, apply bool) (bool, error) {
+	var toggledOn bool
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := checkReactable(ctx, tx, reaction.CommentID); err != nil {
+			return err
+		}
+		added, err := addReaction(ctx, tx, reaction)
+		if err != nil {
+			return err
+		}
+		toggledOn = added
+
+		delta := +1
+		if !added {
+			delta = -1
+			if err := deleteReaction(ctx, tx, reaction.CommentID, reaction.UserID, reaction.Type); err != nil {
+				return err
+			}
+		}
+
+		threadID, err := countReaction(ctx, tx, reaction.CommentID, field, delta, apply)
+		if err != nil {
+			return err
+		}
+
+		change := model.ReactionChange{
+			UserID: reaction.UserID,
+			Type:   reaction.Type,
+			Deltas: map[string]int{field: delta},
+		}
+		event, err := model.NewEvent(model.EventReactionChanged, threadID, reaction.CommentID, change)
+		if err != nil {
+			return err
+		}
+		if !apply {
+			return insertVoteDelta(ctx, tx, event, change.Deltas)
+		}
+		return insertEvents(ctx, tx, event)
+	})
+	if err != nil {
+		return false, err
+	}
+	return toggledOn, nil
+}
+
+// Vote sets the user's vote on a comment to value (+1, 0 or -1), replacing any opposite vote
+// and adjusting both counters in the same transaction. With toggle set, repeating the current
+// vote removes it instead. It returns the previous and the new vote value.
+func (r *Repo) Vote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+	return r.vote(ctx, commentID, userID, value, toggle, true)
+}
+
+// RecordVote sets the user's vote like Vote, but leaves the counters and the outbox to a write-behind
+// flush: the deltas and the reaction.changed event are recorded in vote_deltas instead, in the same
+// transaction as the reaction rows.
+func (r *Repo) RecordVote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+	return r.vote(ctx, commentID, userID, value, toggle, false)
+}
+
+// vote sets the user's vote and records its event. With apply set, the counters are adjusted
+// and the event written to the outbox, otherwise both are recorded as a vote delta.
+func (r *Repo) vote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle, apply bool) (int, int, error) {
+	var prev, next int
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := checkReactable(ctx, tx, commentID); err != nil {
+			return err
+		}
+
+		var existing []ReactionEntity
+		err := tx.NewSelect().
+			Model(&existing).
+			Where("comment_id = ?", commentID).
+			Where("user_id = ?", userID).
+			Where("type IN (?)", bun.In([]string{"upvote", "downvote"})).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		prev, next = 0, value
+		if len(existing) > 0 && existing[0].Type == "upvote" {
+			prev = 1
+		} else if len(existing) > 0 {
+			prev = -1
+		}
+		if toggle && prev == value {
+			next = 0
+		}
+		if prev == next {
+			return nil
+		}
+
+		change := model.ReactionChange{UserID: userID, Type: "vote", Deltas: map[string]int{}}
+		var threadID uuid.UUID
+
+		if prevType, prevField := model.VoteReaction(prev); prevType != "" {
+			if err := deleteReaction(ctx, tx, commentID, userID, prevType); err != nil {
+				return err
+			}
+			if threadID, err = countReaction(ctx, tx, commentID, prevField, -1, apply); err != nil {
+				return err
+			}
+			change.Deltas[prevField] = -1
+		}
+
+		if nextType, nextField := model.VoteReaction(next); nextType != "" {
+			reaction := &model.Reaction{CommentID: commentID, UserID: userID, Type: nextType}
+			if _, err := addReaction(ctx, tx, reaction); err != nil {
+				return err
+			}
+			if threadID, err = countReaction(ctx, tx, commentID, nextField, +1, apply); err != nil {
+				return err
+			}
+			change.Deltas[nextField] = +1
+		}
+
+		event, err := model.NewEvent(model.EventReactionChanged, threadID, commentID, change)
+		if err != nil {
+			return err
+		}
+		if !apply {
+			return insertVoteDelta(ctx, tx, event, change.Deltas)
+		}
+		return insertEvents(ctx, tx, event)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return prev, next, nil
+}
+
+// ListUserReactions returns the reaction types a user left on each of the given comments, in a single query.
+func (r *Repo) ListUserReactions(ctx context.Context, userID string, commentIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	out := make(map[uuid.UUID][]string)
+	if len(commentIDs) == 0 {
+		return out, nil
+	}
+
+	var entities []ReactionEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Column("comment_id", "type").
+		Where("user_id = ?", userID).
+		Where("comment_id IN (?)", bun.In(commentIDs)).
+		Order("type ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entities {
+		out[e.CommentID] = append(out[e.CommentID], e.Type)
+	}
+	return out, nil
+}
+
+// ListReactions pages through the reactions left on a comment, newest first, optionally filtered by type.
+// The keyset follows idx_comment_reactions_listing.
+func (r *Repo) ListReactions(ctx context.Context, commentID uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error) {
+	var entities []ReactionEntity
+
+	q := r.DB.NewSelect().
+		Model(&entities).
+		Where("comment_id = ?", commentID)
+
+	if reactionType != "" {
+		q = q.Where("type = ?", reactionType)
+	}
+	if cursor != nil {
+		q = q.Where("(created_at, id) < (?, ?)", cursor.Time(), cursor.ID)
+	}
+
+	err := q.
+		Order("created_at DESC", "id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.Reaction, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIReaction())
+	}
+	return out, nil
+}
+
+// addReaction stores a new reaction, reporting false if the user already reacted this way.
+func addReaction(ctx context.Context, db bun.IDB, reaction *model.Reaction) (bool, error) {
 	entity := ReactionEntity{
 		ID:        reaction.ID,
 		CommentID: reaction.CommentID,
@@ -120,7 +607,7 @@ func (r *Repo) AddReaction(ctx context.Context, reaction *model.Reaction) (bool,
 		Type:      reaction.Type,
 		CreatedAt: reaction.CreatedAt,
 	}
-	res, err := r.DB.NewInsert().
+	res, err := db.NewInsert().
 		Model(&entity).
 		On("CONFLICT (comment_id, user_id, type) DO NOTHING").
 		Exec(ctx)
@@ -133,9 +620,9 @@ func (r *Repo) AddReaction(ctx context.Context, reaction *model.Reaction) (bool,
 	return rows > 0, nil
 }
 
-// DeleteReaction removes an existing reaction in the database.
-func (r *Repo) DeleteReaction(ctx context.Context, commentID uuid.UUID, userID string, reactionType string) error {
-	_, err := r.DB.NewDelete().
+// deleteReaction removes an existing reaction.
+func deleteReaction(ctx context.Context, db bun.IDB, commentID uuid.UUID, userID string, reactionType string) error {
+	_, err := db.NewDelete().
 		Model((*ReactionEntity)(nil)).
 		Where("comment_id = ?", commentID).
 		Where("user_id = ?", userID).
@@ -144,22 +631,55 @@ func (r *Repo) DeleteReaction(ctx context.Context, commentID uuid.UUID, userID s
 	return err
 }
 
-// IncrementReactionCount increments a specific counter field (e.g. likes, upvotes).
-func (r *Repo) IncrementReactionCount(ctx context.Context, commentID uuid.UUID, field string) error {
-	_, err := r.DB.NewUpdate().
+// checkReactable fails with model.ErrNotFound unless the comment exists and is neither deleted nor hidden,
+// before a reaction row would trip the foreign key to a missing comment.
+func checkReactable(ctx context.Context, db bun.IDB, commentID uuid.UUID) error {
+	var entity CommentEntity
+	err := db.NewSelect().
+		Model(&entity).
+		Column("deleted_at", "hidden_at").
+		Where("id = ?", commentID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if entity.DeletedAt != nil || entity.HiddenAt != nil {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// countReaction adjusts a counter field by delta, unless apply is unset and the counter is left
+// to a write-behind flush, and returns the thread of the comment either way.
+func countReaction(ctx context.Context, db bun.IDB, commentID uuid.UUID, field string, delta int, apply bool) (uuid.UUID, error) {
+	if apply {
+		return adjustReactionCount(ctx, db, commentID, field, delta)
+	}
+
+	var threadID uuid.UUID
+	err := db.NewSelect().
 		Model((*CommentEntity)(nil)).
+		Column("thread_id").
 		Where("id = ?", commentID).
-		Set(fmt.Sprintf("%s = %s + 1", field, field)).
-		Exec(ctx)
-	return err
+		Scan(ctx, &threadID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, model.ErrNotFound
+	}
+	return threadID, err
 }
 
-// DecrementReactionCount decrements a specific counter field (e.g. likes, upvotes).
-func (r *Repo) DecrementReactionCount(ctx context.Context, commentID uuid.UUID, field string) error {
-	_, err := r.DB.NewUpdate().
+// adjustReactionCount adds delta to a specific counter field (e.g. likes, upvotes)
+// and returns the thread of the comment.
+func adjustReactionCount(ctx context.Context, db bun.IDB, commentID uuid.UUID, field string, delta int) (uuid.UUID, error) {
+	var threadID uuid.UUID
+	_, err := db.NewUpdate().
 		Model((*CommentEntity)(nil)).
 		Where("id = ?", commentID).
-		Set(fmt.Sprintf("%s = %s - 1", field, field)).
-		Exec(ctx)
-	return err
+		Set("? = ? + ?", bun.Ident(field), bun.Ident(field), delta).
+		Returning("thread_id").
+		Exec(ctx, &threadID)
+	return threadID, err
 }
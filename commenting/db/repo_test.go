@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,20 +32,31 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
-func insertTestComment(t *testing.T, threadID uuid.UUID, upvotes int) model.Comment {
+func insertTestComment(t testing.TB, threadID uuid.UUID, upvotes int) model.Comment {
 	comment := model.Comment{
 		ID:        uuid.New(),
 		ThreadID:  threadID,
 		UserID:    "test-user",
 		Content:   fmt.Sprintf("Comment with upvotes %d", upvotes),
 		Upvotes:   upvotes,
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
 	}
-	err := testRepo.CreateComment(context.Background(), &comment)
+	ensureTestThread(t, threadID)
+	err := testRepo.CreateComment(context.Background(), &comment, nil)
 	require.NoError(t, err)
 	return comment
 }
 
+// ensureTestThread creates a thread record with the given ID, unless it exists,
+// since top-level comments can only join threads that do.
+func ensureTestThread(t testing.TB, threadID uuid.UUID) {
+	_, err := testRepo.DB.NewInsert().
+		Model(&ThreadEntity{ID: threadID, SubjectKey: "test:" + threadID.String(), PinnedIDs: []string{}, CreatedAt: time.Now().UTC()}).
+		On("CONFLICT DO NOTHING").
+		Exec(context.Background())
+	require.NoError(t, err)
+}
+
 func TestListCommentsSorted(t *testing.T) {
 	ctx := context.Background()
 	threadID := uuid.New()
@@ -54,14 +67,15 @@ func TestListCommentsSorted(t *testing.T) {
 	c3 := insertTestComment(t, threadID, 3)
 
 	// Page 1: should get c1 and c2
-	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", 11, 2)
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 2)
 	require.NoError(t, err)
 	require.Len(t, comments, 2)
 	require.Equal(t, c1.ID, comments[0].ID)
 	require.Equal(t, c2.ID, comments[1].ID)
 
 	// Page 2: should get c3
-	comments, err = testRepo.ListCommentsSorted(ctx, threadID, "upvotes", 7, 10)
+	cursor := model.NewCursor(&comments[1], "upvotes")
+	comments, err = testRepo.ListCommentsSorted(ctx, threadID, "upvotes", &cursor, 10)
 	require.NoError(t, err)
 	require.Len(t, comments, 1)
 	require.Equal(t, c3.ID, comments[0].ID)
@@ -71,7 +85,7 @@ func TestListCommentsSorted_InvalidSortField(t *testing.T) {
 	ctx := context.Background()
 	threadID := uuid.New()
 
-	_, err := testRepo.ListCommentsSorted(ctx, threadID, "notarealfield", 0, 5)
+	_, err := testRepo.ListCommentsSorted(ctx, threadID, "notarealfield", nil, 5)
 	require.Error(t, err)
 }
 
@@ -79,7 +93,7 @@ func TestListCommentsSorted_EmptyThread(t *testing.T) {
 	ctx := context.Background()
 	threadID := uuid.New()
 
-	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", 9999, 5)
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 5)
 	require.NoError(t, err)
 	require.Len(t, comments, 0)
 }
@@ -89,18 +103,18 @@ func TestListCommentsSorted_LimitZero(t *testing.T) {
 	threadID := uuid.New()
 	insertTestComment(t, threadID, 10)
 
-	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", 9999, 0)
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 0)
 	require.NoError(t, err)
 	require.Len(t, comments, 0)
 }
 
-func TestListCommentsSorted_CursorZero(t *testing.T) {
+func TestListCommentsSorted_NoCursor(t *testing.T) {
 	ctx := context.Background()
 	threadID := uuid.New()
 	c1 := insertTestComment(t, threadID, 99)
 	c2 := insertTestComment(t, threadID, 88)
 
-	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", 0, 5)
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 5)
 	require.NoError(t, err)
 	require.Len(t, comments, 2)
 	require.Equal(t, c1.ID, comments[0].ID)
@@ -113,9 +127,591 @@ func TestListCommentsSorted_LimitGreaterThanTotal(t *testing.T) {
 	c1 := insertTestComment(t, threadID, 1)
 	c2 := insertTestComment(t, threadID, 2)
 
-	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", 9999, 10)
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 10)
 	require.NoError(t, err)
 	require.Len(t, comments, 2)
 	require.Equal(t, c2.ID, comments[0].ID)
 	require.Equal(t, c1.ID, comments[1].ID)
 }
+
+func TestListCommentsSorted_TiedScores(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+
+	seen := map[uuid.UUID]bool{}
+	for i := 0; i < 7; i++ {
+		c := insertTestComment(t, threadID, 0)
+		seen[c.ID] = false
+	}
+
+	// Every comment has 0 upvotes, paging must still visit all of them exactly once
+	var cursor *model.Cursor
+	for {
+		comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", cursor, 3)
+		require.NoError(t, err)
+		if len(comments) == 0 {
+			break
+		}
+		for _, c := range comments {
+			require.False(t, seen[c.ID], "comment returned twice")
+			seen[c.ID] = true
+		}
+		next := model.NewCursor(&comments[len(comments)-1], "upvotes")
+		cursor = &next
+	}
+
+	for id, ok := range seen {
+		require.True(t, ok, "comment %s was skipped", id)
+	}
+}
+
+func TestListCommentsSorted_StoredRankingsPageExactly(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Equal vote counts tie exactly, and the same ratio at different sizes gives near-tied floats
+	votes := [][2]int{{1, 1}, {1, 1}, {2, 2}, {3, 1}, {6, 2}, {9, 3}, {30, 10}, {300, 100}, {5, 0}, {0, 5}}
+	ensureTestThread(t, threadID)
+	for i, v := range votes {
+		c := model.Comment{
+			ID:        uuid.New(),
+			ThreadID:  threadID,
+			UserID:    "test-user",
+			Content:   fmt.Sprintf("Ranked comment %d", i),
+			Upvotes:   v[0],
+			Downvotes: v[1],
+			// Half the comments share a creation time so hot ties too
+			CreatedAt: createdAt.Add(time.Duration(i/2) * time.Microsecond),
+		}
+		require.NoError(t, testRepo.CreateComment(ctx, &c, nil))
+	}
+
+	for _, field := range []string{"best", "controversy", "hot"} {
+		all, err := testRepo.ListCommentsSorted(ctx, threadID, field, nil, len(votes))
+		require.NoError(t, err)
+		require.Len(t, all, len(votes))
+
+		// Paging two at a time with cursors built from the stored rankings visits the same order
+		var paged []uuid.UUID
+		var cursor *model.Cursor
+		for {
+			comments, err := testRepo.ListCommentsSorted(ctx, threadID, field, cursor, 2)
+			require.NoError(t, err)
+			if len(comments) == 0 {
+				break
+			}
+			for _, c := range comments {
+				paged = append(paged, c.ID)
+			}
+			next := model.NewCursor(&comments[len(comments)-1], field)
+			cursor = &next
+		}
+
+		want := make([]uuid.UUID, len(all))
+		for i, c := range all {
+			want[i] = c.ID
+		}
+		require.Equal(t, want, paged, field)
+	}
+}
+
+func TestListCommentsSorted_Backward(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	c1 := insertTestComment(t, threadID, 3)
+	c2 := insertTestComment(t, threadID, 2)
+	c3 := insertTestComment(t, threadID, 1)
+
+	cursor := model.NewCursor(&c3, "upvotes")
+	cursor.Backward = true
+
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", &cursor, 2)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	require.Equal(t, c1.ID, comments[0].ID)
+	require.Equal(t, c2.ID, comments[1].ID)
+}
+
+func TestToggleReaction_ConcurrentTogglesKeepCountersInSync(t *testing.T) {
+	ctx := context.Background()
+	comment := insertTestComment(t, uuid.New(), 0)
+
+	const users = 5
+	const togglesPerUser = 7
+
+	var wg sync.WaitGroup
+	errs := make(chan error, users*togglesPerUser)
+	for u := 0; u < users; u++ {
+		userID := fmt.Sprintf("user-%d", u)
+		for i := 0; i < togglesPerUser; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reaction := &model.Reaction{CommentID: comment.ID, UserID: userID, Type: "like"}
+				_, err := testRepo.ToggleReaction(ctx, reaction, "likes")
+				errs <- err
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	rows, err := testRepo.DB.NewSelect().
+		Model((*ReactionEntity)(nil)).
+		Where("comment_id = ?", comment.ID).
+		Where("type = ?", "like").
+		Count(ctx)
+	require.NoError(t, err)
+
+	stored, err := testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, rows, stored.Likes)
+
+	// Every user toggled an odd number of times, so everyone ends up liking it
+	require.Equal(t, users, stored.Likes)
+}
+
+func TestVote_SwitchesBetweenUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	comment := insertTestComment(t, uuid.New(), 0)
+
+	prev, next, err := testRepo.Vote(ctx, comment.ID, "voter", 1, true)
+	require.NoError(t, err)
+	require.Equal(t, 0, prev)
+	require.Equal(t, 1, next)
+
+	prev, next, err = testRepo.Vote(ctx, comment.ID, "voter", -1, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, prev)
+	require.Equal(t, -1, next)
+
+	stored, err := testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, stored.Upvotes)
+	require.Equal(t, 1, stored.Downvotes)
+
+	// Toggling the same vote again clears it
+	prev, next, err = testRepo.Vote(ctx, comment.ID, "voter", -1, true)
+	require.NoError(t, err)
+	require.Equal(t, -1, prev)
+	require.Equal(t, 0, next)
+
+	stored, err = testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, stored.Upvotes)
+	require.Equal(t, 0, stored.Downvotes)
+}
+
+func TestVote_MissingOrHiddenComment(t *testing.T) {
+	ctx := context.Background()
+
+	// Even a vote that changes nothing reports the missing comment
+	_, _, err := testRepo.Vote(ctx, uuid.New(), "voter", 0, false)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = testRepo.ToggleReaction(ctx, &model.Reaction{ID: uuid.New(), CommentID: uuid.New(), UserID: "voter", Type: "like"}, "likes")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	comment := insertTestComment(t, uuid.New(), 0)
+	_, _, err = testRepo.ModerateComment(ctx, comment.ID, "mod1", model.ModerationHide, "spam")
+	require.NoError(t, err)
+	_, _, err = testRepo.Vote(ctx, comment.ID, "voter", 1, true)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestFlushVoteDeltas_AppliesOnce(t *testing.T) {
+	ctx := context.Background()
+	comment := insertTestComment(t, uuid.New(), 0)
+
+	reaction := &model.Reaction{CommentID: comment.ID, UserID: "voter", Type: "like"}
+	toggledOn, err := testRepo.RecordReaction(ctx, reaction, "likes")
+	require.NoError(t, err)
+	require.True(t, toggledOn)
+	_, next, err := testRepo.RecordVote(ctx, comment.ID, "voter", 1, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, next)
+
+	// Recording the reactions leaves the counters to the flush
+	stored, err := testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, stored.Likes)
+	pending, err := testRepo.ListPendingVoteComments(ctx, []uuid.UUID{comment.ID, uuid.New()})
+	require.NoError(t, err)
+	require.Equal(t, map[uuid.UUID]bool{comment.ID: true}, pending)
+
+	flushed, err := testRepo.FlushVoteDeltas(ctx, 100)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, flushed, 2)
+
+	stored, err = testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.Likes)
+	require.Equal(t, 1, stored.Upvotes)
+
+	// Flushed deltas are gone, so a second flush doesn't count them again
+	pending, err = testRepo.ListPendingVoteComments(ctx, []uuid.UUID{comment.ID})
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	_, err = testRepo.FlushVoteDeltas(ctx, 100)
+	require.NoError(t, err)
+
+	stored, err = testRepo.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.Likes)
+
+	outbox, err := testRepo.DB.NewSelect().
+		Model((*OutboxEntity)(nil)).
+		Where("comment_id = ?", comment.ID).
+		Where("type = ?", model.EventReactionChanged).
+		Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, outbox)
+}
+
+// BenchmarkLikeHotComment compares likes on a single comment counted by a per-vote UPDATE with likes
+// whose counter is written behind: only the reaction and delta rows are written per vote, and the
+// deltas are flushed to the comment every flushInterval, as the flusher does.
+func BenchmarkLikeHotComment(b *testing.B) {
+	const flushInterval = 250 * time.Millisecond
+	ctx := context.Background()
+
+	b.Run("per-vote", func(b *testing.B) {
+		comment := insertTestComment(b, uuid.New(), 0)
+		var users atomic.Int64
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				reaction := &model.Reaction{CommentID: comment.ID, UserID: fmt.Sprintf("user-%d", users.Add(1)), Type: "like"}
+				if _, err := testRepo.ToggleReaction(ctx, reaction, "likes"); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("write-behind", func(b *testing.B) {
+		comment := insertTestComment(b, uuid.New(), 0)
+		var users atomic.Int64
+
+		flush := func() error {
+			for {
+				n, err := testRepo.FlushVoteDeltas(ctx, 1000)
+				if err != nil || n < 1000 {
+					return err
+				}
+			}
+		}
+
+		done := make(chan struct{})
+		flushed := make(chan error, 1)
+		go func() {
+			ticker := time.NewTicker(flushInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					flushed <- flush()
+					return
+				case <-ticker.C:
+					if err := flush(); err != nil {
+						flushed <- err
+						return
+					}
+				}
+			}
+		}()
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				reaction := &model.Reaction{CommentID: comment.ID, UserID: fmt.Sprintf("user-%d", users.Add(1)), Type: "like"}
+				if _, err := testRepo.RecordReaction(ctx, reaction, "likes"); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+		close(done)
+		require.NoError(b, <-flushed)
+		b.StopTimer()
+
+		stored, err := testRepo.GetCommentByID(ctx, comment.ID)
+		require.NoError(b, err)
+		require.Equal(b, int(users.Load()), stored.Likes)
+	})
+}
+
+func TestCreateComment_WritesOutboxEvent(t *testing.T) {
+	ctx := context.Background()
+	parent := insertTestComment(t, uuid.New(), 0)
+
+	reply := model.Comment{
+		ID:        uuid.New(),
+		ParentID:  &parent.ID,
+		ThreadID:  parent.ThreadID,
+		UserID:    "test-user",
+		Content:   "reply",
+		Depth:     1,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, testRepo.CreateComment(ctx, &reply, nil))
+
+	var events []OutboxEntity
+	err := testRepo.DB.NewSelect().
+		Model(&events).
+		Where("comment_id = ?", reply.ID).
+		Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, model.EventCommentCreated, events[0].Type)
+	require.Equal(t, parent.ThreadID, events[0].ThreadID)
+	require.Nil(t, events[0].ProcessedAt)
+
+	// The parent's reply count is bumped in the same transaction
+	stored, err := testRepo.GetCommentByID(ctx, parent.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.ReplyCount)
+
+	require.NoError(t, testRepo.MarkEventsProcessed(ctx, []uuid.UUID{events[0].ID}))
+	pending, err := testRepo.ClaimPendingEvents(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+	for _, e := range pending {
+		require.NotEqual(t, events[0].ID, e.ID)
+	}
+}
+
+func TestClaimPendingEvents_LeasesAndPurges(t *testing.T) {
+	ctx := context.Background()
+	comment := insertTestComment(t, uuid.New(), 0)
+
+	// Drain everything already pending, then only the new comment's event is left to claim
+	for {
+		claimed, err := testRepo.ClaimPendingEvents(ctx, 1000, time.Minute)
+		require.NoError(t, err)
+		if len(claimed) == 0 {
+			break
+		}
+	}
+	other := insertTestComment(t, comment.ThreadID, 0)
+
+	claimed, err := testRepo.ClaimPendingEvents(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, other.ID, claimed[0].CommentID)
+
+	// A second relay doesn't get the leased event
+	again, err := testRepo.ClaimPendingEvents(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, again)
+
+	// Once processed, the event is purged after its retention
+	require.NoError(t, testRepo.MarkEventsProcessed(ctx, []uuid.UUID{claimed[0].ID}))
+	n, err := testRepo.PurgeProcessedEvents(ctx, time.Now().Add(time.Minute), 100000)
+	require.NoError(t, err)
+	require.Positive(t, n)
+	exists, err := testRepo.DB.NewSelect().Model((*OutboxEntity)(nil)).Where("id = ?", claimed[0].ID).Exists(ctx)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestWebhookDeadLetterReplay(t *testing.T) {
+	ctx := context.Background()
+
+	sub := &model.WebhookSubscription{
+		ID:        uuid.New(),
+		URL:       "https://example.com/hook",
+		Secret:    "s3cret",
+		Active:    true,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, testRepo.CreateSubscription(ctx, sub))
+
+	event, err := model.NewEvent(model.EventCommentCreated, uuid.New(), uuid.New(), nil)
+	require.NoError(t, err)
+	delivery := model.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		Event:          event,
+		NextAttemptAt:  time.Now().UTC(),
+		CreatedAt:      time.Now().UTC(),
+	}
+	require.NoError(t, testRepo.CreateDeliveries(ctx, []model.WebhookDelivery{delivery}))
+
+	delivery.Attempts = 8
+	delivery.LastError = "unexpected status 500"
+	require.NoError(t, testRepo.DeadLetterDelivery(ctx, delivery))
+
+	dead, err := testRepo.ListDeadLetters(ctx, sub.ID)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.Equal(t, event.ID, dead[0].Event.ID)
+
+	replayed, err := testRepo.ReplayDeadLetters(ctx, sub.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, replayed)
+
+	dead, err = testRepo.ListDeadLetters(ctx, sub.ID)
+	require.NoError(t, err)
+	require.Empty(t, dead)
+
+	// The replayed delivery is due again with a fresh set of attempts
+	due, err := testRepo.ClaimDueDeliveries(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	var found bool
+	for _, d := range due {
+		if d.ID == delivery.ID {
+			found = true
+			require.Equal(t, 0, d.Attempts)
+		}
+	}
+	require.True(t, found)
+
+	require.NoError(t, testRepo.DeleteSubscription(ctx, sub.ID))
+}
+
+func TestCreateComment_StoresPolicyReports(t *testing.T) {
+	ctx := context.Background()
+	comment := model.Comment{ID: uuid.New(), UserID: "test-user", Content: "see links", CreatedAt: time.Now().UTC()}
+	comment.ThreadID = comment.ID
+	report := model.Report{ID: uuid.New(), CommentID: comment.ID, UserID: "policy:links", Reason: "too many links", CreatedAt: time.Now().UTC()}
+
+	require.NoError(t, testRepo.CreateComment(ctx, &comment, []model.Report{report}))
+
+	reports, err := testRepo.DB.NewSelect().
+		Model((*ReportEntity)(nil)).
+		Where("comment_id = ?", comment.ID).
+		Where("user_id = ?", "policy:links").
+		Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, reports)
+}
+
+func TestModerateComment_HideAndQueue(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	visible := insertTestComment(t, threadID, 1)
+	reported := insertTestComment(t, threadID, 2)
+
+	for _, user := range []string{"alice", "bob", "alice"} {
+		err := testRepo.CreateReport(ctx, &model.Report{
+			ID:        uuid.New(),
+			CommentID: reported.ID,
+			UserID:    user,
+			Reason:    "spam",
+			CreatedAt: time.Now().UTC(),
+		})
+		require.NoError(t, err)
+	}
+
+	// Duplicate reports by the same user count once
+	queue, err := testRepo.ListModerationQueue(ctx, 1000)
+	require.NoError(t, err)
+	var item *model.QueueItem
+	for i := range queue {
+		if queue[i].Comment.ID == reported.ID {
+			item = &queue[i]
+		}
+	}
+	require.NotNil(t, item)
+	require.Equal(t, 2, item.ReportCount)
+	require.Equal(t, []string{"spam"}, item.Reasons)
+
+	hidden, changed, err := testRepo.ModerateComment(ctx, reported.ID, "mod1", model.ModerationHide, "spam")
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NotNil(t, hidden.HiddenAt)
+
+	// Hiding it again changes nothing
+	_, changed, err = testRepo.ModerateComment(ctx, reported.ID, "mod2", model.ModerationHide, "")
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	comments, err := testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, visible.ID, comments[0].ID)
+
+	queue, err = testRepo.ListModerationQueue(ctx, 1000)
+	require.NoError(t, err)
+	for _, q := range queue {
+		require.NotEqual(t, reported.ID, q.Comment.ID)
+	}
+
+	// Approving brings it back
+	approved, changed, err := testRepo.ModerateComment(ctx, reported.ID, "mod1", model.ModerationApprove, "")
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Nil(t, approved.HiddenAt)
+
+	comments, err = testRepo.ListCommentsSorted(ctx, threadID, "upvotes", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+
+	actions, err := testRepo.ListModerationActions(ctx, reported.ID)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+	require.Equal(t, model.ModerationApprove, actions[0].Action)
+	require.Equal(t, "mod1", actions[2].ModeratorID)
+	require.Equal(t, model.ModerationHide, actions[2].Action)
+}
+
+func TestThread_CommentCountAndLock(t *testing.T) {
+	ctx := context.Background()
+
+	thread := &model.Thread{ID: uuid.New(), SubjectKey: "test:" + uuid.NewString(), Title: "Test"}
+	created, err := testRepo.CreateThread(ctx, thread)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// A second create for the same subject returns the existing thread
+	again := &model.Thread{ID: uuid.New(), SubjectKey: thread.SubjectKey}
+	created, err = testRepo.CreateThread(ctx, again)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, thread.ID, again.ID)
+
+	c1 := insertTestComment(t, thread.ID, 0)
+	insertTestComment(t, thread.ID, 0)
+	_, deleted, err := testRepo.DeleteComment(ctx, c1.ID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	_, deleted, err = testRepo.DeleteComment(ctx, c1.ID)
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	pinned, err := testRepo.PinComment(ctx, thread.ID, c1.ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{c1.ID}, pinned.PinnedCommentIDs)
+	_, err = testRepo.PinComment(ctx, thread.ID, uuid.New(), 1)
+	require.ErrorIs(t, err, model.ErrTooManyPins)
+
+	thread.Locked = true
+	require.NoError(t, testRepo.UpdateThread(ctx, thread))
+
+	err = testRepo.CreateComment(ctx, &model.Comment{ID: uuid.New(), ThreadID: thread.ID, UserID: "test-user", Content: "late"}, nil)
+	require.ErrorIs(t, err, model.ErrThreadLocked)
+
+	// Top-level comments can't join a thread that doesn't exist
+	err = testRepo.CreateComment(ctx, &model.Comment{ID: uuid.New(), ThreadID: uuid.New(), UserID: "test-user", Content: "lost"}, nil)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	got, err := testRepo.GetThread(ctx, thread.ID)
+	require.NoError(t, err)
+	require.True(t, got.Locked)
+	require.Equal(t, 1, got.CommentCount)
+}
+
+func TestListExistingUsers(t *testing.T) {
+	ctx := context.Background()
+	insertTestComment(t, uuid.New(), 0)
+
+	known, err := testRepo.ListExistingUsers(ctx, []string{"test-user", "nobody-" + uuid.NewString()})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"test-user": true}, known)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+)
+
+// rankExpr scores a comment's content against a plain-text query. It is cast to FLOAT8
+// so that the rank stored in a cursor compares exactly with the recomputed one.
+const rankExpr = "ts_rank(content_tsv, plainto_tsquery('english', ?))::FLOAT8"
+
+// SearchComments returns up to limit listed comments matching the query, most relevant first,
+// optionally within one thread. Matching uses the inverted index on content_tsv.
+func (r *Repo) SearchComments(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error) {
+	var rows []struct {
+		ID   uuid.UUID `bun:"id"`
+		Rank float64   `bun:"rank"`
+	}
+
+	q := r.DB.NewSelect().
+		Model((*CommentEntity)(nil)).
+		Column("id").
+		ColumnExpr(rankExpr+" AS rank", query).
+		Where("content_tsv @@ plainto_tsquery('english', ?)", query).
+		Where("deleted_at IS NULL").
+		Where("hidden_at IS NULL")
+
+	if threadID != nil {
+		q = q.Where("thread_id = ?", *threadID)
+	}
+	if cursor != nil {
+		q = q.Where("("+rankExpr+", created_at, id) < (?, ?, ?)", query, cursor.Score, cursor.Time(), cursor.ID)
+	}
+
+	err := q.
+		OrderExpr("rank DESC, created_at DESC, id DESC").
+		Limit(limit).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []model.SearchResult{}, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	var entities []CommentEntity
+	err = r.DB.NewSelect().
+		Model(&entities).
+		Where("id IN (?)", bun.In(ids)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make(map[uuid.UUID]model.Comment, len(entities))
+	for _, e := range entities {
+		comments[e.ID] = e.APIComment()
+	}
+
+	out := make([]model.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		if c, ok := comments[row.ID]; ok {
+			out = append(out, model.SearchResult{Comment: c, Rank: row.Rank})
+		}
+	}
+	return out, nil
+}
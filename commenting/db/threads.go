@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+)
+
+// CreateThread inserts a thread for a subject key, or loads the existing one if the subject
+// already has a thread. The thread is filled in either way; it reports whether it was created.
+func (r *Repo) CreateThread(ctx context.Context, thread *model.Thread) (bool, error) {
+	entity := ThreadEntity{
+		ID:         thread.ID,
+		SubjectKey: thread.SubjectKey,
+		Title:      thread.Title,
+		PinnedIDs:  []string{},
+		CreatedAt:  thread.CreatedAt,
+	}
+	res, err := r.DB.NewInsert().
+		Model(&entity).
+		On("CONFLICT (subject_key) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		*thread = entity.APIThread()
+		return true, nil
+	}
+
+	existing, err := r.GetThreadBySubject(ctx, thread.SubjectKey)
+	if err != nil {
+		return false, err
+	}
+	*thread = *existing
+	return false, nil
+}
+
+// GetThread retrieves a thread by its ID.
+func (r *Repo) GetThread(ctx context.Context, threadID uuid.UUID) (*model.Thread, error) {
+	return getThread(ctx, r.DB, "id = ?", threadID)
+}
+
+// GetThreadBySubject retrieves the thread attached to a subject key.
+func (r *Repo) GetThreadBySubject(ctx context.Context, subjectKey string) (*model.Thread, error) {
+	return getThread(ctx, r.DB, "subject_key = ?", subjectKey)
+}
+
+func getThread(ctx context.Context, db bun.IDB, where string, arg any) (*model.Thread, error) {
+	var entity ThreadEntity
+	err := db.NewSelect().
+		Model(&entity).
+		Where(where, arg).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	thread := entity.APIThread()
+	return &thread, nil
+}
+
+// UpdateThread saves the title and locked flag of a thread.
+func (r *Repo) UpdateThread(ctx context.Context, thread *model.Thread) error {
+	res, err := r.DB.NewUpdate().
+		Model((*ThreadEntity)(nil)).
+		Set("title = ?", thread.Title).
+		Set("locked = ?", thread.Locked).
+		Where("id = ?", thread.ID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// PinComment appends a comment to the thread's pinned comments, unless it is already pinned.
+// It fails with model.ErrTooManyPins once the thread pins maxPinned comments.
+func (r *Repo) PinComment(ctx context.Context, threadID, commentID uuid.UUID, maxPinned int) (*model.Thread, error) {
+	var thread *model.Thread
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		var entity ThreadEntity
+		err := tx.NewSelect().
+			Model(&entity).
+			Where("id = ?", threadID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		current := entity.APIThread()
+		if current.IsPinned(commentID) {
+			thread = &current
+			return nil
+		}
+		if len(current.PinnedCommentIDs) >= maxPinned {
+			return model.ErrTooManyPins
+		}
+
+		_, err = tx.NewUpdate().
+			Model(&entity).
+			Set("pinned_ids = array_append(pinned_ids, ?::UUID)", commentID).
+			Where("id = ?", threadID).
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		updated := entity.APIThread()
+		thread = &updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// UnpinComment removes a comment from the thread's pinned comments. Unpinning a comment that isn't pinned is a no-op.
+func (r *Repo) UnpinComment(ctx context.Context, threadID, commentID uuid.UUID) (*model.Thread, error) {
+	var entity ThreadEntity
+	err := r.DB.NewUpdate().
+		Model(&entity).
+		Set("pinned_ids = array_remove(pinned_ids, ?::UUID)", commentID).
+		Where("id = ?", threadID).
+		Returning("*").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	thread := entity.APIThread()
+	return &thread, nil
+}
+
+// adjustCommentCount adds delta to the comment count of a thread and reports whether the thread
+// has a row and whether it is locked. Threads created before the threads table existed have no row,
+// count nothing and are never locked.
+func adjustCommentCount(ctx context.Context, db bun.IDB, threadID uuid.UUID, delta int) (bool, bool, error) {
+	var locked []bool
+	_, err := db.NewUpdate().
+		Model((*ThreadEntity)(nil)).
+		Where("id = ?", threadID).
+		Set("comment_count = comment_count + ?", delta).
+		Returning("locked").
+		Exec(ctx, &locked)
+	if err != nil {
+		return false, false, err
+	}
+	if len(locked) == 0 {
+		return false, false, nil
+	}
+	return true, locked[0], nil
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+const (
+	maxTxRetries   = 5
+	txRetryBackoff = 10 * time.Millisecond
+)
+
+// RunInTx runs fn in a serializable transaction. CockroachDB aborts conflicting
+// serializable transactions with SQLSTATE 40001 and expects the client to retry them,
+// so fn is re-run with a growing backoff until it commits or fails for another reason.
+func (r *Repo) RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.Tx) error) error {
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	backoff := txRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := r.DB.RunInTx(ctx, opts, fn)
+		if err == nil || !isRetryable(err) || attempt >= maxTxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+// isRetryable reports whether err is a CockroachDB transaction retry error.
+func isRetryable(err error) bool {
+	var pgErr pgdriver.Error
+	return errors.As(err, &pgErr) && pgErr.Field('C') == "40001"
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+)
+
+// voteDeltas is one comment's row in the bulk update of a flush.
+type voteDeltas struct {
+	CommentID uuid.UUID `bun:",type:uuid"`
+	Upvotes   int
+	Downvotes int
+	Likes     int
+}
+
+// insertVoteDelta records the counter changes of a write-behind reaction and its event for the flusher.
+func insertVoteDelta(ctx context.Context, db bun.IDB, event model.Event, deltas map[string]int) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = db.NewInsert().
+		Model(&VoteDeltaEntity{
+			CommentID: event.CommentID,
+			Upvotes:   deltas["upvotes"],
+			Downvotes: deltas["downvotes"],
+			Likes:     deltas["likes"],
+			Event:     data,
+		}).
+		Exec(ctx)
+	return err
+}
+
+// FlushVoteDeltas adds up to limit of the oldest write-behind counter deltas to their comments, records
+// their events in the outbox and deletes them, in one transaction, so each comment row is written once
+// per flush however many votes it received. It returns how many deltas were flushed.
+func (r *Repo) FlushVoteDeltas(ctx context.Context, limit int) (int, error) {
+	var flushed int
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		flushed = 0
+
+		oldest := tx.NewSelect().
+			Model((*VoteDeltaEntity)(nil)).
+			Column("id").
+			Order("created_at ASC", "id ASC").
+			Limit(limit)
+
+		var entities []VoteDeltaEntity
+		_, err := tx.NewDelete().
+			Model(&entities).
+			Where("id IN (?)", oldest).
+			Returning("*").
+			Exec(ctx)
+		if err != nil || len(entities) == 0 {
+			return err
+		}
+
+		// RETURNING doesn't keep the order of the subquery, and events go to the outbox in vote order
+		sort.Slice(entities, func(i, j int) bool {
+			if !entities[i].CreatedAt.Equal(entities[j].CreatedAt) {
+				return entities[i].CreatedAt.Before(entities[j].CreatedAt)
+			}
+			return bytes.Compare(entities[i].ID[:], entities[j].ID[:]) < 0
+		})
+
+		byComment := make(map[uuid.UUID]*voteDeltas)
+		events := make([]model.Event, 0, len(entities))
+		for _, e := range entities {
+			sum := byComment[e.CommentID]
+			if sum == nil {
+				sum = &voteDeltas{CommentID: e.CommentID}
+				byComment[e.CommentID] = sum
+			}
+			sum.Upvotes += e.Upvotes
+			sum.Downvotes += e.Downvotes
+			sum.Likes += e.Likes
+
+			var event model.Event
+			if err := json.Unmarshal(e.Event, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+
+		values := make([]voteDeltas, 0, len(byComment))
+		for _, sum := range byComment {
+			// Votes that cancelled out leave nothing to write
+			if sum.Upvotes != 0 || sum.Downvotes != 0 || sum.Likes != 0 {
+				values = append(values, *sum)
+			}
+		}
+		if len(values) > 0 {
+			_, err := tx.NewUpdate().
+				With("_data", tx.NewValues(&values)).
+				Model((*CommentEntity)(nil)).
+				TableExpr("_data").
+				Set("upvotes = comment_entity.upvotes + _data.upvotes").
+				Set("downvotes = comment_entity.downvotes + _data.downvotes").
+				Set("likes = comment_entity.likes + _data.likes").
+				Where("comment_entity.id = _data.comment_id").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := insertEvents(ctx, tx, events...); err != nil {
+			return err
+		}
+		flushed = len(entities)
+		return nil
+	})
+
+	return flushed, err
+}
+
+// ListPendingVoteComments returns which of the given comments have write-behind deltas not yet flushed.
+func (r *Repo) ListPendingVoteComments(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	out := make(map[uuid.UUID]bool)
+	if len(commentIDs) == 0 {
+		return out, nil
+	}
+
+	var ids []uuid.UUID
+	err := r.DB.NewSelect().
+		Model((*VoteDeltaEntity)(nil)).
+		Distinct().
+		Column("comment_id").
+		Where("comment_id IN (?)", bun.In(commentIDs)).
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out, nil
+}
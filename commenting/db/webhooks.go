@@ -0,0 +1,261 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// CreateSubscription stores a new webhook subscription.
+func (r *Repo) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	entity := WebhookSubscriptionEntity{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+	}
+	_, err := r.DB.NewInsert().Model(&entity).Exec(ctx)
+	return err
+}
+
+// GetSubscription retrieves a webhook subscription, including its secret.
+func (r *Repo) GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	var entity WebhookSubscriptionEntity
+	err := r.DB.NewSelect().
+		Model(&entity).
+		Where("id = ?", id).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sub := entity.APISubscription()
+	return &sub, nil
+}
+
+// ListSubscriptions returns every webhook subscription, oldest first.
+func (r *Repo) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	var entities []WebhookSubscriptionEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Order("created_at ASC", "id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.WebhookSubscription, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APISubscription())
+	}
+	return out, nil
+}
+
+// UpdateSubscription replaces the URL, event types and active flag of a subscription.
+func (r *Repo) UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	res, err := r.DB.NewUpdate().
+		Model((*WebhookSubscriptionEntity)(nil)).
+		Set("url = ?", sub.URL).
+		Set("event_types = ?", pgdialect.Array(sub.EventTypes)).
+		Set("active = ?", sub.Active).
+		Where("id = ?", sub.ID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// DeleteSubscription removes a subscription along with its pending deliveries and dead letters.
+func (r *Repo) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	res, err := r.DB.NewDelete().
+		Model((*WebhookSubscriptionEntity)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// CreateDeliveries queues deliveries in one insert. Deliveries that are already queued are left as they are.
+func (r *Repo) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	entities := make([]WebhookDeliveryEntity, 0, len(deliveries))
+	for _, d := range deliveries {
+		entities = append(entities, WebhookDeliveryEntity{
+			ID:             d.ID,
+			SubscriptionID: d.SubscriptionID,
+			Event:          d.Event,
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			CreatedAt:      d.CreatedAt,
+		})
+	}
+	_, err := r.DB.NewInsert().Model(&entities).On("CONFLICT (id) DO NOTHING").Exec(ctx)
+	return err
+}
+
+// ClaimDueDeliveries returns up to limit deliveries whose next attempt is due and pushes their
+// next attempt back by lease, so that other dispatchers don't pick them up while they are being sent.
+func (r *Repo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	due := r.DB.NewSelect().
+		Model((*WebhookDeliveryEntity)(nil)).
+		Column("id").
+		Where("next_attempt_at <= now()").
+		Order("next_attempt_at ASC").
+		Limit(limit)
+
+	var entities []WebhookDeliveryEntity
+	_, err := r.DB.NewUpdate().
+		Model(&entities).
+		Set("next_attempt_at = now() + ?::INTERVAL", lease.String()).
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.WebhookDelivery, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIDelivery())
+	}
+	return out, nil
+}
+
+// CompleteDelivery removes a delivery that was accepted by the receiver.
+func (r *Repo) CompleteDelivery(ctx context.Context, id uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*WebhookDeliveryEntity)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+// RescheduleDelivery records a failed attempt and when to try again.
+func (r *Repo) RescheduleDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	_, err := r.DB.NewUpdate().
+		Model((*WebhookDeliveryEntity)(nil)).
+		Set("attempts = ?", d.Attempts).
+		Set("last_error = ?", d.LastError).
+		Set("next_attempt_at = ?", d.NextAttemptAt).
+		Where("id = ?", d.ID).
+		Exec(ctx)
+	return err
+}
+
+// DeadLetterDelivery moves a delivery that ran out of attempts to the dead-letter table.
+func (r *Repo) DeadLetterDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	return r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		entity := WebhookDeadLetterEntity{
+			ID:             d.ID,
+			SubscriptionID: d.SubscriptionID,
+			Event:          d.Event,
+			Attempts:       d.Attempts,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+		}
+		if _, err := tx.NewInsert().Model(&entity).Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.NewDelete().
+			Model((*WebhookDeliveryEntity)(nil)).
+			Where("id = ?", d.ID).
+			Exec(ctx)
+		return err
+	})
+}
+
+// ListDeadLetters returns the dead letters of a subscription, most recently failed first.
+func (r *Repo) ListDeadLetters(ctx context.Context, subscriptionID uuid.UUID) ([]model.WebhookDelivery, error) {
+	var entities []WebhookDeadLetterEntity
+	err := r.DB.NewSelect().
+		Model(&entities).
+		Where("subscription_id = ?", subscriptionID).
+		Order("failed_at DESC", "id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.WebhookDelivery, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.APIDelivery())
+	}
+	return out, nil
+}
+
+// ReplayDeadLetters queues the given dead letters of a subscription again with a fresh set of attempts,
+// or all of them when ids is empty. It returns how many were replayed.
+func (r *Repo) ReplayDeadLetters(ctx context.Context, subscriptionID uuid.UUID, ids []uuid.UUID) (int, error) {
+	var replayed int
+
+	err := r.RunInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
+		var dead []WebhookDeadLetterEntity
+		q := tx.NewSelect().
+			Model(&dead).
+			Where("subscription_id = ?", subscriptionID)
+		if len(ids) > 0 {
+			q = q.Where("id IN (?)", bun.In(ids))
+		}
+		if err := q.For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+		replayed = len(dead)
+		if replayed == 0 {
+			return nil
+		}
+
+		deliveries := make([]WebhookDeliveryEntity, 0, len(dead))
+		deadIDs := make([]uuid.UUID, 0, len(dead))
+		for _, d := range dead {
+			deliveries = append(deliveries, WebhookDeliveryEntity{
+				ID:             d.ID,
+				SubscriptionID: d.SubscriptionID,
+				Event:          d.Event,
+				CreatedAt:      d.CreatedAt,
+			})
+			deadIDs = append(deadIDs, d.ID)
+		}
+
+		if _, err := tx.NewDelete().
+			Model((*WebhookDeadLetterEntity)(nil)).
+			Where("id IN (?)", bun.In(deadIDs)).
+			Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().Model(&deliveries).Exec(ctx)
+		return err
+	})
+
+	return replayed, err
+}
+
+// requireAffected maps an update or delete that matched no rows to model.ErrNotFound.
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
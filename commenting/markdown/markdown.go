@@ -0,0 +1,236 @@
+// Package markdown renders the Markdown subset allowed in comments to HTML.
+//
+// The supported subset is paragraphs, emphasis (*em*, **strong**), code spans, fenced code blocks,
+// links, block quotes and flat ordered and unordered lists. Rendering is safe by construction:
+// the source is HTML-escaped before any markup is added, so raw HTML such as <script> tags or
+// onclick attributes can only ever come out as inert text, and links are restricted to http,
+// https and mailto URLs.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxQuoteDepth caps how deeply block quotes nest; deeper markers are rendered as text.
+const maxQuoteDepth = 5
+
+var (
+	unorderedItem = regexp.MustCompile(`^[-*+][ \t]+(.*)$`)
+	orderedItem   = regexp.MustCompile(`^\d{1,9}[.)][ \t]+(.*)$`)
+
+	codeSpan   = regexp.MustCompile("(`+)(.+?)(`+)")
+	escaped    = regexp.MustCompile(`\\([\\` + "`" + `*_\[\]()>#+\-.!])`)
+	link       = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+	autolink   = regexp.MustCompile(`https?://[^\s<>"\x00]*[^\s<>"\x00.,:;!?'\)\]]`)
+	linkOrURL  = regexp.MustCompile(link.String() + "|" + autolink.String())
+	strong     = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	emphasis   = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	underscore = regexp.MustCompile(`(^|[^\w])_(\S(?:.*?\S)?)_($|[^\w])`)
+)
+
+// Render converts Markdown source to sanitized HTML.
+func Render(src string) string {
+	var b strings.Builder
+	renderBlocks(&b, splitLines(src), 0)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// RenderPlain renders text as-is: escaped, with blank lines separating paragraphs and
+// single newlines kept as line breaks.
+func RenderPlain(src string) string {
+	var b strings.Builder
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+			para = para[:0]
+		}
+	}
+	for _, line := range splitLines(src) {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		para = append(para, html.EscapeString(line))
+	}
+	flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// splitLines normalizes line endings and drops NUL bytes, which the inline renderer uses as placeholders.
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\x00", "")
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	return strings.Split(src, "\n")
+}
+
+func renderBlocks(b *strings.Builder, lines []string, depth int) {
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + renderInline(strings.Join(para, "\n")) + "</p>\n")
+			para = para[:0]
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case strings.HasPrefix(trimmed, ">") && depth < maxQuoteDepth:
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted, depth+1)
+			b.WriteString("</blockquote>\n")
+
+		case unorderedItem.MatchString(trimmed) || orderedItem.MatchString(trimmed):
+			flush()
+			i = renderList(b, lines, i) - 1
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+}
+
+// renderList renders the list starting at lines[start] and returns the index of the first line after it.
+// Indented lines continue the previous item; a blank line or a different kind of item ends the list.
+func renderList(b *strings.Builder, lines []string, start int) int {
+	pattern, tag := unorderedItem, "ul"
+	first := strings.TrimSpace(lines[start])
+	if !unorderedItem.MatchString(first) {
+		pattern, tag = orderedItem, "ol"
+	}
+
+	b.WriteString("<" + tag)
+	if tag == "ol" {
+		if n, _ := strconv.Atoi(first[:strings.IndexAny(first, ".)")]); n > 1 {
+			b.WriteString(` start="` + strconv.Itoa(n) + `"`)
+		}
+	}
+	b.WriteString(">\n")
+
+	var item []string
+	flush := func() {
+		if len(item) > 0 {
+			b.WriteString("<li>" + renderInline(strings.Join(item, "\n")) + "</li>\n")
+			item = item[:0]
+		}
+	}
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if m := pattern.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			item = append(item, m[1])
+			continue
+		}
+		if trimmed == "" || !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			break
+		}
+		item = append(item, trimmed)
+	}
+	flush()
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderInline escapes a block of text and applies the inline markup. Code spans, escaped characters
+// and links are swapped for NUL-delimited placeholders before the text is escaped and emphasis
+// is applied, so that markup inside them is left alone. A link's text gets its emphasis before
+// the link is held, so emphasis is either inside a link or around all of it, and tags always nest.
+func renderInline(text string) string {
+	var tokens []string
+	hold := func(s string) string {
+		tokens = append(tokens, s)
+		return "\x00" + strconv.Itoa(len(tokens)-1) + "\x00"
+	}
+
+	text = codeSpan.ReplaceAllStringFunc(text, func(m string) string {
+		parts := codeSpan.FindStringSubmatch(m)
+		if len(parts[1]) != len(parts[3]) {
+			return m
+		}
+		return hold("<code>" + html.EscapeString(strings.TrimSpace(parts[2])) + "</code>")
+	})
+	text = escaped.ReplaceAllStringFunc(text, func(m string) string {
+		return hold(html.EscapeString(m[1:]))
+	})
+
+	// Links and bare URLs are matched in one pass, so a URL used as link text isn't linked again
+	text = linkOrURL.ReplaceAllStringFunc(text, func(m string) string {
+		if parts := link.FindStringSubmatch(m); parts != nil && parts[0] == m {
+			href, ok := safeURL(parts[2])
+			if !ok {
+				return parts[1]
+			}
+			return hold(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener">` + emphasize(html.EscapeString(parts[1])) + "</a>")
+		}
+		href, ok := safeURL(m)
+		if !ok {
+			return m
+		}
+		return hold(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener">` + html.EscapeString(m) + "</a>")
+	})
+
+	text = emphasize(html.EscapeString(text))
+
+	// Placeholders may nest, as in a link around a code span, so restore from the last one back
+	for i := len(tokens) - 1; i >= 0; i-- {
+		text = strings.ReplaceAll(text, "\x00"+strconv.Itoa(i)+"\x00", tokens[i])
+	}
+	return text
+}
+
+// emphasize applies emphasis and line breaks to escaped text.
+func emphasize(text string) string {
+	text = strong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = emphasis.ReplaceAllString(text, "<em>$1</em>")
+	text = underscore.ReplaceAllString(text, "$1<em>$2</em>$3")
+	return strings.ReplaceAll(text, "\n", "<br>\n")
+}
+
+// safeURL returns the normalized URL if it is an absolute http, https or mailto URL.
+func safeURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
@@ -0,0 +1,51 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>"},
+		{"emphasis", "**bold**, *em* and _em_ but not snake_case_name", "<p><strong>bold</strong>, <em>em</em> and <em>em</em> but not snake_case_name</p>"},
+		{"code span", "run `a *b* <c>` now", "<p>run <code>a *b* &lt;c&gt;</code> now</p>"},
+		{"code block", "```\n<b>x</b>\n**y**\n```", "<pre><code>&lt;b&gt;x&lt;/b&gt;\n**y**</code></pre>"},
+		{"link", "[the *docs*](https://example.com/a_b?x=1&y=2)", `<p><a href="https://example.com/a_b?x=1&amp;y=2" rel="nofollow noopener">the <em>docs</em></a></p>`},
+		{"autolink", "see https://example.com/a_b_c.", `<p>see <a href="https://example.com/a_b_c" rel="nofollow noopener">https://example.com/a_b_c</a>.</p>`},
+		{"url as link text", "[https://a.example](https://b.example)", `<p><a href="https://b.example" rel="nofollow noopener">https://a.example</a></p>`},
+		{"quote", "> quoted\n> > nested\n\nafter", "<blockquote>\n<p>quoted</p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>\n<p>after</p>"},
+		{"unordered list", "- one\n- two\n  more", "<ul>\n<li>one</li>\n<li>two<br>\nmore</li>\n</ul>"},
+		{"ordered list", "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>"},
+		{"backslash escape", `\*not em\*`, "<p>*not em*</p>"},
+		{"emphasis around link", "*see [the docs](https://example.com)*", `<p><em>see <a href="https://example.com" rel="nofollow noopener">the docs</a></em></p>`},
+		{"emphasis into link", "*see [the* docs](https://example.com)", `<p>*see <a href="https://example.com" rel="nofollow noopener">the* docs</a></p>`},
+		{"emphasis out of link", "[the **docs](https://example.com) here**", `<p><a href="https://example.com" rel="nofollow noopener">the **docs</a> here**</p>`},
+		{"emphasis around code", "*run `a*b` now*", "<p><em>run <code>a*b</code> now</em></p>"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Render(tt.src), tt.name)
+	}
+}
+
+func TestRender_StripsActiveContent(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"event handler", `<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>"},
+		{"javascript link", "[click](javascript:alert`1`)", "<p>click</p>"},
+		{"data link", "[click](data:text/html;base64,PHNjcmlwdD4=)", "<p>click</p>"},
+		{"attribute breakout", `[x](https://example.com/"onmouseover="alert(1))`, `<p>[x](<a href="https://example.com/" rel="nofollow noopener">https://example.com/</a>&#34;onmouseover=&#34;alert(1))</p>`},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Render(tt.src), tt.name)
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	require.Equal(t, "<p>**not bold** &lt;b&gt;<br>\nline</p>\n<p>next</p>", RenderPlain("**not bold** <b>\nline\n\n\nnext"))
+}
@@ -9,33 +9,158 @@ import (
 	"github.com/google/uuid"
 )
 
+// DeletedContent replaces the content of a soft-deleted comment.
+const DeletedContent = "[deleted]"
+
+// HiddenContent replaces the content of a comment hidden by a moderator wherever it is still shown.
+const HiddenContent = "[hidden]"
+
+// Rendered forms of DeletedContent and HiddenContent.
+const (
+	DeletedContentHTML = "<p>[deleted]</p>"
+	HiddenContentHTML  = "<p>[hidden]</p>"
+)
+
+// Content formats. Markdown content is rendered to HTML; plain content is only escaped.
+const (
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+)
+
 type Comment struct {
-	ID         uuid.UUID  `json:"id"`
-	ParentID   *uuid.UUID `json:"parent_id,omitempty"`
-	ThreadID   uuid.UUID  `json:"thread_id"`
-	UserID     string     `json:"user_id"`
-	Content    string     `json:"content"`
-	ReplyCount int        `json:"reply_count"`
-	Upvotes    int        `json:"upvotes"`
-	Downvotes  int        `json:"downvotes"`
-	Likes      int        `json:"likes"`
-	CreatedAt  time.Time  `json:"created_at"`
+	ID       uuid.UUID  `json:"id"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	ThreadID uuid.UUID  `json:"thread_id"`
+	UserID   string     `json:"user_id"`
+	Content  string     `json:"content"`
+	// ContentHTML is the sanitized rendering of Content in its Format, produced when the content is written.
+	ContentHTML string `json:"content_html"`
+	Format      string `json:"format"`
+	ReplyCount  int    `json:"reply_count"`
+	Upvotes     int    `json:"upvotes"`
+	Downvotes   int    `json:"downvotes"`
+	Likes       int    `json:"likes"`
+	// Best, Controversy and Hot are the rankings stored by the database, see NetScore.
+	Best        float64    `json:"-"`
+	Controversy float64    `json:"-"`
+	Hot         float64    `json:"-"`
+	Depth       int        `json:"depth"`
+	Path        string     `json:"-"`
+	Revision    int        `json:"revision"`
+	EditedAt    *time.Time `json:"edited_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	HiddenAt    *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// ViewerReactions lists the reaction types the requesting user left on the comment.
+	// It depends on who is asking, so it is never stored or cached.
+	ViewerReactions []string `json:"viewer_reactions,omitempty"`
+	// Pinned marks a comment pinned to its thread in thread listings. It is never stored or cached.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// PathSegment returns the materialized path segment of a comment: its ID followed by a slash.
+// A comment's path is the concatenation of the segments of all its ancestors and itself.
+func PathSegment(id uuid.UUID) string {
+	return id.String() + "/"
+}
+
+// Listed reports whether the comment belongs in sorted listings: it is neither deleted nor hidden.
+func (c *Comment) Listed() bool {
+	return c.DeletedAt == nil && c.HiddenAt == nil
+}
+
+// MaskHidden replaces the content of a comment hidden by a moderator.
+func (c *Comment) MaskHidden() {
+	if c.HiddenAt != nil {
+		c.Content = HiddenContent
+		c.ContentHTML = HiddenContentHTML
+	}
+}
+
+// SortScore returns the value a comment is ordered by for the given sort field.
+func (c *Comment) SortScore(field string) float64 {
+	switch field {
+	case "upvotes":
+		return float64(c.Upvotes)
+	case "reply_count":
+		return float64(c.ReplyCount)
+	case "likes":
+		return float64(c.Likes)
+	case "score":
+		return float64(NetScore(c.Upvotes, c.Downvotes))
+	case "best":
+		return c.Best
+	case "controversy":
+		return c.Controversy
+	case "hot":
+		return c.Hot
+	default:
+		return float64(c.CreatedAt.UnixNano())
+	}
+}
+
+// CachedComment is a comment as read back from the cache, with its score in each of
+// the thread's sorted sets it is a member of, by sort field.
+type CachedComment struct {
+	Comment Comment
+	Scores  map[string]float64
+}
+
+// CacheDrift compares a cached copy of a comment with the stored one and returns the fields
+// that differ: the counters, mapped to the cached value minus the stored one, and the revision,
+// deleted and hidden state. It returns nil when the cached copy is current.
+func CacheDrift(cached, stored *Comment) map[string]int {
+	drift := make(map[string]int)
+	diff := func(field string, c, s int) {
+		if c != s {
+			drift[field] = c - s
+		}
+	}
+	diff("reply_count", cached.ReplyCount, stored.ReplyCount)
+	diff("upvotes", cached.Upvotes, stored.Upvotes)
+	diff("downvotes", cached.Downvotes, stored.Downvotes)
+	diff("likes", cached.Likes, stored.Likes)
+	diff("revision", cached.Revision, stored.Revision)
+	if (cached.DeletedAt == nil) != (stored.DeletedAt == nil) {
+		drift["deleted"] = 1
+	}
+	if (cached.HiddenAt == nil) != (stored.HiddenAt == nil) {
+		drift["hidden"] = 1
+	}
+
+	if len(drift) == 0 {
+		return nil
+	}
+	return drift
 }
 
-type QueryCommentsFunc func(ctx context.Context, threadID uuid.UUID) ([]Comment, error)
+// QueryCommentsFunc loads one page of a thread's sorted comments from the database, for pages the cache can't serve.
+type QueryCommentsFunc func(ctx context.Context, threadID uuid.UUID, cursor *Cursor, limit int) ([]Comment, error)
 
 func (c *Comment) ToHash() map[string]interface{} {
 	return map[string]interface{}{
-		"id":          c.ID.String(),
-		"parent_id":   uuidOrNil(c.ParentID),
-		"thread_id":   c.ThreadID.String(),
-		"user_id":     c.UserID,
-		"content":     c.Content,
-		"reply_count": c.ReplyCount,
-		"upvotes":     c.Upvotes,
-		"downvotes":   c.Downvotes,
-		"likes":       c.Likes,
-		"created_at":  c.CreatedAt.UnixNano(),
+		"id":           c.ID.String(),
+		"parent_id":    uuidOrNil(c.ParentID),
+		"thread_id":    c.ThreadID.String(),
+		"user_id":      c.UserID,
+		"content":      c.Content,
+		"content_html": c.ContentHTML,
+		"format":       c.Format,
+		"reply_count":  c.ReplyCount,
+		"upvotes":      c.Upvotes,
+		"downvotes":    c.Downvotes,
+		"likes":        c.Likes,
+		"best":         floatToStr(c.Best),
+		"controversy":  floatToStr(c.Controversy),
+		"hot":          floatToStr(c.Hot),
+		"depth":        c.Depth,
+		"path":         c.Path,
+		"revision":     c.Revision,
+		"edited_at":    timeOrZero(c.EditedAt),
+		"deleted_at":   timeOrZero(c.DeletedAt),
+		"hidden_at":    timeOrZero(c.HiddenAt),
+		"created_at":   c.CreatedAt.UnixNano(),
 	}
 }
 
@@ -63,17 +188,32 @@ func CommentFromHash(data map[string]string) (Comment, error) {
 		return Comment{}, fmt.Errorf("invalid created_at: %w", err)
 	}
 
+	editedAt := optionalUnixNano(data["edited_at"])
+	deletedAt := optionalUnixNano(data["deleted_at"])
+	hiddenAt := optionalUnixNano(data["hidden_at"])
+
 	return Comment{
-		ID:         id,
-		ParentID:   parentID,
-		ThreadID:   threadID,
-		UserID:     data["user_id"],
-		Content:    data["content"],
-		ReplyCount: intFromStr(data["reply_count"]),
-		Upvotes:    intFromStr(data["upvotes"]),
-		Downvotes:  intFromStr(data["downvotes"]),
-		Likes:      intFromStr(data["likes"]),
-		CreatedAt:  createdAt,
+		ID:          id,
+		ParentID:    parentID,
+		ThreadID:    threadID,
+		UserID:      data["user_id"],
+		Content:     data["content"],
+		ContentHTML: data["content_html"],
+		Format:      data["format"],
+		ReplyCount:  intFromStr(data["reply_count"]),
+		Upvotes:     intFromStr(data["upvotes"]),
+		Downvotes:   intFromStr(data["downvotes"]),
+		Likes:       intFromStr(data["likes"]),
+		Best:        floatFromStr(data["best"]),
+		Controversy: floatFromStr(data["controversy"]),
+		Hot:         floatFromStr(data["hot"]),
+		Depth:       intFromStr(data["depth"]),
+		Path:        data["path"],
+		Revision:    intFromStr(data["revision"]),
+		EditedAt:    editedAt,
+		DeletedAt:   deletedAt,
+		HiddenAt:    hiddenAt,
+		CreatedAt:   createdAt,
 	}, nil
 }
 
@@ -82,6 +222,16 @@ func intFromStr(s string) int {
 	return i
 }
 
+// floatToStr formats f so that floatFromStr reads back exactly the same value.
+func floatToStr(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func floatFromStr(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
 func parseUnixNano(s string) (time.Time, error) {
 	nanos, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
@@ -96,3 +246,21 @@ func uuidOrNil(u *uuid.UUID) string {
 	}
 	return uuid.Nil.String()
 }
+
+func optionalUnixNano(s string) *time.Time {
+	if s == "" || s == "0" {
+		return nil
+	}
+	t, err := parseUnixNano(s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func timeOrZero(t *time.Time) int64 {
+	if t != nil {
+		return t.UnixNano()
+	}
+	return 0
+}
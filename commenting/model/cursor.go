@@ -0,0 +1,115 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a keyset pagination position over comments ordered by (score, created_at, id), descending.
+// The created_at and id components break ties, so comments sharing a score are never skipped.
+// Clients only ever see it as an opaque string.
+type Cursor struct {
+	Score     float64   `json:"s"`
+	CreatedAt int64     `json:"t"`
+	ID        uuid.UUID `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// CommentPage is one page of a sorted comment listing.
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+}
+
+// ReactionPage is one page of the reactions left on a comment, newest first.
+type ReactionPage struct {
+	Reactions  []Reaction `json:"reactions"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// NewCursor returns the position of a comment in the ordering of the given sort field.
+func NewCursor(c *Comment, field string) Cursor {
+	return Cursor{
+		Score:     c.SortScore(field),
+		CreatedAt: c.CreatedAt.UnixNano(),
+		ID:        c.ID,
+	}
+}
+
+// NewReactionCursor returns the position of a reaction in newest-first order.
+func NewReactionCursor(r *Reaction) Cursor {
+	return Cursor{
+		CreatedAt: r.CreatedAt.UnixNano(),
+		ID:        r.ID,
+	}
+}
+
+// Time returns the created_at component of the cursor.
+func (c Cursor) Time() time.Time {
+	return time.Unix(0, c.CreatedAt)
+}
+
+// Before reports whether c comes before other in descending (score, created_at, id) order.
+func (c Cursor) Before(other Cursor) bool {
+	if c.Score != other.Score {
+		return c.Score > other.Score
+	}
+	if c.CreatedAt != other.CreatedAt {
+		return c.CreatedAt > other.CreatedAt
+	}
+	return bytes.Compare(c.ID[:], other.ID[:]) > 0
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode. An empty string yields a nil cursor.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// NewCommentPage builds a page from comments fetched after cursor, in descending order,
+// and derives the cursors leading to the next and previous pages.
+func NewCommentPage(comments []Comment, field string, cursor *Cursor, limit int) CommentPage {
+	page := CommentPage{Comments: comments}
+	if len(comments) == 0 {
+		return page
+	}
+
+	backward := cursor != nil && cursor.Backward
+	full := len(comments) >= limit
+
+	if full || backward {
+		page.NextCursor = NewCursor(&comments[len(comments)-1], field).Encode()
+	}
+	if cursor != nil && (full || !backward) {
+		prev := NewCursor(&comments[0], field)
+		prev.Backward = true
+		page.PrevCursor = prev.Encode()
+	}
+	return page
+}
@@ -0,0 +1,13 @@
+package model
+
+import "errors"
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrThreadLocked is returned when commenting on a locked thread.
+	ErrThreadLocked = errors.New("thread is locked")
+	// ErrTooManyPins is returned when pinning a comment to a thread that already pins MaxPinnedComments.
+	ErrTooManyPins = errors.New("too many pinned comments")
+)
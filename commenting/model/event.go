@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain event types emitted for comment and reaction mutations.
+const (
+	EventCommentCreated  = "comment.created"
+	EventCommentUpdated  = "comment.updated"
+	EventCommentDeleted  = "comment.deleted"
+	EventReactionChanged = "reaction.changed"
+)
+
+// Event describes a committed change to a comment or its reactions.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	ThreadID  uuid.UUID       `json:"thread_id"`
+	CommentID uuid.UUID       `json:"comment_id"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ReactionChange is the payload of a reaction.changed event.
+type ReactionChange struct {
+	UserID string `json:"user_id"`
+	Type   string `json:"type"`
+	// Deltas holds the change of each counter, e.g. {"upvotes": 1, "downvotes": -1} for a switched vote.
+	Deltas map[string]int `json:"deltas"`
+}
+
+// NewEvent builds an event whose payload is the JSON encoding of payload.
+func NewEvent(eventType string, threadID, commentID uuid.UUID, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		ThreadID:  threadID,
+		CommentID: commentID,
+		Payload:   data,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// StreamEvent is an event read back from a thread's event stream.
+// StreamID is its position in the stream, which clients send back as Last-Event-ID to resume.
+type StreamEvent struct {
+	StreamID string
+	Event    Event
+}
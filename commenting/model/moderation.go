@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Moderation actions a moderator can take on a reported comment.
+const (
+	ModerationApprove = "approve"
+	ModerationHide    = "hide"
+	ModerationRemove  = "remove"
+)
+
+// Report is a user's flag on a comment. It stays open until a moderator acts on the comment.
+type Report struct {
+	ID         uuid.UUID  `json:"id"`
+	CommentID  uuid.UUID  `json:"comment_id"`
+	UserID     string     `json:"user_id"`
+	Reason     string     `json:"reason"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// QueueItem is a reported comment in the moderation queue, with its open reports aggregated.
+type QueueItem struct {
+	Comment        Comment   `json:"comment"`
+	ReportCount    int       `json:"report_count"`
+	Reasons        []string  `json:"reasons"`
+	LastReportedAt time.Time `json:"last_reported_at"`
+}
+
+// ModerationAction is an audit record of a moderator's decision on a comment.
+type ModerationAction struct {
+	ID          uuid.UUID `json:"id"`
+	CommentID   uuid.UUID `json:"comment_id"`
+	ModeratorID string    `json:"moderator_id"`
+	Action      string    `json:"action"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
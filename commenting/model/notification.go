@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types.
+const (
+	NotificationMention = "mention"
+	NotificationReply   = "reply"
+)
+
+// Notification tells a user that someone mentioned them or replied to their comment.
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    string     `json:"user_id"`
+	Type      string     `json:"type"`
+	ActorID   string     `json:"actor_id"`
+	CommentID uuid.UUID  `json:"comment_id"`
+	ThreadID  uuid.UUID  `json:"thread_id"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationPage is one page of a user's notifications, newest first, with the user's unread count.
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+	UnreadCount   int            `json:"unread_count"`
+}
+
+// NewNotificationCursor returns the position of a notification in newest-first order.
+func NewNotificationCursor(n *Notification) Cursor {
+	return Cursor{
+		CreatedAt: n.CreatedAt.UnixNano(),
+		ID:        n.ID,
+	}
+}
@@ -0,0 +1,11 @@
+package model
+
+// NetScore is upvotes minus downvotes.
+//
+// The best, controversy and hot rankings are computed only by the database, as stored columns
+// (see migration 0005_ranking), and read back into Comment. Computing them again here would give
+// floats that differ from the stored ones in the last bits, and cursors and sorted set scores
+// built from those would not line up with the database order.
+func NetScore(upvotes, downvotes int) int {
+	return upvotes - downvotes
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows bursts of up to Limit requests and refills one request every Period/Limit.
+// A zero Limit disables the budget.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRateLimit parses a budget written as "{limit}/{period}", such as "10/1m".
+func ParseRateLimit(s string) (RateLimit, error) {
+	limit, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q: expected {limit}/{period}", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: invalid limit", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: invalid period", s)
+	}
+	return RateLimit{Limit: n, Period: d}, nil
+}
+
+// Interval is the time it takes to earn back one request.
+func (l RateLimit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Limit)
+}
+
+// RateBudget is a rate limit applied to one key, such as a user or a thread.
+type RateBudget struct {
+	Key   string
+	Limit RateLimit
+}
+
+// RateLimitResult describes the most restrictive of the budgets checked for a request.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // zero when allowed
+	ResetAfter time.Duration // until the budget is full again
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	l, err := ParseRateLimit("10/1m")
+	require.NoError(t, err)
+	require.Equal(t, RateLimit{Limit: 10, Period: time.Minute}, l)
+	require.Equal(t, 6*time.Second, l.Interval())
+
+	for _, s := range []string{"10", "x/1m", "-1/1m", "10/0s", "10/soon"} {
+		_, err := ParseRateLimit(s)
+		require.Error(t, err, s)
+	}
+}
@@ -13,3 +13,16 @@ type Reaction struct {
 	Type      string    `json:"type"` // "like", "upvote" or "downvote"
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// VoteReaction returns the reaction type and the comment counter field recorded for a vote value:
+// +1 is an upvote, -1 a downvote. Any other value is no vote and yields empty strings.
+func VoteReaction(value int) (reactionType, field string) {
+	switch value {
+	case 1:
+		return "upvote", "upvotes"
+	case -1:
+		return "downvote", "downvotes"
+	default:
+		return "", ""
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision is a previous version of a comment, kept whenever the comment is edited.
+type Revision struct {
+	ID        uuid.UUID `json:"id"`
+	CommentID uuid.UUID `json:"comment_id"`
+	Revision  int       `json:"revision"`
+	UserID    string    `json:"user_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
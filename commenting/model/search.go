@@ -0,0 +1,24 @@
+package model
+
+// SearchResult is a comment matching a search, with its relevance and an HTML snippet
+// of the content around the matched terms.
+type SearchResult struct {
+	Comment Comment `json:"comment"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchPage is one page of search results, most relevant first.
+type SearchPage struct {
+	Results    []SearchResult `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// NewSearchCursor returns the position of a result in (rank, created_at, id) descending order.
+func NewSearchCursor(r *SearchResult) Cursor {
+	return Cursor{
+		Score:     r.Rank,
+		CreatedAt: r.Comment.CreatedAt.UnixNano(),
+		ID:        r.Comment.ID,
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxPinnedComments caps how many comments a thread can pin.
+const MaxPinnedComments = 3
+
+// Thread groups the comments about one external subject, such as an article URL or a product ID.
+// Comments created before threads existed have no Thread; their thread ID is their root comment's ID.
+type Thread struct {
+	ID         uuid.UUID `json:"id"`
+	SubjectKey string    `json:"subject_key"`
+	Title      string    `json:"title"`
+	// CommentCount counts the thread's comments that haven't been deleted, replies included.
+	CommentCount int  `json:"comment_count"`
+	Locked       bool `json:"locked"`
+	// PinnedCommentIDs are listed before every other comment on the first page of the thread, in pin order.
+	PinnedCommentIDs []uuid.UUID `json:"pinned_comment_ids"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+// IsPinned reports whether the comment is pinned to the thread.
+func (t *Thread) IsPinned(commentID uuid.UUID) bool {
+	return slices.Contains(t.PinnedCommentIDs, commentID)
+}
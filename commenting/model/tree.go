@@ -0,0 +1,13 @@
+package model
+
+// CommentNode is a comment nested together with its replies.
+type CommentNode struct {
+	Comment
+	Replies []*CommentNode `json:"replies,omitempty"`
+	More    *MoreReplies   `json:"more_replies,omitempty"`
+}
+
+// MoreReplies marks a node whose replies were cut off by the depth limit.
+type MoreReplies struct {
+	Count int `json:"count"`
+}
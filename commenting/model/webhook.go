@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription registers a URL to receive events. An empty EventTypes receives every event.
+// The secret signs each delivery and is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID         uuid.UUID `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Accepts reports whether the subscription wants events of the given type.
+func (s WebhookSubscription) Accepts(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one subscription. Deliveries that ran out of
+// attempts become dead letters, with FailedAt set, until they are replayed.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	Event          Event      `json:"event"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	FailedAt       *time.Time `json:"failed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,225 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// Store claims pending events from the outbox table and reads the comments they refer to.
+type Store interface {
+	ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error)
+	MarkEventsProcessed(ctx context.Context, ids []uuid.UUID) error
+	PurgeProcessedEvents(ctx context.Context, before time.Time, limit int) (int, error)
+	GetCommentByID(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
+}
+
+// Cache is the part of the comment cache the relay keeps in sync.
+type Cache interface {
+	SetComment(ctx context.Context, comment *model.Comment) error
+	DeleteComment(ctx context.Context, comment *model.Comment) error
+}
+
+// Publisher delivers domain events to downstream consumers.
+type Publisher interface {
+	Publish(ctx context.Context, event model.Event) error
+}
+
+// WebhookQueue queues processed events for delivery to webhook subscribers.
+type WebhookQueue interface {
+	Enqueue(ctx context.Context, events []model.Event) error
+}
+
+const (
+	defaultInterval      = 500 * time.Millisecond
+	defaultBatchSize     = 100
+	defaultLease         = 30 * time.Second
+	defaultRetention     = 24 * time.Hour
+	defaultPurgeInterval = 10 * time.Minute
+)
+
+// Relay drains the outbox: for every committed event it refreshes the cached comment
+// from the database and publishes the event, then queues the batch's events for webhooks
+// and marks them processed. Delivery is at-least-once, so consumers must tolerate duplicates.
+//
+// Several relays can run side by side: each claims its batch for Lease, so the others skip it.
+// A batch still unprocessed when its lease runs out, say because the relay died, is claimed again.
+// Processed events are kept for Retention and then purged.
+type Relay struct {
+	store     Store
+	cache     Cache
+	publisher Publisher
+	webhooks  WebhookQueue
+	logger    *slog.Logger
+
+	Interval      time.Duration
+	BatchSize     int
+	Lease         time.Duration
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// NewRelay returns a relay; webhooks may be nil to not deliver events to webhook subscribers.
+func NewRelay(store Store, cache Cache, publisher Publisher, webhooks WebhookQueue, logger *slog.Logger) *Relay {
+	return &Relay{
+		store:         store,
+		cache:         cache,
+		publisher:     publisher,
+		webhooks:      webhooks,
+		logger:        logger,
+		Interval:      defaultInterval,
+		BatchSize:     defaultBatchSize,
+		Lease:         defaultLease,
+		Retention:     defaultRetention,
+		PurgeInterval: defaultPurgeInterval,
+	}
+}
+
+// Run processes batches until ctx is cancelled. A full batch is followed
+// immediately by the next one; otherwise the relay waits for the next tick.
+// Every PurgeInterval it also purges the events past their retention.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(r.PurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-purgeTicker.C:
+			if _, err := r.Purge(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error("outbox purge failed", slog.Any("error", err))
+			}
+		default:
+		}
+
+		n, err := r.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("outbox relay failed", slog.Any("error", err))
+		}
+		if err == nil && n == r.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims up to BatchSize pending events, handles them in order and returns how many
+// were processed. It stops at the first failing event so that later events are not applied before it;
+// the events handled up to that point are still marked processed.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	events, err := r.store.ClaimPendingEvents(ctx, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := make([]model.Event, 0, len(events))
+	var handleErr error
+	for _, e := range events {
+		if e, handleErr = r.handle(ctx, e); handleErr != nil {
+			break
+		}
+		handled = append(handled, e)
+	}
+
+	// Webhooks are queued before the events are marked, so none are lost if the relay stops in between
+	if r.webhooks != nil {
+		if err := r.webhooks.Enqueue(ctx, handled); err != nil {
+			return 0, err
+		}
+	}
+
+	processed := make([]uuid.UUID, 0, len(handled))
+	for _, e := range handled {
+		processed = append(processed, e.ID)
+	}
+	if err := r.store.MarkEventsProcessed(ctx, processed); err != nil {
+		return 0, err
+	}
+	return len(processed), handleErr
+}
+
+// Purge deletes the events processed more than Retention ago, BatchSize at a time,
+// and returns how many were deleted.
+func (r *Relay) Purge(ctx context.Context) (int, error) {
+	before := time.Now().Add(-r.Retention)
+	total := 0
+	for {
+		n, err := r.store.PurgeProcessedEvents(ctx, before, r.BatchSize)
+		total += n
+		if err != nil || n < r.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// handle brings the cache in line with the database for the event's comment, publishes the event
+// and returns it as published. Creating or deleting a reply also changes the parent's reply count,
+// so the parent is refreshed too.
+func (r *Relay) handle(ctx context.Context, e model.Event) (model.Event, error) {
+	comment, err := r.syncComment(ctx, e.CommentID)
+	if err != nil {
+		return e, err
+	}
+
+	if comment != nil && comment.ParentID != nil &&
+		(e.Type == model.EventCommentCreated || e.Type == model.EventCommentDeleted) {
+		if _, err := r.syncComment(ctx, *comment.ParentID); err != nil {
+			return e, err
+		}
+	}
+
+	// A comment hidden after the event was recorded must not leak through its payload
+	if comment != nil && comment.HiddenAt != nil &&
+		(e.Type == model.EventCommentCreated || e.Type == model.EventCommentUpdated) {
+		if e, err = maskPayload(e, comment.HiddenAt); err != nil {
+			return e, err
+		}
+	}
+
+	return e, r.publisher.Publish(ctx, e)
+}
+
+// maskPayload replaces the content in a comment event's payload as of the comment being hidden.
+func maskPayload(e model.Event, hiddenAt *time.Time) (model.Event, error) {
+	var payload model.Comment
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return e, err
+	}
+	payload.HiddenAt = hiddenAt
+	payload.MaskHidden()
+
+	masked, err := json.Marshal(payload)
+	if err != nil {
+		return e, err
+	}
+	e.Payload = masked
+	return e, nil
+}
+
+// syncComment writes the current database state of a comment to the cache.
+// Writing absolute state rather than deltas keeps redelivered events harmless.
+func (r *Relay) syncComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, error) {
+	comment, err := r.store.GetCommentByID(ctx, commentID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, nil // nothing left to cache
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !comment.Listed() {
+		return comment, r.cache.DeleteComment(ctx, comment)
+	}
+	return comment, r.cache.SetComment(ctx, comment)
+}
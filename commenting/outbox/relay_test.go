@@ -0,0 +1,248 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/outbox"
+	"github.com/kiremitrov123/onboarding/commenting/outbox/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestProcessBatch_CreatedReplyRefreshesParent(t *testing.T) {
+	ctx := context.Background()
+	parentID := uuid.New()
+	reply := &model.Comment{ID: uuid.New(), ParentID: &parentID, ThreadID: parentID}
+	parent := &model.Comment{ID: parentID, ThreadID: parentID, ReplyCount: 1}
+	event := model.Event{ID: uuid.New(), Type: model.EventCommentCreated, ThreadID: parentID, CommentID: reply.ID}
+
+	store := &mocks.StoreMock{
+		ClaimPendingEventsFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+			return []model.Event{event}, nil
+		},
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			if id == parentID {
+				return parent, nil
+			}
+			return reply, nil
+		},
+		MarkEventsProcessedFunc: func(ctx context.Context, ids []uuid.UUID) error {
+			require.Equal(t, []uuid.UUID{event.ID}, ids)
+			return nil
+		},
+	}
+	cache := &mocks.CacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	publisher := &mocks.PublisherMock{
+		PublishFunc: func(ctx context.Context, e model.Event) error { return nil },
+	}
+
+	relay := outbox.NewRelay(store, cache, publisher, nil, testLogger)
+	n, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	calls := cache.SetCommentCalls()
+	require.Len(t, calls, 2)
+	require.Equal(t, reply.ID, calls[0].Comment.ID)
+	require.Equal(t, parentID, calls[1].Comment.ID)
+	require.Len(t, publisher.PublishCalls(), 1)
+}
+
+func TestProcessBatch_DeletedCommentIsEvicted(t *testing.T) {
+	ctx := context.Background()
+	deletedAt := time.Now().UTC()
+	comment := &model.Comment{ID: uuid.New(), Content: model.DeletedContent, DeletedAt: &deletedAt}
+	comment.ThreadID = comment.ID
+
+	store := &mocks.StoreMock{
+		ClaimPendingEventsFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+			return []model.Event{{ID: uuid.New(), Type: model.EventCommentDeleted, CommentID: comment.ID}}, nil
+		},
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return comment, nil
+		},
+		MarkEventsProcessedFunc: func(ctx context.Context, ids []uuid.UUID) error { return nil },
+	}
+	cache := &mocks.CacheMock{
+		DeleteCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	publisher := &mocks.PublisherMock{
+		PublishFunc: func(ctx context.Context, e model.Event) error { return nil },
+	}
+
+	relay := outbox.NewRelay(store, cache, publisher, nil, testLogger)
+	_, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, cache.DeleteCommentCalls(), 1)
+}
+
+func TestProcessBatch_MasksHiddenComment(t *testing.T) {
+	ctx := context.Background()
+	hiddenAt := time.Now().UTC()
+	comment := &model.Comment{ID: uuid.New(), Content: "spam", ContentHTML: "<p>spam</p>", HiddenAt: &hiddenAt}
+	comment.ThreadID = comment.ID
+	// The event was recorded before the comment was hidden, so its payload still has the content
+	event, err := model.NewEvent(model.EventCommentCreated, comment.ThreadID, comment.ID, model.Comment{
+		ID: comment.ID, ThreadID: comment.ThreadID, Content: "spam", ContentHTML: "<p>spam</p>",
+	})
+	require.NoError(t, err)
+
+	store := &mocks.StoreMock{
+		ClaimPendingEventsFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+			return []model.Event{event}, nil
+		},
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return comment, nil
+		},
+		MarkEventsProcessedFunc: func(ctx context.Context, ids []uuid.UUID) error { return nil },
+	}
+	cache := &mocks.CacheMock{
+		DeleteCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	publisher := &mocks.PublisherMock{
+		PublishFunc: func(ctx context.Context, e model.Event) error { return nil },
+	}
+	hooks := &mocks.WebhookQueueMock{
+		EnqueueFunc: func(ctx context.Context, events []model.Event) error { return nil },
+	}
+
+	relay := outbox.NewRelay(store, cache, publisher, hooks, testLogger)
+	_, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, publisher.PublishCalls(), 1)
+	require.Len(t, hooks.EnqueueCalls(), 1)
+	for _, e := range []model.Event{publisher.PublishCalls()[0].Event, hooks.EnqueueCalls()[0].Events[0]} {
+		var payload model.Comment
+		require.NoError(t, json.Unmarshal(e.Payload, &payload))
+		require.Equal(t, model.HiddenContent, payload.Content)
+		require.Equal(t, model.HiddenContentHTML, payload.ContentHTML)
+	}
+}
+
+func TestProcessBatch_StopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	first := model.Event{ID: uuid.New(), Type: model.EventReactionChanged, CommentID: uuid.New()}
+	second := model.Event{ID: uuid.New(), Type: model.EventReactionChanged, CommentID: uuid.New()}
+	third := model.Event{ID: uuid.New(), Type: model.EventReactionChanged, CommentID: uuid.New()}
+
+	store := &mocks.StoreMock{
+		ClaimPendingEventsFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+			return []model.Event{first, second, third}, nil
+		},
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id}, nil
+		},
+		MarkEventsProcessedFunc: func(ctx context.Context, ids []uuid.UUID) error {
+			require.Equal(t, []uuid.UUID{first.ID}, ids)
+			return nil
+		},
+	}
+	cache := &mocks.CacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error {
+			if c.ID == second.CommentID {
+				return errors.New("redis down")
+			}
+			return nil
+		},
+	}
+	publisher := &mocks.PublisherMock{
+		PublishFunc: func(ctx context.Context, e model.Event) error { return nil },
+	}
+
+	relay := outbox.NewRelay(store, cache, publisher, nil, testLogger)
+	n, err := relay.ProcessBatch(ctx)
+	require.Error(t, err)
+	require.Equal(t, 1, n)
+	require.Len(t, publisher.PublishCalls(), 1)
+	require.Len(t, store.MarkEventsProcessedCalls(), 1)
+}
+
+func TestProcessBatch_QueuesWebhooksBeforeMarking(t *testing.T) {
+	ctx := context.Background()
+	first := model.Event{ID: uuid.New(), Type: model.EventReactionChanged, CommentID: uuid.New()}
+	second := model.Event{ID: uuid.New(), Type: model.EventReactionChanged, CommentID: uuid.New()}
+
+	store := &mocks.StoreMock{
+		ClaimPendingEventsFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+			return []model.Event{first, second}, nil
+		},
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id}, nil
+		},
+		MarkEventsProcessedFunc: func(ctx context.Context, ids []uuid.UUID) error { return nil },
+	}
+	cache := &mocks.CacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	publisher := &mocks.PublisherMock{
+		PublishFunc: func(ctx context.Context, e model.Event) error { return nil },
+	}
+	hooks := &mocks.WebhookQueueMock{
+		EnqueueFunc: func(ctx context.Context, events []model.Event) error { return nil },
+	}
+
+	relay := outbox.NewRelay(store, cache, publisher, hooks, testLogger)
+	n, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Len(t, hooks.EnqueueCalls(), 1)
+	require.Equal(t, []model.Event{first, second}, hooks.EnqueueCalls()[0].Events)
+
+	// Events whose webhooks couldn't be queued stay claimed, so they are handled again after the lease
+	hooks.EnqueueFunc = func(ctx context.Context, events []model.Event) error { return errors.New("db down") }
+	_, err = relay.ProcessBatch(ctx)
+	require.Error(t, err)
+	require.Len(t, store.MarkEventsProcessedCalls(), 1)
+}
+
+func TestProcessBatch_ClaimsWithLease(t *testing.T) {
+	store := &mocks.StoreMock{
+		ClaimPendingEventsFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.Event, error) {
+			return nil, nil
+		},
+		MarkEventsProcessedFunc: func(ctx context.Context, ids []uuid.UUID) error { return nil },
+	}
+
+	relay := outbox.NewRelay(store, &mocks.CacheMock{}, &mocks.PublisherMock{}, nil, testLogger)
+	relay.BatchSize = 10
+	relay.Lease = time.Minute
+	_, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	calls := store.ClaimPendingEventsCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, 10, calls[0].Limit)
+	require.Equal(t, time.Minute, calls[0].Lease)
+}
+
+func TestPurge_DeletesInBatchesPastRetention(t *testing.T) {
+	remaining := 25
+	store := &mocks.StoreMock{
+		PurgeProcessedEventsFunc: func(ctx context.Context, before time.Time, limit int) (int, error) {
+			require.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Minute)
+			n := min(limit, remaining)
+			remaining -= n
+			return n, nil
+		},
+	}
+
+	relay := outbox.NewRelay(store, &mocks.CacheMock{}, &mocks.PublisherMock{}, nil, testLogger)
+	relay.BatchSize = 10
+	relay.Retention = time.Hour
+	n, err := relay.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 25, n)
+	require.Len(t, store.PurgeProcessedEventsCalls(), 3)
+}
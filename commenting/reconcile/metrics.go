@@ -0,0 +1,103 @@
+package reconcile
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics accumulates drift statistics across reconciliation passes
+// and serves them in the Prometheus text exposition format.
+type Metrics struct {
+	runs     atomic.Int64
+	checked  atomic.Int64
+	drifted  atomic.Int64
+	repaired atomic.Int64
+	evicted  atomic.Int64
+	skipped  atomic.Int64
+	errors   atomic.Int64
+	lastRun  atomic.Int64
+
+	mu sync.Mutex
+	// fields counts drifted comments by the field that drifted
+	fields map[string]int64
+	// amounts sums the absolute drift by field, e.g. the upvotes the cache was off by
+	amounts map[string]float64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		fields:  make(map[string]int64),
+		amounts: make(map[string]float64),
+	}
+}
+
+// observe adds a finished pass to the totals.
+func (m *Metrics) observe(report *Report) {
+	m.runs.Add(1)
+	m.checked.Add(int64(report.Checked))
+	m.drifted.Add(int64(report.Drifted))
+	m.repaired.Add(int64(report.Repaired))
+	m.evicted.Add(int64(report.Evicted))
+	m.skipped.Add(int64(report.Skipped))
+	m.lastRun.Store(time.Now().Unix())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for field, n := range report.Fields {
+		m.fields[field] += int64(n)
+	}
+}
+
+// addDrift adds the absolute drift found in one comment's field.
+func (m *Metrics) addDrift(field string, amount float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.amounts[field] += amount
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	counter := func(name, help string, v int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("commenting_reconcile_runs_total", "Reconciliation passes run.", m.runs.Load())
+	counter("commenting_reconcile_checked_total", "Cached comments compared with the database.", m.checked.Load())
+	counter("commenting_reconcile_drifted_total", "Cached comments found to differ from the database.", m.drifted.Load())
+	counter("commenting_reconcile_repaired_total", "Drifted cached comments rewritten from the database.", m.repaired.Load())
+	counter("commenting_reconcile_evicted_total", "Cached comments evicted because they no longer exist in the database.", m.evicted.Load())
+	counter("commenting_reconcile_skipped_total", "Cached comments left alone because they changed while being checked or have votes still to be flushed.", m.skipped.Load())
+	counter("commenting_reconcile_errors_total", "Reconciliation passes that failed.", m.errors.Load())
+
+	fmt.Fprintf(w, "# HELP commenting_reconcile_last_run_timestamp_seconds Unix time the last pass finished.\n")
+	fmt.Fprintf(w, "# TYPE commenting_reconcile_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(w, "commenting_reconcile_last_run_timestamp_seconds %d\n", m.lastRun.Load())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP commenting_reconcile_field_drifted_total Drifted cached comments by drifted field.\n")
+	fmt.Fprintf(w, "# TYPE commenting_reconcile_field_drifted_total counter\n")
+	for _, field := range sortedKeys(m.fields) {
+		fmt.Fprintf(w, "commenting_reconcile_field_drifted_total{field=%q} %d\n", field, m.fields[field])
+	}
+
+	fmt.Fprintf(w, "# HELP commenting_reconcile_drift_amount_total Sum of the absolute drift by field.\n")
+	fmt.Fprintf(w, "# TYPE commenting_reconcile_drift_amount_total counter\n")
+	for _, field := range sortedKeys(m.amounts) {
+		fmt.Fprintf(w, "commenting_reconcile_drift_amount_total{field=%q} %g\n", field, m.amounts[field])
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,255 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// Store reads the stored state of comments, which the cache is repaired to match.
+type Store interface {
+	GetCommentByID(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
+	ListPendingVoteComments(ctx context.Context, commentIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// Cache is the part of the comment cache the reconciler audits and repairs. It also holds the lease
+// that lets one replica at a time reconcile, and the scan cursor they share.
+type Cache interface {
+	ScanComments(ctx context.Context, cursor uint64, count int64) ([]model.CachedComment, uint64, error)
+	RepairComment(ctx context.Context, cached model.CachedComment, fresh *model.Comment) (bool, error)
+	EvictComment(ctx context.Context, cached model.CachedComment) (bool, error)
+	AcquireReconcileLease(ctx context.Context, ttl time.Duration) (bool, error)
+	ReconcileCursor(ctx context.Context) (uint64, error)
+	SaveReconcileCursor(ctx context.Context, cursor uint64) error
+}
+
+const (
+	defaultInterval   = 5 * time.Minute
+	defaultBatchSize  = 100
+	defaultSampleSize = 1000
+)
+
+// Report sums up one reconciliation pass.
+type Report struct {
+	Checked  int `json:"checked"`
+	Drifted  int `json:"drifted"`
+	Repaired int `json:"repaired"`
+	Evicted  int `json:"evicted"`
+	// Skipped counts comments left to the next pass: drifted comments that changed while they were
+	// checked, and comments with write-behind votes not yet flushed to the database.
+	Skipped int `json:"skipped"`
+	// Fields counts the drifted comments by the field that drifted; "sorted_set" is a stale sorted set score.
+	Fields map[string]int `json:"fields"`
+}
+
+// Reconciler finds cached comments whose counters or state have drifted from the database
+// and repairs them, hash and sorted sets alike. Each pass checks up to SampleSize comments,
+// continuing the scan of the cache where the previous pass stopped, so that over successive
+// passes the whole cache is covered.
+//
+// A repair only goes ahead if the cached hash is unchanged since it was read. A vote committed
+// between reading the cache and the database can still be counted twice in the cache, until
+// the outbox relay writes the comment's absolute state for that vote's event. Comments with
+// write-behind votes still to be flushed are skipped: the cache already counts those votes
+// and the database doesn't yet.
+type Reconciler struct {
+	store   Store
+	cache   Cache
+	logger  *slog.Logger
+	Metrics *Metrics
+
+	Interval   time.Duration
+	BatchSize  int
+	SampleSize int
+	// DryRun reports drift without repairing it.
+	DryRun bool
+
+	cursor uint64
+}
+
+func NewReconciler(store Store, cache Cache, logger *slog.Logger) *Reconciler {
+	return &Reconciler{
+		store:      store,
+		cache:      cache,
+		logger:     logger,
+		Metrics:    NewMetrics(),
+		Interval:   defaultInterval,
+		BatchSize:  defaultBatchSize,
+		SampleSize: defaultSampleSize,
+	}
+}
+
+// Run reconciles a sample of the cache every Interval until ctx is cancelled. Every replica runs it,
+// but a pass only starts in the replica that takes the lease, which lasts most of an Interval, so
+// the cache is reconciled about once per Interval, continuing the scan where the last pass stopped
+// whichever replica ran it.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		report, ran, err := r.runLeased(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("cache reconciliation failed", slog.Any("error", err))
+		} else if ran && report.Drifted > 0 {
+			r.logger.Warn("repaired cache drift",
+				slog.Int("checked", report.Checked),
+				slog.Int("drifted", report.Drifted),
+				slog.Int("repaired", report.Repaired),
+				slog.Int("evicted", report.Evicted),
+				slog.Any("fields", report.Fields),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLeased runs a pass from the shared scan cursor if this replica takes the lease, and saves
+// the cursor it stopped at. It reports whether the pass ran.
+func (r *Reconciler) runLeased(ctx context.Context) (Report, bool, error) {
+	// The lease expires a little before the next tick, so the replica holding it can take it again
+	ok, err := r.cache.AcquireReconcileLease(ctx, r.Interval-r.Interval/10)
+	if err != nil || !ok {
+		return Report{}, false, err
+	}
+
+	if r.cursor, err = r.cache.ReconcileCursor(ctx); err != nil {
+		return Report{}, false, err
+	}
+	report, err := r.RunOnce(ctx)
+	if saveErr := r.cache.SaveReconcileCursor(ctx, r.cursor); err == nil {
+		err = saveErr
+	}
+	return report, true, err
+}
+
+// RunOnce checks up to SampleSize cached comments, or the rest of the cache if it is smaller,
+// and repairs those that drifted. A SampleSize of 0 or less scans the whole cache.
+func (r *Reconciler) RunOnce(ctx context.Context) (Report, error) {
+	report := Report{Fields: map[string]int{}}
+	defer r.Metrics.observe(&report)
+
+	started := false
+	for r.SampleSize <= 0 || report.Checked < r.SampleSize {
+		// A full scan ends where it started, back at cursor 0
+		if started && r.cursor == 0 {
+			break
+		}
+		started = true
+
+		batch, next, err := r.cache.ScanComments(ctx, r.cursor, int64(r.BatchSize))
+		if err != nil {
+			r.Metrics.errors.Add(1)
+			return report, err
+		}
+		r.cursor = next
+
+		// Looked up after the batch was read, so votes cached before the read are flushed or seen pending
+		ids := make([]uuid.UUID, 0, len(batch))
+		for _, cached := range batch {
+			ids = append(ids, cached.Comment.ID)
+		}
+		pending, err := r.store.ListPendingVoteComments(ctx, ids)
+		if err != nil {
+			r.Metrics.errors.Add(1)
+			return report, err
+		}
+
+		for _, cached := range batch {
+			if pending[cached.Comment.ID] {
+				report.Checked++
+				report.Skipped++
+				continue
+			}
+			if err := r.check(ctx, cached, &report); err != nil {
+				r.Metrics.errors.Add(1)
+				return report, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// check compares one cached comment with the database and repairs or evicts it if it drifted.
+func (r *Reconciler) check(ctx context.Context, cached model.CachedComment, report *Report) error {
+	report.Checked++
+
+	stored, err := r.store.GetCommentByID(ctx, cached.Comment.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		report.Drifted++
+		report.Fields["missing"]++
+		if r.DryRun {
+			return nil
+		}
+		evicted, err := r.cache.EvictComment(ctx, cached)
+		if err != nil {
+			return err
+		}
+		if evicted {
+			report.Evicted++
+		} else {
+			report.Skipped++
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	drift := Drift(cached, stored)
+	if len(drift) == 0 {
+		return nil
+	}
+
+	report.Drifted++
+	for field, delta := range drift {
+		report.Fields[field]++
+		r.Metrics.addDrift(field, math.Abs(delta))
+	}
+	if r.DryRun {
+		return nil
+	}
+
+	repaired, err := r.cache.RepairComment(ctx, cached, stored)
+	if err != nil {
+		return err
+	}
+	if repaired {
+		report.Repaired++
+	} else {
+		report.Skipped++
+	}
+	return nil
+}
+
+// Drift returns how far a cached comment is from the stored one, by field: the fields of
+// model.CacheDrift, and "sorted_set" for the largest error among its sorted set scores.
+// A comment that is no longer listed but still in a sorted set counts as a sorted set error of 1.
+func Drift(cached model.CachedComment, stored *model.Comment) map[string]float64 {
+	drift := make(map[string]float64)
+	for field, delta := range model.CacheDrift(&cached.Comment, stored) {
+		drift[field] = float64(delta)
+	}
+
+	for field, score := range cached.Scores {
+		if !stored.Listed() {
+			drift["sorted_set"] = max(drift["sorted_set"], 1)
+			continue
+		}
+		want := stored.SortScore(field)
+		if diff := math.Abs(score - want); diff > 1e-9*max(1, math.Abs(want)) {
+			drift["sorted_set"] = max(drift["sorted_set"], diff)
+		}
+	}
+	return drift
+}
@@ -0,0 +1,185 @@
+package reconcile_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/reconcile"
+	"github.com/kiremitrov123/onboarding/commenting/reconcile/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func noPendingVotes(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return map[uuid.UUID]bool{}, nil
+}
+
+func TestRunOnce_RepairsAndEvicts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	current := model.Comment{ID: uuid.New(), ThreadID: uuid.New(), Upvotes: 2, CreatedAt: now}
+	drifted := model.Comment{ID: uuid.New(), ThreadID: uuid.New(), Upvotes: 5, ReplyCount: 1, CreatedAt: now}
+	gone := model.Comment{ID: uuid.New(), ThreadID: uuid.New(), CreatedAt: now}
+
+	stored := map[uuid.UUID]*model.Comment{
+		current.ID: &current,
+		drifted.ID: {ID: drifted.ID, ThreadID: drifted.ThreadID, Upvotes: 3, ReplyCount: 1, CreatedAt: now},
+	}
+	store := &mocks.StoreMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			if c, ok := stored[id]; ok {
+				return c, nil
+			}
+			return nil, model.ErrNotFound
+		},
+		ListPendingVoteCommentsFunc: noPendingVotes,
+	}
+	cache := &mocks.CacheMock{
+		ScanCommentsFunc: func(ctx context.Context, cursor uint64, count int64) ([]model.CachedComment, uint64, error) {
+			return []model.CachedComment{
+				{Comment: current, Scores: map[string]float64{"upvotes": 2}},
+				{Comment: drifted, Scores: map[string]float64{"upvotes": 5}},
+				{Comment: gone},
+			}, 0, nil
+		},
+		RepairCommentFunc: func(ctx context.Context, cached model.CachedComment, fresh *model.Comment) (bool, error) {
+			return true, nil
+		},
+		EvictCommentFunc: func(ctx context.Context, cached model.CachedComment) (bool, error) {
+			return true, nil
+		},
+	}
+
+	r := reconcile.NewReconciler(store, cache, testLogger)
+	report, err := r.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Checked)
+	require.Equal(t, 2, report.Drifted)
+	require.Equal(t, 1, report.Repaired)
+	require.Equal(t, 1, report.Evicted)
+	require.Equal(t, map[string]int{"upvotes": 1, "sorted_set": 1, "missing": 1}, report.Fields)
+
+	repairs := cache.RepairCommentCalls()
+	require.Len(t, repairs, 1)
+	require.Equal(t, 3, repairs[0].Fresh.Upvotes)
+	require.Equal(t, gone.ID, cache.EvictCommentCalls()[0].Cached.Comment.ID)
+
+	rr := httptest.NewRecorder()
+	r.Metrics.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	require.Contains(t, rr.Body.String(), "commenting_reconcile_drifted_total 2\n")
+	require.Contains(t, rr.Body.String(), `commenting_reconcile_drift_amount_total{field="upvotes"} 2`)
+}
+
+func TestRunOnce_SkipsPendingVotes(t *testing.T) {
+	// The cache counts a write-behind upvote the database doesn't have yet
+	comment := model.Comment{ID: uuid.New(), ThreadID: uuid.New(), Upvotes: 1}
+
+	store := &mocks.StoreMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, ThreadID: comment.ThreadID}, nil
+		},
+		ListPendingVoteCommentsFunc: func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+			return map[uuid.UUID]bool{comment.ID: true}, nil
+		},
+	}
+	cache := &mocks.CacheMock{
+		ScanCommentsFunc: func(ctx context.Context, cursor uint64, count int64) ([]model.CachedComment, uint64, error) {
+			return []model.CachedComment{{Comment: comment}}, 0, nil
+		},
+	}
+
+	report, err := reconcile.NewReconciler(store, cache, testLogger).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Checked)
+	require.Equal(t, 1, report.Skipped)
+	require.Zero(t, report.Drifted)
+	require.Empty(t, store.GetCommentByIDCalls())
+	require.Empty(t, cache.RepairCommentCalls())
+}
+
+func TestRun_OnlyWithLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &mocks.StoreMock{ListPendingVoteCommentsFunc: noPendingVotes}
+	held := true
+	cache := &mocks.CacheMock{
+		AcquireReconcileLeaseFunc: func(ctx context.Context, ttl time.Duration) (bool, error) {
+			// Another replica holds the lease on the first tick, this one takes it on the second
+			if held {
+				held = false
+				return false, nil
+			}
+			return true, nil
+		},
+		ReconcileCursorFunc: func(ctx context.Context) (uint64, error) { return 7, nil },
+		ScanCommentsFunc: func(ctx context.Context, cursor uint64, count int64) ([]model.CachedComment, uint64, error) {
+			return nil, 0, nil
+		},
+		SaveReconcileCursorFunc: func(ctx context.Context, cursor uint64) error {
+			cancel()
+			return nil
+		},
+	}
+
+	r := reconcile.NewReconciler(store, cache, testLogger)
+	r.Interval = time.Millisecond
+	r.Run(ctx)
+
+	require.Len(t, cache.AcquireReconcileLeaseCalls(), 2)
+	require.Less(t, cache.AcquireReconcileLeaseCalls()[0].Ttl, r.Interval)
+
+	// The pass continues the scan from the shared cursor and saves where it stopped
+	scans := cache.ScanCommentsCalls()
+	require.Len(t, scans, 1)
+	require.Equal(t, uint64(7), scans[0].Cursor)
+	require.Equal(t, uint64(0), cache.SaveReconcileCursorCalls()[0].Cursor)
+}
+
+func TestRunOnce_SamplesAcrossPasses(t *testing.T) {
+	ctx := context.Background()
+	comment := model.Comment{ID: uuid.New(), ThreadID: uuid.New(), Likes: 1}
+
+	store := &mocks.StoreMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, ThreadID: comment.ThreadID}, nil
+		},
+		ListPendingVoteCommentsFunc: noPendingVotes,
+	}
+	// Two batches of one comment each, then the scan wraps around
+	cache := &mocks.CacheMock{
+		ScanCommentsFunc: func(ctx context.Context, cursor uint64, count int64) ([]model.CachedComment, uint64, error) {
+			return []model.CachedComment{{Comment: comment}}, (cursor + 1) % 2, nil
+		},
+	}
+
+	r := reconcile.NewReconciler(store, cache, testLogger)
+	r.SampleSize = 1
+	r.DryRun = true
+
+	report, err := r.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Checked)
+	require.Equal(t, 1, report.Drifted)
+
+	// The next pass picks up where the last one stopped
+	_, err = r.RunOnce(ctx)
+	require.NoError(t, err)
+	calls := cache.ScanCommentsCalls()
+	require.Equal(t, []uint64{0, 1}, []uint64{calls[0].Cursor, calls[1].Cursor})
+	require.Empty(t, cache.RepairCommentCalls())
+
+	// A full scan stops once the cursor is back at the start
+	r.SampleSize = 0
+	report, err = r.RunOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Checked)
+}
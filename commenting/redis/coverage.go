@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// A sorted set only holds the top maxItems comments of its ordering, and only holds all of them once
+// the first page has been loaded into it. Its coverage records how far down the ordering it can be
+// trusted: every listed comment at or before the coverage position is in the set. A set without
+// coverage can't be trusted at all.
+
+// coverAll is the coverage of a sorted set that holds every listed comment of its thread.
+var coverAll = model.Cursor{Score: math.Inf(-1)}
+
+// coverageKey is the hash holding the coverage position of a thread's sorted set for field.
+// It is given the same expiry as the sorted set whenever either is written.
+func coverageKey(threadID uuid.UUID, field string) string {
+	return fmt.Sprintf("%s:%s:%s:covered", prefix, threadID.String(), field)
+}
+
+func coverageHash(c model.Cursor) map[string]interface{} {
+	return map[string]interface{}{
+		"s": strconv.FormatFloat(c.Score, 'g', -1, 64),
+		"t": c.CreatedAt,
+		"i": c.ID.String(),
+	}
+}
+
+// parseCoverage reads a coverage hash, reporting false if there is none.
+func parseCoverage(fields map[string]string) (model.Cursor, bool) {
+	score, err1 := strconv.ParseFloat(fields["s"], 64)
+	createdAt, err2 := strconv.ParseInt(fields["t"], 10, 64)
+	id, err3 := uuid.Parse(fields["i"])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return model.Cursor{}, false
+	}
+	return model.Cursor{Score: score, CreatedAt: createdAt, ID: id}, true
+}
+
+// trimScript trims a sorted set to its top ARGV[1] members. Redis breaks ties on score by member rather
+// than by created_at and id, so comments sharing the highest trimmed score may be gone; the coverage is
+// raised to cover only comments scored above it, the position given by ARGV[2] and ARGV[3].
+var trimScript = redis.NewScript(`
+local cut = redis.call('ZREVRANGE', KEYS[1], ARGV[1], ARGV[1], 'WITHSCORES')[2]
+if not cut then
+	return 0
+end
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[1]) - 1)
+local covered = redis.call('HGET', KEYS[2], 's')
+if covered then
+	local score = tonumber(covered)
+	if not score or score <= tonumber(cut) then
+		redis.call('HSET', KEYS[2], 's', cut, 't', ARGV[2], 'i', ARGV[3])
+	end
+end
+return 1
+`)
+
+// trimArgs are the created_at and id of trimScript's coverage position, after every comment with the cut score.
+var trimArgs = []interface{}{int64(math.MaxInt64), "ffffffff-ffff-ffff-ffff-ffffffffffff"}
+
+// listCovered serves a page from a sorted set whose coverage is covered. A page that runs past the
+// coverage is completed through fallback, from where the cached part ends.
+func (rc *RedisCache) listCovered(
+	ctx context.Context,
+	threadID uuid.UUID,
+	sortKey string,
+	keys []string,
+	covered model.Cursor,
+	cursor *model.Cursor,
+	limit int,
+	fallback model.QueryCommentsFunc,
+) ([]model.Comment, error) {
+	cached := rc.getComments(ctx, keys)
+	if len(cached) < len(keys) {
+		// Some comment hashes expired, leaving gaps in the set
+		return rc.loadComments(ctx, threadID, sortKey, cursor, limit, fallback)
+	}
+
+	comments := make([]model.Comment, 0, len(cached))
+	for _, c := range cached {
+		if !covered.Before(model.NewCursor(&c, sortKey)) {
+			comments = append(comments, c)
+		}
+	}
+
+	complete := math.IsInf(covered.Score, -1)
+	if cursor != nil && cursor.Backward {
+		// Everything before a covered cursor is cached
+		if complete || !covered.Before(*cursor) {
+			return pageComments(comments, sortKey, cursor, limit), nil
+		}
+		return rc.loadComments(ctx, threadID, sortKey, cursor, limit, fallback)
+	}
+
+	page := pageComments(comments, sortKey, cursor, limit)
+	if complete || len(page) == limit {
+		return page, nil
+	}
+	if len(page) == 0 {
+		return rc.loadComments(ctx, threadID, sortKey, cursor, limit, fallback)
+	}
+
+	last := model.NewCursor(&page[len(page)-1], sortKey)
+	rest, err := rc.loadComments(ctx, threadID, sortKey, &last, limit-len(page), fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	// A comment rescored since it was cached can show up on both sides of the seam
+	seen := make(map[uuid.UUID]bool, len(page))
+	for _, c := range page {
+		seen[c.ID] = true
+	}
+	for _, c := range rest {
+		if !seen[c.ID] {
+			page = append(page, c)
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// eventsChannel is the Pub/Sub channel domain events are published on.
+	eventsChannel = "comment-events"
+
+	// streamMaxLen bounds each thread's event stream, which is also the Last-Event-ID replay window.
+	streamMaxLen = 1000
+	streamTTL    = 24 * time.Hour
+)
+
+// Publish appends a domain event to its thread's stream, which live clients on every API replica
+// follow, and sends it as JSON on the comment events channel. The outbox relay publishes each committed
+// event once, so stream entries carry the same event ID as the outbox.
+func (rc *RedisCache) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	streamKey := threadStreamKey(event.ThreadID)
+	_, err = rc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: streamKey,
+			MaxLen: streamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"event": data},
+		})
+		pipe.Expire(ctx, streamKey, streamTTL)
+		pipe.Publish(ctx, eventsChannel, data)
+		return nil
+	})
+	return err
+}
+
+// LastThreadEventID returns the position of the newest event in a thread's stream, or "0" if it has none.
+func (rc *RedisCache) LastThreadEventID(ctx context.Context, threadID uuid.UUID) (string, error) {
+	last, err := rc.client.XRevRangeN(ctx, threadStreamKey(threadID), "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(last) == 0 {
+		return "0", nil
+	}
+	return last[0].ID, nil
+}
+
+// ThreadEventsBetween returns the events of a thread's stream after afterID, up to and including untilID.
+func (rc *RedisCache) ThreadEventsBetween(ctx context.Context, threadID uuid.UUID, afterID, untilID string) ([]model.StreamEvent, error) {
+	msgs, err := rc.client.XRange(ctx, threadStreamKey(threadID), "("+afterID, untilID).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeStreamEvents(msgs), nil
+}
+
+// ReadThreadEvents waits up to block for events after the given position in any of the given threads'
+// streams. It returns the events and the new position of each stream that had any; malformed entries
+// are skipped but still move the position past them. It returns no events when block elapses.
+func (rc *RedisCache) ReadThreadEvents(ctx context.Context, after map[uuid.UUID]string, block time.Duration) ([]model.StreamEvent, map[uuid.UUID]string, error) {
+	keys := make([]string, 0, 2*len(after))
+	ids := make([]string, 0, len(after))
+	threads := make(map[string]uuid.UUID, len(after))
+	for threadID, afterID := range after {
+		key := threadStreamKey(threadID)
+		keys = append(keys, key)
+		ids = append(ids, afterID)
+		threads[key] = threadID
+	}
+
+	streams, err := rc.client.XRead(ctx, &redis.XReadArgs{
+		Streams: append(keys, ids...),
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, nil // nothing new before the timeout
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []model.StreamEvent
+	positions := make(map[uuid.UUID]string, len(streams))
+	for _, stream := range streams {
+		if len(stream.Messages) == 0 {
+			continue
+		}
+		positions[threads[stream.Stream]] = stream.Messages[len(stream.Messages)-1].ID
+		out = append(out, decodeStreamEvents(stream.Messages)...)
+	}
+	return out, positions, nil
+}
+
+// decodeStreamEvents parses stream entries written by Publish, skipping malformed ones.
+func decodeStreamEvents(msgs []redis.XMessage) []model.StreamEvent {
+	out := make([]model.StreamEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		raw, _ := msg.Values["event"].(string)
+		var event model.Event
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			continue
+		}
+		out = append(out, model.StreamEvent{StreamID: msg.ID, Event: event})
+	}
+	return out
+}
+
+func threadStreamKey(threadID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:events", prefix, threadID.String())
+}
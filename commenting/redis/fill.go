@@ -0,0 +1,251 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// fillTimeout bounds one load of a page from the database: the fill lock expires after it,
+	// and replicas waiting on the lock stop waiting and load the page themselves.
+	fillTimeout = 5 * time.Second
+	// fillPoll is how often a replica waiting on a fill checks for its result.
+	fillPoll = 25 * time.Millisecond
+	// fillResultTTL is how long the result of a fill is kept for the replicas waiting on it.
+	fillResultTTL = 5 * time.Second
+	// emptyTTL is how long a thread found without comments in the database is remembered as empty.
+	emptyTTL = 30 * time.Second
+)
+
+// releaseLockScript deletes a lock only if it is still held by the given token,
+// so a holder whose lock expired can't release the lock of the next one.
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// markEmptyScript marks a thread as empty unless its sorted set was created in the meantime,
+// which happens when a comment is cached after the database was found empty.
+var markEmptyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('SET', KEYS[1], 1, 'PX', ARGV[1])
+return 1
+`)
+
+// emptyKey marks a thread that has no listed comments in the database. Caching a listed comment clears it.
+func emptyKey(threadID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:empty", prefix, threadID.String())
+}
+
+// loadComments loads a page the cache can't serve through fallback, one load per page at a time.
+// Concurrent calls in this process share one load, and across processes the one holding the page's
+// fill lock in Redis loads it while the others wait for its result, so a burst of requests for a cold
+// thread costs a single database query.
+func (rc *RedisCache) loadComments(
+	ctx context.Context,
+	threadID uuid.UUID,
+	sortKey string,
+	cursor *model.Cursor,
+	limit int,
+	fallback model.QueryCommentsFunc,
+) ([]model.Comment, error) {
+	page := "first"
+	if cursor != nil {
+		page = cursor.Encode()
+	}
+	flight := fmt.Sprintf("%s:%s:%s:%d", threadID.String(), sortKey, page, limit)
+
+	// The load outlives a caller that gives up, since other callers may be waiting on it.
+	// It may wait up to fillTimeout for another replica, then take as long again to load the page itself.
+	ch := rc.fills.DoChan(flight, func() (any, error) {
+		fillCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*fillTimeout)
+		defer cancel()
+		return rc.fill(fillCtx, flight, threadID, sortKey, cursor, limit, fallback)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Every caller gets its own copy, as callers fill in per-viewer fields
+		return slices.Clone(res.Val.([]model.Comment)), nil
+	}
+}
+
+// fill loads a page while holding its fill lock, or waits for the replica holding it.
+func (rc *RedisCache) fill(
+	ctx context.Context,
+	flight string,
+	threadID uuid.UUID,
+	sortKey string,
+	cursor *model.Cursor,
+	limit int,
+	fallback model.QueryCommentsFunc,
+) ([]model.Comment, error) {
+	lockKey := fmt.Sprintf("%s:fill:%s", prefix, flight)
+	resultKey := fmt.Sprintf("%s:page:%s", prefix, flight)
+
+	token := uuid.NewString()
+	locked, err := rc.client.SetNX(ctx, lockKey, token, fillTimeout).Result()
+	if err != nil {
+		// Without Redis there is nothing to coordinate with
+		return fallback(ctx, threadID, cursor, limit)
+	}
+	if !locked {
+		if comments, ok := rc.awaitFill(ctx, lockKey, resultKey); ok {
+			return comments, nil
+		}
+		// The holder failed or is too slow; load the page here, leaving the cache to the holder
+		return fallback(ctx, threadID, cursor, limit)
+	}
+	defer releaseLockScript.Run(context.WithoutCancel(ctx), rc.client, []string{lockKey}, token)
+
+	comments, err := fallback(ctx, threadID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Once the first page is cached, the sorted set covers the ordering down to its last comment,
+	// or all of it if the page wasn't full
+	var coverage *model.Cursor
+	if cursor == nil && limit > 0 {
+		coverage = &coverAll
+		if len(comments) >= limit {
+			last := model.NewCursor(&comments[len(comments)-1], sortKey)
+			coverage = &last
+		}
+	}
+	rc.cacheComments(ctx, threadID, sortKey, comments, coverage)
+
+	keys := make([]string, len(comments))
+	for i, c := range comments {
+		keys[i] = fmt.Sprintf("%s:%s", prefix, c.ID.String())
+	}
+	_ = rc.client.Set(ctx, resultKey, strings.Join(keys, " "), fillResultTTL).Err()
+
+	if cursor == nil && len(comments) == 0 {
+		_ = markEmptyScript.Run(ctx, rc.client,
+			[]string{emptyKey(threadID), sortedSetKey(threadID, sortKey)},
+			emptyTTL.Milliseconds(),
+		).Err()
+	}
+	return comments, nil
+}
+
+// awaitFill waits for the replica holding a fill lock to publish its result: the keys of the page's
+// comments, which it cached. It reports false if the lock went away without a result, the wait timed
+// out, or some of the comments are no longer cached.
+func (rc *RedisCache) awaitFill(ctx context.Context, lockKey, resultKey string) ([]model.Comment, bool) {
+	ticker := time.NewTicker(fillPoll)
+	defer ticker.Stop()
+	timeout := time.After(fillTimeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-timeout:
+			return nil, false
+		case <-ticker.C:
+		}
+
+		var result *redis.StringCmd
+		var held *redis.IntCmd
+		_, _ = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			result = pipe.Get(ctx, resultKey)
+			held = pipe.Exists(ctx, lockKey)
+			return nil
+		})
+
+		val, err := result.Result()
+		if errors.Is(err, redis.Nil) {
+			if held.Val() == 0 {
+				return nil, false
+			}
+			continue
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		keys := strings.Fields(val)
+		comments := rc.getComments(ctx, keys)
+		if len(comments) < len(keys) {
+			return nil, false
+		}
+		return comments, true
+	}
+}
+
+// cacheComments caches comments loaded from the database. A comment that is already cached is kept
+// as it is, since votes may have reached the cache after the database was read; only its sorted set
+// entries are restored from it. With a coverage, the comments are the first page of the sort field's
+// ordering and the sorted set's coverage is extended to it. Caching is best effort and gives up if the
+// comments or the coverage change meanwhile.
+func (rc *RedisCache) cacheComments(ctx context.Context, threadID uuid.UUID, sortKey string, comments []model.Comment, coverage *model.Cursor) {
+	if len(comments) == 0 && coverage == nil {
+		return
+	}
+
+	zKey := sortedSetKey(threadID, sortKey)
+	coverKey := coverageKey(threadID, sortKey)
+	keys := make([]string, len(comments))
+	for i, c := range comments {
+		keys[i] = fmt.Sprintf("%s:%s", prefix, c.ID.String())
+	}
+
+	_ = rc.client.Watch(ctx, func(tx *redis.Tx) error {
+		var covered *redis.MapStringStringCmd
+		cmds := make([]*redis.MapStringStringCmd, len(keys))
+		_, err := tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			covered = pipe.HGetAll(ctx, coverKey)
+			for i, k := range keys {
+				cmds[i] = pipe.HGetAll(ctx, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			// Coverage is extended before the comments are added, so that trimming them narrows it again
+			if current, ok := parseCoverage(covered.Val()); coverage != nil && (!ok || current.Before(*coverage)) {
+				pipe.HSet(ctx, coverKey, coverageHash(*coverage))
+				pipe.Expire(ctx, coverKey, ttl)
+				pipe.Expire(ctx, zKey, ttl)
+			}
+
+			for i := range comments {
+				c := comments[i]
+				fields := cmds[i].Val()
+				if cached, err := model.CommentFromHash(fields); len(fields) > 0 && err == nil {
+					c = cached
+				} else {
+					pipe.HSet(ctx, keys[i], c.ToHash())
+					pipe.Expire(ctx, keys[i], ttl)
+				}
+				if c.Listed() {
+					addToSortedSets(ctx, pipe, &c, keys[i])
+				}
+			}
+			return nil
+		})
+		return err
+	}, append(keys, coverKey)...)
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const unreadTTL = 24 * time.Hour
+
+// incrIfExistsScript adjusts a cached counter only if it is cached, so that a missing count
+// is recomputed from the database instead of starting from the delta. It never goes below zero.
+var incrIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if n < 0 then
+	redis.call('SET', KEYS[1], 0, 'KEEPTTL')
+	return 0
+end
+return n
+`)
+
+func unreadKey(userID string) string {
+	return fmt.Sprintf("notifications:%s:unread", userID)
+}
+
+// GetUnreadCount returns the cached unread notification count of a user, or redis.Nil if it isn't cached.
+func (rc *RedisCache) GetUnreadCount(ctx context.Context, userID string) (int, error) {
+	return rc.client.Get(ctx, unreadKey(userID)).Int()
+}
+
+// FillUnreadCount caches the unread notification count of a user as counted in the database, unless
+// it is cached already: the cached count may have been incremented for notifications the count missed.
+func (rc *RedisCache) FillUnreadCount(ctx context.Context, userID string, count int) error {
+	return rc.client.SetNX(ctx, unreadKey(userID), count, unreadTTL).Err()
+}
+
+// IncrUnreadCount adjusts a cached unread count by delta. Uncached counts are left alone.
+func (rc *RedisCache) IncrUnreadCount(ctx context.Context, userID string, delta int) error {
+	return incrIfExistsScript.Run(ctx, rc.client, []string{unreadKey(userID)}, delta).Err()
+}
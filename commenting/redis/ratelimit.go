@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitPrefix = "ratelimit"
+
+// gcraScript applies the generic cell rate algorithm to every key at once. Each key stores its
+// theoretical arrival time (TAT) in milliseconds; a request is allowed when it arrives no earlier
+// than TAT - limit*interval. The request is counted against every key only if all of them allow it,
+// so a rejection by one budget doesn't spend the others. Redis' own clock is used so that API
+// instances with skewed clocks share the same budgets.
+//
+// ARGV holds an interval and a limit per key. For each key the script returns
+// remaining, retry-after and reset-after (ms), preceded by 1 if the request was allowed.
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local allowed = 1
+local state = {}
+for i, key in ipairs(KEYS) do
+	local interval = tonumber(ARGV[2 * i - 1])
+	local limit = tonumber(ARGV[2 * i])
+	local tat = math.max(tonumber(redis.call('GET', key) or now), now)
+	local allow_at = tat + interval - interval * limit
+	if now < allow_at then
+		allowed = 0
+	end
+	state[i] = {interval, tat, allow_at}
+end
+
+local out = {allowed}
+for i, key in ipairs(KEYS) do
+	local interval, tat, allow_at = state[i][1], state[i][2], state[i][3]
+	if allowed == 1 then
+		local new_tat = tat + interval
+		redis.call('SET', key, new_tat, 'PX', new_tat - now)
+		table.insert(out, math.floor((now - allow_at) / interval))
+		table.insert(out, 0)
+		table.insert(out, new_tat - now)
+	else
+		table.insert(out, math.max(math.floor((now - allow_at) / interval), 0))
+		table.insert(out, math.max(allow_at - now, 0))
+		table.insert(out, tat - now)
+	end
+end
+return out
+`)
+
+// AllowRequest counts a request against every budget and reports the most restrictive one.
+// Budgets with a zero limit are skipped.
+func (rc *RedisCache) AllowRequest(ctx context.Context, budgets []model.RateBudget) (model.RateLimitResult, error) {
+	keys := make([]string, 0, len(budgets))
+	args := make([]any, 0, 2*len(budgets))
+	limits := make([]int, 0, len(budgets))
+	for _, b := range budgets {
+		if b.Limit.Limit <= 0 {
+			continue
+		}
+		keys = append(keys, fmt.Sprintf("%s:%s", rateLimitPrefix, b.Key))
+		args = append(args, max(b.Limit.Interval().Milliseconds(), 1), b.Limit.Limit)
+		limits = append(limits, b.Limit.Limit)
+	}
+	if len(keys) == 0 {
+		return model.RateLimitResult{Allowed: true}, nil
+	}
+
+	vals, err := gcraScript.Run(ctx, rc.client, keys, args...).Int64Slice()
+	if err != nil {
+		return model.RateLimitResult{}, err
+	}
+
+	result := model.RateLimitResult{Allowed: vals[0] == 1}
+	for i, limit := range limits {
+		remaining := int(vals[1+3*i])
+		retryAfter := time.Duration(vals[2+3*i]) * time.Millisecond
+		resetAfter := time.Duration(vals[3+3*i]) * time.Millisecond
+
+		// Report the budget that lets the client back in last, or the one closest to running out
+		tighter := retryAfter > result.RetryAfter ||
+			(retryAfter == result.RetryAfter && (i == 0 || remaining < result.Remaining))
+		if tighter {
+			result.Limit = limit
+			result.Remaining = remaining
+			result.RetryAfter = retryAfter
+			result.ResetAfter = resetAfter
+		}
+	}
+	return result, nil
+}
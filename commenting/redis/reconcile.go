@@ -0,0 +1,184 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	reconcileLeaseKey  = "reconcile:lease"
+	reconcileCursorKey = "reconcile:cursor"
+)
+
+// AcquireReconcileLease takes the lease on reconciling the cache for ttl, reporting false
+// if another replica holds it. The lease isn't released; it expires.
+func (rc *RedisCache) AcquireReconcileLease(ctx context.Context, ttl time.Duration) (bool, error) {
+	return rc.client.SetNX(ctx, reconcileLeaseKey, 1, ttl).Result()
+}
+
+// ReconcileCursor returns the SCAN cursor the last reconciliation pass stopped at, or 0 if none did.
+func (rc *RedisCache) ReconcileCursor(ctx context.Context) (uint64, error) {
+	cursor, err := rc.client.Get(ctx, reconcileCursorKey).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return cursor, err
+}
+
+// SaveReconcileCursor stores the SCAN cursor for the next reconciliation pass, whichever replica runs it.
+func (rc *RedisCache) SaveReconcileCursor(ctx context.Context, cursor uint64) error {
+	return rc.client.Set(ctx, reconcileCursorKey, cursor, 0).Err()
+}
+
+// ScanComments reads a batch of cached comment hashes, starting at a SCAN cursor, along with
+// their scores in the thread's sorted sets. It returns the cursor to continue from, 0 once the
+// whole keyspace has been scanned. SCAN may return a key more than once across batches.
+func (rc *RedisCache) ScanComments(ctx context.Context, cursor uint64, count int64) ([]model.CachedComment, uint64, error) {
+	keys, next, err := rc.client.ScanType(ctx, cursor, prefix+":*", count, "hash").Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Comment hashes are comments:{id}; the thread's sorted sets and streams have more segments
+	commentKeys := keys[:0]
+	for _, k := range keys {
+		if strings.Count(k, ":") == 1 {
+			commentKeys = append(commentKeys, k)
+		}
+	}
+
+	hashes := make([]*redis.MapStringStringCmd, len(commentKeys))
+	_, err = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, k := range commentKeys {
+			hashes[i] = pipe.HGetAll(ctx, k)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, err
+	}
+
+	comments := make([]model.CachedComment, 0, len(commentKeys))
+	for _, cmd := range hashes {
+		fields, err := cmd.Result()
+		if err != nil || len(fields) == 0 {
+			continue // expired since the scan
+		}
+		comment, err := model.CommentFromHash(fields)
+		if err != nil {
+			continue
+		}
+		comments = append(comments, model.CachedComment{Comment: comment})
+	}
+
+	scores := make([][]*redis.FloatCmd, len(comments))
+	_, err = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, c := range comments {
+			commentKey := fmt.Sprintf("%s:%s", prefix, c.Comment.ID.String())
+			scores[i] = make([]*redis.FloatCmd, len(sortFields))
+			for j, field := range sortFields {
+				zKey := sortedSetKey(c.Comment.ThreadID, field)
+				scores[i][j] = pipe.ZScore(ctx, zKey, commentKey)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, err
+	}
+
+	for i := range comments {
+		comments[i].Scores = make(map[string]float64)
+		for j, field := range sortFields {
+			if score, err := scores[i][j].Result(); err == nil {
+				comments[i].Scores[field] = score
+			}
+		}
+	}
+	return comments, next, nil
+}
+
+// RepairComment overwrites a cached comment with fresh state from the database and rescores it in
+// the sorted sets it is a member of, or removes it from them if it is no longer listed. The repair
+// is skipped, reporting false, if the hash changed since it was read as cached, because a concurrent
+// write has already moved it on.
+func (rc *RedisCache) RepairComment(ctx context.Context, cached model.CachedComment, fresh *model.Comment) (bool, error) {
+	commentKey := fmt.Sprintf("%s:%s", prefix, fresh.ID.String())
+
+	repaired := false
+	err := rc.client.Watch(ctx, func(tx *redis.Tx) error {
+		if ok, err := unchanged(ctx, tx, commentKey, &cached.Comment); err != nil || !ok {
+			return err
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			// The hash keeps its TTL, so the repair doesn't extend how long the comment stays cached
+			pipe.HSet(ctx, commentKey, fresh.ToHash())
+			if fresh.Listed() {
+				rescoreSortedSets(ctx, pipe, fresh, commentKey)
+			} else {
+				removeFromSortedSets(ctx, pipe, fresh.ThreadID, commentKey)
+			}
+			return nil
+		})
+		repaired = err == nil
+		return err
+	}, commentKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return repaired, err
+}
+
+// EvictComment drops a cached comment that no longer exists in the database, along with its
+// sorted set entries. Like RepairComment it reports false if the hash changed since it was read.
+func (rc *RedisCache) EvictComment(ctx context.Context, cached model.CachedComment) (bool, error) {
+	commentKey := fmt.Sprintf("%s:%s", prefix, cached.Comment.ID.String())
+
+	evicted := false
+	err := rc.client.Watch(ctx, func(tx *redis.Tx) error {
+		if ok, err := unchanged(ctx, tx, commentKey, &cached.Comment); err != nil || !ok {
+			return err
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, commentKey)
+			removeFromSortedSets(ctx, pipe, cached.Comment.ThreadID, commentKey)
+			return nil
+		})
+		evicted = err == nil
+		return err
+	}, commentKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return evicted, err
+}
+
+// unchanged reports whether the watched hash still holds the given comment.
+func unchanged(ctx context.Context, tx *redis.Tx, commentKey string, c *model.Comment) (bool, error) {
+	fields, err := tx.HGetAll(ctx, commentKey).Result()
+	if err != nil || len(fields) == 0 {
+		return false, err
+	}
+	current, err := model.CommentFromHash(fields)
+	if err != nil {
+		return false, nil
+	}
+	return model.CacheDrift(&current, c) == nil, nil
+}
+
+// rescoreSortedSets updates the comment's score in the sorted sets it is already a member of,
+// without adding it to sets it was trimmed from.
+func rescoreSortedSets(ctx context.Context, pipe redis.Pipeliner, c *model.Comment, commentKey string) {
+	for _, field := range sortFields {
+		zKey := sortedSetKey(c.ThreadID, field)
+		pipe.ZAddXX(ctx, zKey, redis.Z{Score: c.SortScore(field), Member: commentKey})
+	}
+}
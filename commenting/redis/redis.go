@@ -3,17 +3,20 @@ package redis
 import (
 	"context"
 	"fmt"
-	"math"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kiremitrov123/onboarding/commenting/model"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
 type RedisCache struct {
 	client *redis.Client
+	// fills coalesces concurrent loads of the same page from the database
+	fills singleflight.Group
 }
 
 func NewCache(ctx context.Context, addr string) (*RedisCache, error) {
@@ -32,28 +35,101 @@ const (
 	maxItems = 10
 )
 
-// SetComment stores a comment as a hash and updates the sorted sets for date, replies, and upvotes.
+// sortFields lists the fields that have a per-thread sorted set, scored by model.Comment.SortScore.
+var sortFields = []string{"created_at", "reply_count", "upvotes", "likes", "score", "best", "controversy", "hot"}
+
+// sortedSetKey is the sorted set of a thread's listed comments scored by field.
+func sortedSetKey(threadID uuid.UUID, field string) string {
+	return fmt.Sprintf("%s:%s:%s", prefix, threadID.String(), field)
+}
+
+// legacyRepliesKey is the reply-count sorted set from before the sets were named after their field.
+// Nothing maintains it any more, so it is dropped whenever the thread's sets change rather than
+// being left to serve stale counts until it expires.
+func legacyRepliesKey(threadID uuid.UUID) string {
+	return sortedSetKey(threadID, "replies")
+}
+
+// SetComment stores a comment as a hash and updates the sorted set of every sort field.
+// Deleted and hidden comments are removed from the sorted sets instead.
 func (rc *RedisCache) SetComment(ctx context.Context, c *model.Comment) error {
-	threadID := c.ThreadID.String()
 	commentKey := fmt.Sprintf("%s:%s", prefix, c.ID.String())
 	data := c.ToHash()
 
-	sortedScores := map[string]float64{
-		"created_at": float64(c.CreatedAt.UnixNano()),
-		"replies":    float64(c.ReplyCount),
-		"upvotes":    float64(c.Upvotes),
-	}
-
 	return rc.client.Watch(ctx, func(tx *redis.Tx) error {
 		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
 			pipe.HSet(ctx, commentKey, data)
 			pipe.Expire(ctx, commentKey, ttl)
+			if c.Listed() {
+				addToSortedSets(ctx, pipe, c, commentKey)
+			} else {
+				removeFromSortedSets(ctx, pipe, c.ThreadID, commentKey)
+			}
+			return nil
+		})
+		return err
+	}, commentKey)
+}
+
+// addToSortedSets scores the comment in each sort field's sorted set, keeping only the top maxItems
+// and narrowing the set's coverage to match. The thread is no longer empty, so its empty marker is cleared.
+func addToSortedSets(ctx context.Context, pipe redis.Pipeliner, c *model.Comment, commentKey string) {
+	pipe.Del(ctx, emptyKey(c.ThreadID), legacyRepliesKey(c.ThreadID))
+	for _, field := range sortFields {
+		zKey := sortedSetKey(c.ThreadID, field)
+		coverKey := coverageKey(c.ThreadID, field)
+		pipe.ZAdd(ctx, zKey, redis.Z{Score: c.SortScore(field), Member: commentKey})
+		// The full script is sent, as a missing script can't be retried inside a transaction
+		trimScript.Eval(ctx, pipe, []string{zKey, coverKey}, append([]interface{}{maxItems}, trimArgs...)...)
+		pipe.Expire(ctx, zKey, ttl)
+		pipe.Expire(ctx, coverKey, ttl)
+	}
+}
+
+// removeFromSortedSets drops the comment from each sort field's sorted set.
+func removeFromSortedSets(ctx context.Context, pipe redis.Pipeliner, threadID uuid.UUID, commentKey string) {
+	pipe.Del(ctx, legacyRepliesKey(threadID))
+	for _, field := range sortFields {
+		zKey := sortedSetKey(threadID, field)
+		pipe.ZRem(ctx, zKey, commentKey)
+	}
+}
+
+// UpdateComment refreshes the hash of an already cached comment, leaving the sorted sets untouched.
+func (rc *RedisCache) UpdateComment(ctx context.Context, c *model.Comment) error {
+	commentKey := fmt.Sprintf("%s:%s", prefix, c.ID.String())
+
+	return rc.client.Watch(ctx, func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, commentKey).Result()
+		if err != nil || exists == 0 {
+			return err // not cached, nothing to refresh
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, commentKey, c.ToHash())
+			pipe.Expire(ctx, commentKey, ttl)
+			return nil
+		})
+		return err
+	}, commentKey)
+}
+
+// DeleteComment drops a soft-deleted or hidden comment from the thread's sorted sets
+// and replaces the cached hash with the tombstone so replies can still resolve it.
+func (rc *RedisCache) DeleteComment(ctx context.Context, c *model.Comment) error {
+	commentKey := fmt.Sprintf("%s:%s", prefix, c.ID.String())
+
+	return rc.client.Watch(ctx, func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, commentKey).Result()
+		if err != nil {
+			return err
+		}
 
-			for field, score := range sortedScores {
-				zKey := fmt.Sprintf("%s:%s:%s", prefix, threadID, field)
-				pipe.ZAdd(ctx, zKey, redis.Z{Score: score, Member: commentKey})
-				pipe.ZRemRangeByRank(ctx, zKey, 0, int64(-maxItems-1))
-				pipe.Expire(ctx, zKey, ttl)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			removeFromSortedSets(ctx, pipe, c.ThreadID, commentKey)
+			if exists > 0 {
+				pipe.HSet(ctx, commentKey, c.ToHash())
+				pipe.Expire(ctx, commentKey, ttl)
 			}
 			return nil
 		})
@@ -81,43 +157,53 @@ func (rc *RedisCache) GetCommentByID(ctx context.Context, commentID uuid.UUID) (
 }
 
 // ListComments retrieves sorted comments from Redis or uses fallback to load and repopulate them.
+// Sorted sets are capped at maxItems, so the whole set is read and paged in memory with the same
+// (score, created_at, id) ordering the database uses. Ties on score are therefore never skipped.
+// Only the part of the ordering the set covers is served from it, and the rest of a page is loaded
+// through fallback. A thread recently found empty is answered from its empty marker.
 func (rc *RedisCache) ListComments(
 	ctx context.Context,
 	threadID uuid.UUID,
 	sortKey string,
-	cursor int64,
+	cursor *model.Cursor,
 	limit int,
 	fallback model.QueryCommentsFunc,
 ) ([]model.Comment, error) {
-	threadKey := threadID.String()
-	zsetKey := fmt.Sprintf("%s:%s:%s", prefix, threadKey, sortKey)
+	var keys *redis.StringSliceCmd
+	var covered *redis.MapStringStringCmd
+	var empty *redis.IntCmd
+	_, err := rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		keys = pipe.ZRevRange(ctx, sortedSetKey(threadID, sortKey), 0, -1)
+		covered = pipe.HGetAll(ctx, coverageKey(threadID, sortKey))
+		empty = pipe.Exists(ctx, emptyKey(threadID))
+		return nil
+	})
 
-	// Default to max value if cursor is not provided
-	if cursor == 0 {
-		cursor = math.MaxInt64
+	if err == nil {
+		if len(keys.Val()) == 0 && empty.Val() > 0 {
+			return []model.Comment{}, nil
+		}
+		if coverage, ok := parseCoverage(covered.Val()); ok {
+			return rc.listCovered(ctx, threadID, sortKey, keys.Val(), coverage, cursor, limit, fallback)
+		}
 	}
 
-	keys, err := rc.client.ZRevRangeByScore(ctx, zsetKey, &redis.ZRangeBy{
-		Max:    fmt.Sprintf("(%d", cursor), // exclusive
-		Min:    "-inf",
-		Offset: 0,
-		Count:  int64(limit),
-	}).Result()
+	return rc.loadComments(ctx, threadID, sortKey, cursor, limit, fallback)
+}
 
-	if err != nil || len(keys) == 0 {
-		comments, err := fallback(ctx, threadID)
-		if err != nil {
-			return nil, err
+// getComments loads the hashes of the given comment keys in one round trip, skipping missing ones.
+func (rc *RedisCache) getComments(ctx context.Context, keys []string) []model.Comment {
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	_, _ = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, k := range keys {
+			cmds[i] = pipe.HGetAll(ctx, k)
 		}
-		for _, c := range comments {
-			_ = rc.SetComment(ctx, &c)
-		}
-		return comments, nil
-	}
+		return nil
+	})
 
-	var out []model.Comment
-	for _, k := range keys {
-		fields, err := rc.client.HGetAll(ctx, k).Result()
+	out := make([]model.Comment, 0, len(keys))
+	for _, cmd := range cmds {
+		fields, err := cmd.Result()
 		if err != nil || len(fields) == 0 {
 			continue
 		}
@@ -126,11 +212,43 @@ func (rc *RedisCache) ListComments(
 			out = append(out, comment)
 		}
 	}
-	return out, nil
+	return out
 }
 
-// UpdateCommentScore increments a numeric field and updates the score in the sorted set.
+// pageComments orders comments by (score, created_at, id) descending and returns
+// up to limit of them following the cursor, or preceding it for a backward cursor.
+func pageComments(comments []model.Comment, sortKey string, cursor *model.Cursor, limit int) []model.Comment {
+	sort.Slice(comments, func(i, j int) bool {
+		return model.NewCursor(&comments[i], sortKey).Before(model.NewCursor(&comments[j], sortKey))
+	})
+
+	if cursor == nil {
+		return comments[:min(limit, len(comments))]
+	}
+
+	var out []model.Comment
+	for _, c := range comments {
+		pos := model.NewCursor(&c, sortKey)
+		if cursor.Backward && pos.Before(*cursor) || !cursor.Backward && cursor.Before(pos) {
+			out = append(out, c)
+		}
+	}
+
+	if cursor.Backward {
+		return out[max(0, len(out)-limit):]
+	}
+	return out[:min(limit, len(out))]
+}
+
+// UpdateCommentScore increments a numeric field and rescores the comment in every sorted set.
 func (rc *RedisCache) UpdateCommentScore(ctx context.Context, commentID uuid.UUID, field string, delta int) error {
+	return rc.UpdateCommentScores(ctx, commentID, map[string]int{field: delta})
+}
+
+// UpdateCommentScores applies several counter deltas at once and rescores the comment in every sorted set.
+// The net score follows the counters, but best, controversy and hot keep the values last read from the
+// database until the outbox relay caches the comment again, since only the database computes them.
+func (rc *RedisCache) UpdateCommentScores(ctx context.Context, commentID uuid.UUID, deltas map[string]int) error {
 	commentKey := fmt.Sprintf("%s:%s", prefix, commentID.String())
 
 	return rc.client.Watch(ctx, func(tx *redis.Tx) error {
@@ -139,15 +257,24 @@ func (rc *RedisCache) UpdateCommentScore(ctx context.Context, commentID uuid.UUI
 			return nil // silently ignore if not cached
 		}
 
-		threadID := fields["thread_id"]
-		currentVal, _ := strconv.Atoi(fields[field])
-		newVal := currentVal + delta
+		updates := make(map[string]interface{}, len(deltas))
+		for field, delta := range deltas {
+			currentVal, _ := strconv.Atoi(fields[field])
+			newVal := currentVal + delta
+			fields[field] = strconv.Itoa(newVal)
+			updates[field] = newVal
+		}
 
-		zsetKey := fmt.Sprintf("%s:%s:%s", prefix, threadID, field)
+		comment, err := model.CommentFromHash(fields)
+		if err != nil {
+			return nil // unreadable entry, leave it to expire
+		}
 
 		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-			pipe.HSet(ctx, commentKey, field, newVal)
-			pipe.ZAdd(ctx, zsetKey, redis.Z{Score: float64(newVal), Member: commentKey})
+			pipe.HSet(ctx, commentKey, updates)
+			if comment.Listed() {
+				addToSortedSets(ctx, pipe, &comment, commentKey)
+			}
 			return nil
 		})
 		return err
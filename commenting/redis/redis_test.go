@@ -3,11 +3,15 @@ package redis
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,9 +20,12 @@ func setupRedis(t *testing.T) *RedisCache {
 	cache, err := NewCache(ctx, "localhost:6379")
 	require.NoError(t, err)
 
-	// Clear test keys under "comments:*"
+	// Clear test keys under "comments:*" and the write-behind votes
 	keys, err := cache.client.Keys(ctx, "comments:*").Result()
 	require.NoError(t, err)
+	votes, err := cache.client.Keys(ctx, "votes:*").Result()
+	require.NoError(t, err)
+	keys = append(keys, votes...)
 
 	if len(keys) > 0 {
 		_, err := cache.client.Del(ctx, keys...).Result()
@@ -28,17 +35,29 @@ func setupRedis(t *testing.T) *RedisCache {
 	return cache
 }
 
+// listEmptyThread lists a thread before it has comments, so that its sorted set for sortKey
+// is known to hold every comment cached after it.
+func listEmptyThread(t *testing.T, cache *RedisCache, threadID uuid.UUID, sortKey string) {
+	comments, err := cache.ListComments(context.Background(), threadID, sortKey, nil, 10, func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.Empty(t, comments)
+}
+
 func TestSetAndGetComment(t *testing.T) {
 	ctx := context.Background()
 	cache := setupRedis(t)
 
 	c := model.Comment{
-		ID:        uuid.New(),
-		ThreadID:  uuid.New(),
-		UserID:    "user123",
-		Content:   "This is a comment",
-		CreatedAt: time.Now(),
-		Upvotes:   10,
+		ID:          uuid.New(),
+		ThreadID:    uuid.New(),
+		UserID:      "user123",
+		Content:     "This is a *comment*",
+		ContentHTML: "<p>This is a <em>comment</em></p>",
+		Format:      model.FormatMarkdown,
+		CreatedAt:   time.Now(),
+		Upvotes:     10,
 	}
 
 	err := cache.SetComment(ctx, &c)
@@ -48,6 +67,8 @@ func TestSetAndGetComment(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, c.ID, fetched.ID)
 	require.Equal(t, c.Content, fetched.Content)
+	require.Equal(t, c.ContentHTML, fetched.ContentHTML)
+	require.Equal(t, c.Format, fetched.Format)
 	require.Equal(t, c.Upvotes, fetched.Upvotes)
 }
 
@@ -65,12 +86,13 @@ func TestListComments_CacheHit(t *testing.T) {
 		ReplyCount: 5,
 		Upvotes:    7,
 	}
+	listEmptyThread(t, cache, threadID, "upvotes")
 
 	// Store in Redis
 	err := cache.SetComment(ctx, &c)
 	require.NoError(t, err)
 
-	comments, err := cache.ListComments(ctx, threadID, "upvotes", int64(c.Upvotes+1), 10, func(context.Context, uuid.UUID) ([]model.Comment, error) {
+	comments, err := cache.ListComments(ctx, threadID, "upvotes", nil, 10, func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
 		t.Fatal("should not call fallback")
 		return nil, nil
 	})
@@ -96,7 +118,7 @@ func TestListComments_CacheMissFallback(t *testing.T) {
 
 	// No Redis insert
 
-	comments, err := cache.ListComments(ctx, threadID, "upvotes", 999, 10, func(_ context.Context, _ uuid.UUID) ([]model.Comment, error) {
+	comments, err := cache.ListComments(ctx, threadID, "upvotes", nil, 10, func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
 		return []model.Comment{c}, nil
 	})
 	require.NoError(t, err)
@@ -104,6 +126,106 @@ func TestListComments_CacheMissFallback(t *testing.T) {
 	require.Equal(t, c.ID, comments[0].ID)
 }
 
+func TestListComments_ColdThreadLoadsOnce(t *testing.T) {
+	ctx := context.Background()
+	// Two caches stand in for two API replicas
+	replicas := []*RedisCache{setupRedis(t), setupRedis(t)}
+
+	threadID := uuid.New()
+	c := model.Comment{ID: uuid.New(), ThreadID: threadID, UserID: "user123", Content: "Cold", CreatedAt: time.Now()}
+
+	var calls atomic.Int32
+	fallback := func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return []model.Comment{c}, nil
+	}
+
+	const requests = 40
+	results := make(chan []model.Comment, requests)
+	errs := make(chan error, requests)
+
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			comments, err := replicas[i%2].ListComments(ctx, threadID, "upvotes", nil, 10, fallback)
+			results <- comments
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	for comments := range results {
+		require.Len(t, comments, 1)
+		require.Equal(t, c.ID, comments[0].ID)
+	}
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestListComments_EmptyThreadIsRemembered(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	threadID := uuid.New()
+	var calls int
+	fallback := func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
+		calls++
+		return nil, nil
+	}
+
+	for range 3 {
+		comments, err := cache.ListComments(ctx, threadID, "created_at", nil, 10, fallback)
+		require.NoError(t, err)
+		require.Empty(t, comments)
+	}
+	require.Equal(t, 1, calls)
+
+	// Caching a comment clears the empty marker
+	c := model.Comment{ID: uuid.New(), ThreadID: threadID, UserID: "user123", Content: "First", CreatedAt: time.Now()}
+	require.NoError(t, cache.SetComment(ctx, &c))
+
+	comments, err := cache.ListComments(ctx, threadID, "created_at", nil, 10, fallback)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, 1, calls)
+}
+
+func TestListComments_FallbackKeepsNewerCachedComment(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	threadID := uuid.New()
+	c := model.Comment{ID: uuid.New(), ThreadID: threadID, UserID: "user123", Content: "Voted", CreatedAt: time.Now(), Upvotes: 5}
+	require.NoError(t, cache.SetComment(ctx, &c))
+
+	// The sorted sets expired while the hash, with votes the database hasn't seen yet, is still cached
+	for _, field := range sortFields {
+		require.NoError(t, cache.client.Del(ctx, sortedSetKey(threadID, field)).Err())
+	}
+
+	stale := c
+	stale.Upvotes = 1
+	_, err := cache.ListComments(ctx, threadID, "upvotes", nil, 10, func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
+		return []model.Comment{stale}, nil
+	})
+	require.NoError(t, err)
+
+	fetched, err := cache.GetCommentByID(ctx, c.ID)
+	require.NoError(t, err)
+	require.Equal(t, 5, fetched.Upvotes)
+
+	score, err := cache.client.ZScore(ctx, sortedSetKey(threadID, "upvotes"), "comments:"+c.ID.String()).Result()
+	require.NoError(t, err)
+	require.Equal(t, float64(5), score)
+}
+
 func TestUpdateCommentScore(t *testing.T) {
 	ctx := context.Background()
 	cache := setupRedis(t)
@@ -159,3 +281,393 @@ func TestSetComment_EvictsLowScore(t *testing.T) {
 	require.Equal(t, float64(6), members[0].Score)
 	require.Equal(t, float64(15), members[9].Score)
 }
+
+func TestDeleteComment_RemovesFromSortedSets(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	threadID := uuid.New()
+	c := model.Comment{
+		ID:        uuid.New(),
+		ThreadID:  threadID,
+		UserID:    "user123",
+		Content:   "Soon gone",
+		CreatedAt: time.Now(),
+		Upvotes:   3,
+	}
+
+	err := cache.SetComment(ctx, &c)
+	require.NoError(t, err)
+
+	now := time.Now()
+	c.Content = model.DeletedContent
+	c.DeletedAt = &now
+	err = cache.DeleteComment(ctx, &c)
+	require.NoError(t, err)
+
+	for _, field := range sortFields {
+		zsetKey := fmt.Sprintf("comments:%s:%s", threadID, field)
+		count, err := cache.client.ZCard(ctx, zsetKey).Result()
+		require.NoError(t, err)
+		require.Zero(t, count)
+	}
+
+	tombstone, err := cache.GetCommentByID(ctx, c.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.DeletedContent, tombstone.Content)
+	require.NotNil(t, tombstone.DeletedAt)
+}
+
+func TestListComments_PagesThroughTiedScores(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	threadID := uuid.New()
+	listEmptyThread(t, cache, threadID, "upvotes")
+	for i := 0; i < 5; i++ {
+		c := model.Comment{
+			ID:        uuid.New(),
+			ThreadID:  threadID,
+			UserID:    "user123",
+			Content:   fmt.Sprintf("Tied #%d", i),
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Second),
+		}
+		require.NoError(t, cache.SetComment(ctx, &c))
+	}
+
+	noFallback := func(context.Context, uuid.UUID, *model.Cursor, int) ([]model.Comment, error) {
+		t.Fatal("should not call fallback")
+		return nil, nil
+	}
+
+	page1, err := cache.ListComments(ctx, threadID, "upvotes", nil, 3, noFallback)
+	require.NoError(t, err)
+	require.Len(t, page1, 3)
+
+	cursor := model.NewCursor(&page1[2], "upvotes")
+	page2, err := cache.ListComments(ctx, threadID, "upvotes", &cursor, 3, noFallback)
+	require.NoError(t, err)
+	require.Len(t, page2, 2)
+
+	seen := map[uuid.UUID]bool{}
+	for _, c := range append(page1, page2...) {
+		require.False(t, seen[c.ID])
+		seen[c.ID] = true
+	}
+}
+
+func TestListComments_PagesPastCacheWindow(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	// The thread has more comments than the maxItems its sorted sets keep
+	threadID := uuid.New()
+	all := make([]model.Comment, 25)
+	for i := range all {
+		all[i] = model.Comment{
+			ID:        uuid.New(),
+			ThreadID:  threadID,
+			UserID:    "user123",
+			Content:   fmt.Sprintf("#%d", i),
+			CreatedAt: time.Now(),
+			Upvotes:   100 - i,
+		}
+		require.NoError(t, cache.SetComment(ctx, &all[i]))
+	}
+
+	var calls int
+	db := func(_ context.Context, _ uuid.UUID, cursor *model.Cursor, limit int) ([]model.Comment, error) {
+		calls++
+		return pageComments(slices.Clone(all), "upvotes", cursor, limit), nil
+	}
+
+	requireAllPages := func(limit int) {
+		var got []model.Comment
+		var cursor *model.Cursor
+		for {
+			page, err := cache.ListComments(ctx, threadID, "upvotes", cursor, limit, db)
+			require.NoError(t, err)
+			got = append(got, page...)
+			if len(page) < limit {
+				break
+			}
+			next := model.NewCursor(&page[len(page)-1], "upvotes")
+			cursor = &next
+		}
+
+		require.Len(t, got, len(all))
+		for i := range all {
+			require.Equal(t, all[i].ID, got[i].ID)
+		}
+	}
+
+	for _, limit := range []int{maxItems, 7, 4} {
+		requireAllPages(limit)
+	}
+
+	// The first page is now served from the cache alone
+	calls = 0
+	page, err := cache.ListComments(ctx, threadID, "upvotes", nil, maxItems, db)
+	require.NoError(t, err)
+	require.Len(t, page, maxItems)
+	require.Zero(t, calls)
+
+	// A page running past the window takes the rest from the database
+	cursor := model.NewCursor(&all[6], "upvotes")
+	page, err = cache.ListComments(ctx, threadID, "upvotes", &cursor, 6, db)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+	require.Len(t, page, 6)
+	for i, c := range page {
+		require.Equal(t, all[7+i].ID, c.ID)
+	}
+
+	// A new top comment pushes the last one out of the window, where the next page still finds it
+	top := model.Comment{ID: uuid.New(), ThreadID: threadID, UserID: "user123", Content: "Top", CreatedAt: time.Now(), Upvotes: 1000}
+	require.NoError(t, cache.SetComment(ctx, &top))
+	all = append([]model.Comment{top}, all...)
+	requireAllPages(maxItems)
+}
+
+func TestUpdateCommentScore_KeepsStoredRankings(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	threadID := uuid.New()
+	c := model.Comment{
+		ID:        uuid.New(),
+		ThreadID:  threadID,
+		UserID:    "user123",
+		Content:   "Ranked comment",
+		CreatedAt: time.Now(),
+		Upvotes:   5,
+		Downvotes: 1,
+		Best:      0.4,
+	}
+
+	err := cache.SetComment(ctx, &c)
+	require.NoError(t, err)
+
+	err = cache.UpdateCommentScore(ctx, c.ID, "downvotes", 2)
+	require.NoError(t, err)
+
+	member := fmt.Sprintf("comments:%s", c.ID)
+	score, err := cache.client.ZScore(ctx, fmt.Sprintf("comments:%s:score", threadID), member).Result()
+	require.NoError(t, err)
+	require.Equal(t, float64(2), score)
+
+	// The database hasn't recomputed best yet, so the stored value stands
+	best, err := cache.client.ZScore(ctx, fmt.Sprintf("comments:%s:best", threadID), member).Result()
+	require.NoError(t, err)
+	require.Equal(t, c.Best, best)
+}
+
+func TestThreadEvents_ReadAndReplay(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+	threadID := uuid.New()
+	otherID := uuid.New()
+
+	start, err := cache.LastThreadEventID(ctx, threadID)
+	require.NoError(t, err)
+	require.Equal(t, "0", start)
+
+	for _, eventType := range []string{model.EventCommentCreated, model.EventCommentUpdated} {
+		e, err := model.NewEvent(eventType, threadID, uuid.New(), nil)
+		require.NoError(t, err)
+		require.NoError(t, cache.Publish(ctx, e))
+	}
+
+	// One read covers every followed thread, and only those with new events move on
+	events, positions, err := cache.ReadThreadEvents(ctx, map[uuid.UUID]string{threadID: start, otherID: "0"}, time.Second)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, model.EventCommentCreated, events[0].Event.Type)
+	require.Equal(t, map[uuid.UUID]string{threadID: events[1].StreamID}, positions)
+
+	last, err := cache.LastThreadEventID(ctx, threadID)
+	require.NoError(t, err)
+	require.Equal(t, events[1].StreamID, last)
+
+	// Resuming from the first event replays only the second
+	replay, err := cache.ThreadEventsBetween(ctx, threadID, events[0].StreamID, last)
+	require.NoError(t, err)
+	require.Len(t, replay, 1)
+	require.Equal(t, model.EventCommentUpdated, replay[0].Event.Type)
+
+	// Nothing new before the timeout
+	events, positions, err = cache.ReadThreadEvents(ctx, map[uuid.UUID]string{threadID: last}, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.Empty(t, positions)
+}
+
+func TestSetComment_HiddenLeavesSortedSets(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	threadID := uuid.New()
+	c := model.Comment{
+		ID:        uuid.New(),
+		ThreadID:  threadID,
+		UserID:    "user123",
+		Content:   "Reported",
+		CreatedAt: time.Now(),
+		Upvotes:   2,
+	}
+	require.NoError(t, cache.SetComment(ctx, &c))
+
+	now := time.Now()
+	c.HiddenAt = &now
+	require.NoError(t, cache.SetComment(ctx, &c))
+
+	for _, field := range sortFields {
+		zsetKey := fmt.Sprintf("comments:%s:%s", threadID, field)
+		count, err := cache.client.ZCard(ctx, zsetKey).Result()
+		require.NoError(t, err)
+		require.Zero(t, count)
+	}
+
+	// Vote updates on a hidden comment don't bring it back either
+	require.NoError(t, cache.UpdateCommentScore(ctx, c.ID, "upvotes", 1))
+	count, err := cache.client.ZCard(ctx, fmt.Sprintf("comments:%s:upvotes", threadID)).Result()
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	cached, err := cache.GetCommentByID(ctx, c.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cached.HiddenAt)
+}
+
+func TestAllowRequest_GCRA(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	user := model.RateBudget{Key: "create:user:" + uuid.NewString(), Limit: model.RateLimit{Limit: 3, Period: time.Minute}}
+	thread := model.RateBudget{Key: "create:thread:" + uuid.NewString(), Limit: model.RateLimit{Limit: 2, Period: time.Minute}}
+
+	// The burst is limited by the tighter thread budget
+	for remaining := 1; remaining >= 0; remaining-- {
+		res, err := cache.AllowRequest(ctx, []model.RateBudget{user, thread})
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Equal(t, 2, res.Limit)
+		require.Equal(t, remaining, res.Remaining)
+	}
+
+	res, err := cache.AllowRequest(ctx, []model.RateBudget{user, thread})
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.InDelta(t, 30*time.Second, res.RetryAfter, float64(time.Second))
+
+	// The rejected request didn't spend the user's budget
+	res, err = cache.AllowRequest(ctx, []model.RateBudget{user})
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Zero(t, res.Remaining)
+}
+
+func TestUnreadCount_IncrOnlyWhenCached(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+	userID := uuid.NewString()
+
+	// An uncached count stays uncached so it is recomputed from the database
+	require.NoError(t, cache.IncrUnreadCount(ctx, userID, 1))
+	_, err := cache.GetUnreadCount(ctx, userID)
+	require.ErrorIs(t, err, redis.Nil)
+
+	require.NoError(t, cache.FillUnreadCount(ctx, userID, 2))
+	require.NoError(t, cache.FillUnreadCount(ctx, userID, 5))
+	require.NoError(t, cache.IncrUnreadCount(ctx, userID, 1))
+	n, err := cache.GetUnreadCount(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.NoError(t, cache.IncrUnreadCount(ctx, userID, -5))
+	n, err = cache.GetUnreadCount(ctx, userID)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestPinnedIDs_FillDoesNotOverwrite(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+	threadID := uuid.New()
+
+	_, err := cache.GetPinnedIDs(ctx, threadID)
+	require.ErrorIs(t, err, redis.Nil)
+
+	// A thread without pins is cached too
+	require.NoError(t, cache.FillPinnedIDs(ctx, threadID, []uuid.UUID{}))
+	ids, err := cache.GetPinnedIDs(ctx, threadID)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	pinned := []uuid.UUID{uuid.New(), uuid.New()}
+	require.NoError(t, cache.SetPinnedIDs(ctx, threadID, pinned))
+	require.NoError(t, cache.FillPinnedIDs(ctx, threadID, nil))
+	ids, err = cache.GetPinnedIDs(ctx, threadID)
+	require.NoError(t, err)
+	require.Equal(t, pinned, ids)
+}
+
+func TestReconcileLeaseAndCursor(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+	require.NoError(t, cache.client.Del(ctx, reconcileLeaseKey, reconcileCursorKey).Err())
+
+	ok, err := cache.AcquireReconcileLease(ctx, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = cache.AcquireReconcileLease(ctx, time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	cursor, err := cache.ReconcileCursor(ctx)
+	require.NoError(t, err)
+	require.Zero(t, cursor)
+	require.NoError(t, cache.SaveReconcileCursor(ctx, 42))
+	cursor, err = cache.ReconcileCursor(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), cursor)
+}
+
+func TestScanAndRepairComment(t *testing.T) {
+	ctx := context.Background()
+	cache := setupRedis(t)
+
+	c := model.Comment{ID: uuid.New(), ThreadID: uuid.New(), UserID: "user123", Content: "Drifted", CreatedAt: time.Now(), Upvotes: 7}
+	require.NoError(t, cache.SetComment(ctx, &c))
+
+	var scanned []model.CachedComment
+	cursor := uint64(0)
+	for {
+		batch, next, err := cache.ScanComments(ctx, cursor, 100)
+		require.NoError(t, err)
+		scanned = append(scanned, batch...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	require.Len(t, scanned, 1)
+	require.Equal(t, 7.0, scanned[0].Scores["upvotes"])
+
+	fresh := c
+	fresh.Upvotes = 4
+	repaired, err := cache.RepairComment(ctx, scanned[0], &fresh)
+	require.NoError(t, err)
+	require.True(t, repaired)
+
+	cached, err := cache.GetCommentByID(ctx, c.ID)
+	require.NoError(t, err)
+	require.Equal(t, 4, cached.Upvotes)
+	score, err := cache.client.ZScore(ctx, fmt.Sprintf("comments:%s:upvotes", c.ThreadID), "comments:"+c.ID.String()).Result()
+	require.NoError(t, err)
+	require.Equal(t, 4.0, score)
+
+	// The snapshot is stale now, so repairing from it again is skipped
+	repaired, err = cache.RepairComment(ctx, scanned[0], &c)
+	require.NoError(t, err)
+	require.False(t, repaired)
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// pinsKey holds the IDs of a thread's pinned comments, comma-separated and in pin order.
+func pinsKey(threadID uuid.UUID) string {
+	return fmt.Sprintf("threads:%s:pins", threadID.String())
+}
+
+// GetPinnedIDs returns the cached pinned comment IDs of a thread, or redis.Nil if they aren't cached.
+func (rc *RedisCache) GetPinnedIDs(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error) {
+	value, err := rc.client.Get(ctx, pinsKey(threadID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uuid.UUID{}
+	if value == "" {
+		return ids, nil
+	}
+	for _, s := range strings.Split(value, ",") {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pinned comment ID from redis: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SetPinnedIDs caches the pinned comment IDs of a thread after they changed.
+func (rc *RedisCache) SetPinnedIDs(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error {
+	return rc.client.Set(ctx, pinsKey(threadID), joinIDs(ids), ttl).Err()
+}
+
+// FillPinnedIDs caches the pinned comment IDs of a thread as read from the database, unless they
+// are cached already: a pin that committed after the read has cached the newer list.
+func (rc *RedisCache) FillPinnedIDs(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error {
+	return rc.client.SetNX(ctx, pinsKey(threadID), joinIDs(ids), ttl).Err()
+}
+
+func joinIDs(ids []uuid.UUID) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = id.String()
+	}
+	return strings.Join(parts, ",")
+}
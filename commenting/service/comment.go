@@ -2,111 +2,501 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/markdown"
 	"github.com/kiremitrov123/onboarding/commenting/model"
 )
 
 type CommentRepo interface {
-	CreateComment(ctx context.Context, comment *model.Comment) error
+	CreateComment(ctx context.Context, comment *model.Comment, reports []model.Report) error
 	GetCommentByID(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
-	IncrementReplyCount(ctx context.Context, parentID uuid.UUID) error
-	AddReaction(ctx context.Context, reaction *model.Reaction) (bool, error)
-	DeleteReaction(ctx context.Context, commentID uuid.UUID, userID, reactionType string) error
-	IncrementReactionCount(ctx context.Context, commentID uuid.UUID, field string) error
-	DecrementReactionCount(ctx context.Context, commentID uuid.UUID, field string) error
-	ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor int64, limit int) ([]model.Comment, error)
+	UpdateComment(ctx context.Context, commentID uuid.UUID, content, contentHTML string, reports []model.Report) (*model.Comment, error)
+	DeleteComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, bool, error)
+	ListRevisions(ctx context.Context, commentID uuid.UUID) ([]model.Revision, error)
+	ToggleReaction(ctx context.Context, reaction *model.Reaction, field string) (bool, error)
+	Vote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error)
+	RecordReaction(ctx context.Context, reaction *model.Reaction, field string) (bool, error)
+	RecordVote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error)
+	ListUserReactions(ctx context.Context, userID string, commentIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+	ListReactions(ctx context.Context, commentID uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error)
+	ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor *model.Cursor, limit int) ([]model.Comment, error)
+	ListThreadTree(ctx context.Context, threadID uuid.UUID, maxDepth, limit int) ([]model.Comment, map[uuid.UUID]int, error)
+	CreateReport(ctx context.Context, report *model.Report) error
+	ListModerationQueue(ctx context.Context, limit int) ([]model.QueueItem, error)
+	ModerateComment(ctx context.Context, commentID uuid.UUID, moderatorID, action, note string) (*model.Comment, bool, error)
+	ListModerationActions(ctx context.Context, commentID uuid.UUID) ([]model.ModerationAction, error)
+	CreateNotifications(ctx context.Context, notifications []model.Notification) error
+	ListExistingUsers(ctx context.Context, userIDs []string) (map[string]bool, error)
+	ListNotifications(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error)
+	CountUnreadNotifications(ctx context.Context, userID string) (int, error)
+	MarkNotificationsRead(ctx context.Context, userID string, ids []uuid.UUID) (int, error)
+	SearchComments(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error)
+	CreateThread(ctx context.Context, thread *model.Thread) (bool, error)
+	GetThread(ctx context.Context, threadID uuid.UUID) (*model.Thread, error)
+	GetThreadBySubject(ctx context.Context, subjectKey string) (*model.Thread, error)
+	UpdateThread(ctx context.Context, thread *model.Thread) error
+	PinComment(ctx context.Context, threadID, commentID uuid.UUID, maxPinned int) (*model.Thread, error)
+	UnpinComment(ctx context.Context, threadID, commentID uuid.UUID) (*model.Thread, error)
 }
 
 type CommentCache interface {
 	SetComment(ctx context.Context, comment *model.Comment) error
 	GetCommentByID(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
+	UpdateComment(ctx context.Context, comment *model.Comment) error
+	DeleteComment(ctx context.Context, comment *model.Comment) error
 	UpdateCommentScore(ctx context.Context, commentID uuid.UUID, field string, delta int) error
-	ListComments(ctx context.Context, threadID uuid.UUID, sortKey string, cursor int64, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error)
+	UpdateCommentScores(ctx context.Context, commentID uuid.UUID, deltas map[string]int) error
+	ListComments(ctx context.Context, threadID uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error)
+	GetUnreadCount(ctx context.Context, userID string) (int, error)
+	FillUnreadCount(ctx context.Context, userID string, count int) error
+	IncrUnreadCount(ctx context.Context, userID string, delta int) error
+	GetPinnedIDs(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error)
+	SetPinnedIDs(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error
+	FillPinnedIDs(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error
+}
+
+// EventStream delivers the events of a thread, as published by the outbox relay, to live clients.
+type EventStream interface {
+	Subscribe(ctx context.Context, threadID uuid.UUID, lastEventID string) (<-chan model.StreamEvent, error)
+}
+
+// ErrStreamUnavailable is returned when the service runs without an event stream.
+var ErrStreamUnavailable = errors.New("event stream unavailable")
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidVote   = errors.New("invalid vote value")
+	ErrInvalidType   = errors.New("invalid reaction type")
+	ErrInvalidFormat = errors.New("invalid content format")
+)
+
+// validReactionTypes are the reaction types a comment can receive.
+var validReactionTypes = map[string]bool{
+	"like":     true,
+	"upvote":   true,
+	"downvote": true,
+}
+
+// validSortFields maps the sort names accepted by the API to comment fields.
+var validSortFields = map[string]string{
+	"date":          "created_at",
+	"upvotes":       "upvotes",
+	"replies":       "reply_count",
+	"likes":         "likes",
+	"score":         "score",
+	"best":          "best",
+	"controversial": "controversy",
+	"hot":           "hot",
 }
 
 type CommentService struct {
-	repo  CommentRepo
-	cache CommentCache
+	repo        CommentRepo
+	cache       CommentCache
+	events      EventStream
+	policies    []ContentPolicy
+	writeBehind bool
+	logger      *slog.Logger
 }
 
-func NewCommentService(repo CommentRepo, cache CommentCache) *CommentService {
-	return &CommentService{repo: repo, cache: cache}
+// Option configures an optional dependency of the CommentService.
+type Option func(*CommentService)
+
+// WithLogger sets the logger for failures that don't fail the request, like notification fan-out.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *CommentService) { s.logger = logger }
+}
+
+// WithEventStream enables thread event streaming.
+func WithEventStream(events EventStream) Option {
+	return func(s *CommentService) { s.events = events }
+}
+
+// WithWriteBehindVotes enables write-behind mode for reactions: the reaction row is written straight
+// away with its counter deltas, which the flusher adds to the comment in batches.
+func WithWriteBehindVotes() Option {
+	return func(s *CommentService) { s.writeBehind = true }
+}
+
+// WithContentPolicies sets the policies new comments are checked against, in order.
+func WithContentPolicies(policies ...ContentPolicy) Option {
+	return func(s *CommentService) { s.policies = policies }
+}
+
+func NewCommentService(repo CommentRepo, cache CommentCache, opts ...Option) *CommentService {
+	s := &CommentService{repo: repo, cache: cache, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateComment stores the comment in DB and cache, and updates parent reply count if needed.
+// The comment gets a new ID and creation time and starts with zero counters, whatever it carried.
+// Comments can't be added to a locked thread.
+// Cache writes here and in the other mutations are best-effort: the outbox relay
+// converges the cache from the committed events, so a Redis failure doesn't fail the request.
 func (s *CommentService) CreateComment(ctx context.Context, comment *model.Comment) error {
-	// Generate a new UUID for the comment if none was provided
-	if comment.ID == uuid.Nil {
-		comment.ID = uuid.New()
+	comment.ID = uuid.New()
+
+	// Stamp the creation time here, at the database's microsecond precision,
+	// so that the cached copy and pagination cursors agree with the stored row
+	comment.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	// A new comment starts without reactions, replies, edits or moderation
+	comment.ReplyCount, comment.Upvotes, comment.Downvotes, comment.Likes = 0, 0, 0, 0
+	comment.Revision = 0
+	comment.EditedAt, comment.DeletedAt, comment.HiddenAt = nil, nil, nil
+
+	if comment.Format == "" {
+		comment.Format = model.FormatMarkdown
 	}
+	html, err := renderContent(comment.Format, comment.Content)
+	if err != nil {
+		return err
+	}
+	comment.ContentHTML = html
 
-	// If it's a top-level comment, use its own ID as the thread ID
-	// It's a reply — get parent comment to inherit thread ID
+	// A top-level comment joins the given thread, or starts its own without one
+	// A reply inherits the thread of its parent
+	var parent *model.Comment
 	if comment.ParentID == nil {
-		comment.ThreadID = comment.ID
+		if comment.ThreadID == uuid.Nil {
+			comment.ThreadID = comment.ID
+		} else {
+			thread, err := s.repo.GetThread(ctx, comment.ThreadID)
+			if err != nil {
+				return err
+			}
+			// The repository checks the lock again when storing the comment, this only fails early
+			if thread.Locked {
+				return model.ErrThreadLocked
+			}
+		}
+		comment.Depth = 0
+		comment.Path = model.PathSegment(comment.ID)
 	} else {
-		parent, err := s.GetCommentByID(ctx, *comment.ParentID)
+		parent, err = s.GetCommentByID(ctx, *comment.ParentID)
 		if err != nil {
 			return err
 		}
+		// A parent cached before paths were backfilled has none, so read it from the database
+		if parent.Path == "" {
+			parent, err = s.repo.GetCommentByID(ctx, *comment.ParentID)
+			if err != nil {
+				return err
+			}
+		}
 		comment.ThreadID = parent.ThreadID
+		comment.Depth = parent.Depth + 1
+		comment.Path = parent.Path + model.PathSegment(comment.ID)
 	}
 
-	if err := s.repo.CreateComment(ctx, comment); err != nil {
+	flags, err := s.checkPolicies(ctx, comment)
+	if err != nil {
 		return err
 	}
 
-	if comment.ParentID != nil {
-		if err := s.repo.IncrementReplyCount(ctx, *comment.ParentID); err != nil {
-			return err
-		}
+	// Flagged comments are published but land in the moderation queue
+	if err := s.repo.CreateComment(ctx, comment, flagReports(comment.ID, flags)); err != nil {
+		return err
+	}
+
+	s.notify(ctx, comment, parent, ParseMentions(comment.Content))
+
+	_ = s.cache.SetComment(ctx, comment)
+	return nil
+}
+
+// renderContent renders content to sanitized HTML in the given format.
+// Comments stored before formats existed have none and are treated as Markdown.
+func renderContent(format, content string) (string, error) {
+	switch format {
+	case model.FormatMarkdown, "":
+		return markdown.Render(content), nil
+	case model.FormatPlain:
+		return markdown.RenderPlain(content), nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+// fillHTML renders the HTML of a comment stored before content_html existed, which the
+// migration that added the column left empty.
+func fillHTML(c *model.Comment) {
+	if c.ContentHTML == "" && c.Content != "" {
+		c.ContentHTML, _ = renderContent(c.Format, c.Content)
 	}
-	return s.cache.SetComment(ctx, comment)
 }
 
 // GetCommentByID retrieves the comment by its ID
 // Tries cache, fallbacks to DB
+// The content of a hidden comment is masked, as in the thread tree.
 func (s *CommentService) GetCommentByID(ctx context.Context, commentID uuid.UUID) (*model.Comment, error) {
 	comment, err := s.cache.GetCommentByID(ctx, commentID)
-	if err == nil {
-		return comment, nil
+	if err != nil {
+		comment, err = s.repo.GetCommentByID(ctx, commentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fillHTML(comment)
+	comment.MaskHidden()
+	return comment, nil
+}
+
+// UpdateComment edits the content of a comment owned by userID.
+// The previous content is kept as a revision and the cached copy is refreshed.
+func (s *CommentService) UpdateComment(ctx context.Context, commentID uuid.UUID, userID, content string) (*model.Comment, error) {
+	existing, err := s.repo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.DeletedAt != nil {
+		return nil, model.ErrNotFound
+	}
+	if existing.UserID != userID {
+		return nil, model.ErrForbidden
+	}
+	// A hidden comment stays as the moderator left it
+	if existing.HiddenAt != nil {
+		return nil, model.ErrForbidden
+	}
+
+	// The edit keeps the format the comment was written in
+	html, err := renderContent(existing.Format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := *existing
+	edited.Content = content
+	flags, err := s.checkPolicies(ctx, &edited)
+	if err != nil {
+		return nil, err
 	}
 
-	comment, err = s.repo.GetCommentByID(ctx, commentID)
+	comment, err := s.repo.UpdateComment(ctx, commentID, content, html, flagReports(commentID, flags))
 	if err != nil {
 		return nil, err
 	}
 
+	// Only the users the edit newly mentions are notified
+	s.notify(ctx, comment, nil, newMentions(existing.Content, content))
+
+	_ = s.cache.UpdateComment(ctx, comment)
 	return comment, nil
 }
 
+// DeleteComment soft-deletes a comment owned by userID.
+// The comment stays in place as a tombstone so its replies remain reachable.
+func (s *CommentService) DeleteComment(ctx context.Context, commentID uuid.UUID, userID string) error {
+	existing, err := s.repo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if existing.UserID != userID {
+		return model.ErrForbidden
+	}
+	if existing.DeletedAt != nil {
+		return nil // already deleted
+	}
+
+	comment, deleted, err := s.repo.DeleteComment(ctx, commentID)
+	if err != nil || !deleted {
+		return err // a concurrent delete got there first and adjusted the counts
+	}
+
+	_ = s.cache.DeleteComment(ctx, comment)
+	if comment.ParentID != nil {
+		_ = s.cache.UpdateCommentScore(ctx, *comment.ParentID, "reply_count", -1)
+	}
+	return nil
+}
+
+// ListRevisions returns the earlier versions of a comment, newest first.
+func (s *CommentService) ListRevisions(ctx context.Context, commentID uuid.UUID) ([]model.Revision, error) {
+	if _, err := s.repo.GetCommentByID(ctx, commentID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRevisions(ctx, commentID)
+}
+
+// Upvote toggles the user's upvote, replacing a downvote if there is one.
 func (s *CommentService) Upvote(ctx context.Context, commentID uuid.UUID, userID string) error {
-	return s.ToggleReaction(ctx, commentID, userID, "upvote", "upvotes")
+	return s.vote(ctx, commentID, userID, +1, true)
 }
 
+// Downvote toggles the user's downvote, replacing an upvote if there is one.
 func (s *CommentService) Downvote(ctx context.Context, commentID uuid.UUID, userID string) error {
-	return s.ToggleReaction(ctx, commentID, userID, "downvote", "downvotes")
+	return s.vote(ctx, commentID, userID, -1, true)
+}
+
+// Vote sets the user's vote to +1 (up), -1 (down) or 0 (none). Up and down votes are mutually exclusive.
+func (s *CommentService) Vote(ctx context.Context, commentID uuid.UUID, userID string, value int) error {
+	if value < -1 || value > 1 {
+		return ErrInvalidVote
+	}
+	return s.vote(ctx, commentID, userID, value, false)
 }
 
 func (s *CommentService) Like(ctx context.Context, commentID uuid.UUID, userID string) error {
 	return s.ToggleReaction(ctx, commentID, userID, "like", "likes")
 }
 
-func (s *CommentService) ListByDate(ctx context.Context, threadID uuid.UUID, cursor int64, limit int) ([]model.Comment, error) {
-	return s.listSorted(ctx, threadID, "date", cursor, limit)
+// ListComments returns one page of a thread's comments in the requested sort order,
+// along with opaque cursors for the next and previous pages. Unknown sorts fall back to date.
+// The thread's pinned comments lead the first page and are left out of every page after it.
+// When viewerID is set, each comment carries the reactions that viewer left on it.
+func (s *CommentService) ListComments(ctx context.Context, threadID uuid.UUID, sort string, cursor *model.Cursor, limit int, viewerID string) (model.CommentPage, error) {
+	field, ok := validSortFields[sort]
+	if !ok {
+		field = validSortFields["date"]
+	}
+
+	comments, err := s.listSorted(ctx, threadID, field, cursor, limit)
+	if err != nil {
+		return model.CommentPage{}, err
+	}
+
+	pinned, err := s.pinnedComments(ctx, threadID)
+	if err != nil {
+		return model.CommentPage{}, err
+	}
+
+	// The cursors are derived from the sorted comments, so pages line up whatever is pinned
+	page := model.NewCommentPage(comments, field, cursor, limit)
+	if len(pinned) > 0 {
+		page.Comments = withPinned(comments, pinned, cursor == nil)
+	}
+	for i := range page.Comments {
+		fillHTML(&page.Comments[i])
+	}
+
+	if viewerID != "" {
+		if err := s.attachViewerReactions(ctx, page.Comments, viewerID); err != nil {
+			return model.CommentPage{}, err
+		}
+	}
+	return page, nil
+}
+
+// withPinned drops the pinned comments from a page of sorted comments, and puts them first on the first page.
+func withPinned(comments, pinned []model.Comment, first bool) []model.Comment {
+	ids := make(map[uuid.UUID]bool, len(pinned))
+	for _, p := range pinned {
+		ids[p.ID] = true
+	}
+
+	out := make([]model.Comment, 0, len(comments)+len(pinned))
+	if first {
+		out = append(out, pinned...)
+	}
+	for _, c := range comments {
+		if !ids[c.ID] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// attachViewerReactions fills in the viewer's reactions on a page of comments with one batched lookup.
+func (s *CommentService) attachViewerReactions(ctx context.Context, comments []model.Comment, viewerID string) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+
+	reactions, err := s.repo.ListUserReactions(ctx, viewerID, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].ViewerReactions = reactions[comments[i].ID]
+	}
+	return nil
 }
 
-func (s *CommentService) ListByUpvotes(ctx context.Context, threadID uuid.UUID, cursor int64, limit int) ([]model.Comment, error) {
-	return s.listSorted(ctx, threadID, "upvotes", cursor, limit)
+// MaxTreeDepth and MaxTreeSize bound a thread tree: replies below MaxTreeDepth levels are cut off,
+// and at most MaxTreeSize comments are returned.
+const (
+	MaxTreeDepth = 10
+	MaxTreeSize  = 500
+)
+
+// GetThreadTree returns the comments of a thread nested under their parents, down to maxDepth levels of replies.
+// Siblings are ordered by the sort field, and comments whose replies were cut off carry a "more replies" marker.
+// A thread with more than MaxTreeSize comments is cut off in path order, which is reported as truncated.
+func (s *CommentService) GetThreadTree(ctx context.Context, threadID uuid.UUID, sortField string, maxDepth int) ([]*model.CommentNode, bool, error) {
+	field, ok := validSortFields[sortField]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: %s", ErrInvalidSort, sortField)
+	}
+	maxDepth = min(maxDepth, MaxTreeDepth)
+
+	comments, hidden, err := s.repo.ListThreadTree(ctx, threadID, maxDepth, MaxTreeSize+1)
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := len(comments) > MaxTreeSize
+	if truncated {
+		comments = comments[:MaxTreeSize]
+	}
+
+	// Comments come ordered by path, so a parent is always seen before its replies
+	nodes := make(map[uuid.UUID]*model.CommentNode, len(comments))
+	var roots []*model.CommentNode
+	for _, c := range comments {
+		// Hidden comments keep their place in the tree so their replies stay reachable
+		fillHTML(&c)
+		c.MaskHidden()
+		node := &model.CommentNode{Comment: c}
+		if count := hidden[c.ID]; count > 0 {
+			node.More = &model.MoreReplies{Count: count}
+		}
+		nodes[c.ID] = node
+
+		if c.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*c.ParentID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	sortNodes(roots, field)
+	return roots, truncated, nil
 }
 
-func (s *CommentService) ListByReplies(ctx context.Context, threadID uuid.UUID, cursor int64, limit int) ([]model.Comment, error) {
-	return s.listSorted(ctx, threadID, "replies", cursor, limit)
+// sortNodes orders sibling nodes by the sort field, newest first on ties, recursively.
+func sortNodes(nodes []*model.CommentNode, field string) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].SortScore(field), nodes[j].SortScore(field)
+		if a != b {
+			return a > b
+		}
+		return nodes[i].CreatedAt.After(nodes[j].CreatedAt)
+	})
+	for _, n := range nodes {
+		sortNodes(n.Replies, field)
+	}
 }
 
 // ToggleReaction adds or removes a user reaction and adjusts the comment's score field to reflect the change.
+// The reaction row and the counter are written in one database transaction, unless votes are written
+// behind, in which case the counter delta is written with the reaction for the flusher.
 func (s *CommentService) ToggleReaction(ctx context.Context, commentID uuid.UUID, userID, reactionType, field string) error {
 	reaction := &model.Reaction{
 		CommentID: commentID,
@@ -114,43 +504,104 @@ func (s *CommentService) ToggleReaction(ctx context.Context, commentID uuid.UUID
 		Type:      reactionType,
 	}
 
-	toggledOn, err := s.repo.AddReaction(ctx, reaction)
+	var toggledOn bool
+	var err error
+	if s.writeBehind {
+		toggledOn, err = s.repo.RecordReaction(ctx, reaction, field)
+	} else {
+		toggledOn, err = s.repo.ToggleReaction(ctx, reaction, field)
+	}
 	if err != nil {
 		return err
 	}
 
+	delta := -1
 	if toggledOn {
-		if err := s.repo.IncrementReactionCount(ctx, commentID, field); err != nil {
-			return err
-		}
-		return s.cache.UpdateCommentScore(ctx, commentID, field, +1)
+		delta = +1
+	}
+	_ = s.cache.UpdateCommentScore(ctx, commentID, field, delta)
+	return nil
+}
+
+// vote records the vote and moves the cached counters from the previous vote to the new one in one update.
+func (s *CommentService) vote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) error {
+	var prev, next int
+	var err error
+	if s.writeBehind {
+		prev, next, err = s.repo.RecordVote(ctx, commentID, userID, value, toggle)
+	} else {
+		prev, next, err = s.repo.Vote(ctx, commentID, userID, value, toggle)
+	}
+	if err != nil || prev == next {
+		return err
 	}
 
-	// toggled off
-	if err := s.repo.DeleteReaction(ctx, commentID, userID, reactionType); err != nil {
+	deltas := map[string]int{}
+	if _, field := model.VoteReaction(prev); field != "" {
+		deltas[field]--
+	}
+	if _, field := model.VoteReaction(next); field != "" {
+		deltas[field]++
+	}
+	_ = s.cache.UpdateCommentScores(ctx, commentID, deltas)
+	return nil
+}
+
+// StreamEvents forwards the events of a thread to ch until ctx is cancelled or reading fails,
+// then closes ch. With lastEventID set, events after that stream position are replayed first.
+func (s *CommentService) StreamEvents(ctx context.Context, threadID uuid.UUID, lastEventID string, ch chan<- model.StreamEvent) error {
+	if s.events == nil {
+		return ErrStreamUnavailable
+	}
+	if err := s.checkThread(ctx, threadID); err != nil {
 		return err
 	}
-	if err := s.repo.DecrementReactionCount(ctx, commentID, field); err != nil {
+
+	events, err := s.events.Subscribe(ctx, threadID, lastEventID)
+	if err != nil {
 		return err
 	}
-	return s.cache.UpdateCommentScore(ctx, commentID, field, -1)
+
+	go func() {
+		defer close(ch)
+		for e := range events {
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
 }
 
-// listSorted fetches from Redis or falls back to DB
-// listing is based on the sort field
-func (s *CommentService) listSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor int64, limit int) ([]model.Comment, error) {
-	validFields := map[string]string{
-		"date":    "created_at",
-		"upvotes": "upvotes",
-		"replies": "reply_count",
+// ListReactions returns one page of who reacted to a comment, newest first.
+// An empty reactionType lists every type.
+func (s *CommentService) ListReactions(ctx context.Context, commentID uuid.UUID, reactionType string, cursor *model.Cursor, limit int) (model.ReactionPage, error) {
+	if reactionType != "" && !validReactionTypes[reactionType] {
+		return model.ReactionPage{}, ErrInvalidType
 	}
 
-	field, ok := validFields[sortField]
-	if !ok {
-		return nil, fmt.Errorf("invalid sort field: %s", sortField)
+	if _, err := s.GetCommentByID(ctx, commentID); err != nil {
+		return model.ReactionPage{}, err
+	}
+
+	reactions, err := s.repo.ListReactions(ctx, commentID, reactionType, cursor, limit)
+	if err != nil {
+		return model.ReactionPage{}, err
 	}
 
-	return s.cache.ListComments(ctx, threadID, field, cursor, limit, func(ctx context.Context, tid uuid.UUID) ([]model.Comment, error) {
+	page := model.ReactionPage{Reactions: reactions}
+	if len(reactions) > 0 && len(reactions) >= limit {
+		page.NextCursor = model.NewReactionCursor(&reactions[len(reactions)-1]).Encode()
+	}
+	return page, nil
+}
+
+// listSorted fetches from Redis or falls back to DB
+// listing is based on the sort field
+func (s *CommentService) listSorted(ctx context.Context, threadID uuid.UUID, field string, cursor *model.Cursor, limit int) ([]model.Comment, error) {
+	return s.cache.ListComments(ctx, threadID, field, cursor, limit, func(ctx context.Context, tid uuid.UUID, cursor *model.Cursor, limit int) ([]model.Comment, error) {
 		return s.repo.ListCommentsSorted(ctx, tid, field, cursor, limit)
 	})
 }
@@ -3,7 +3,9 @@ package service_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kiremitrov123/onboarding/commenting/model"
@@ -23,17 +25,12 @@ func TestToggleReaction_InsertSuccess(t *testing.T) {
 	cache := &mocks.CommentCacheMock{}
 	svc := service.NewCommentService(repo, cache)
 
-	repo.AddReactionFunc = func(ctx context.Context, r *model.Reaction) (bool, error) {
+	repo.ToggleReactionFunc = func(ctx context.Context, r *model.Reaction, f string) (bool, error) {
 		require.Equal(t, commentID, r.CommentID)
 		require.Equal(t, userID, r.UserID)
 		require.Equal(t, reactionType, r.Type)
-		return true, nil
-	}
-
-	repo.IncrementReactionCountFunc = func(ctx context.Context, id uuid.UUID, f string) error {
-		require.Equal(t, commentID, id)
 		require.Equal(t, field, f)
-		return nil
+		return true, nil
 	}
 
 	cache.UpdateCommentScoreFunc = func(ctx context.Context, id uuid.UUID, f string, delta int) error {
@@ -58,21 +55,12 @@ func TestToggleReaction_ToggleOff(t *testing.T) {
 	cache := &mocks.CommentCacheMock{}
 	svc := service.NewCommentService(repo, cache)
 
-	repo.AddReactionFunc = func(ctx context.Context, r *model.Reaction) (bool, error) {
-		return false, nil // simulate already exists
-	}
-
-	repo.DeleteReactionFunc = func(ctx context.Context, id uuid.UUID, user string, typ string) error {
-		require.Equal(t, commentID, id)
-		require.Equal(t, userID, user)
-		require.Equal(t, reactionType, typ)
-		return nil
-	}
-
-	repo.DecrementReactionCountFunc = func(ctx context.Context, id uuid.UUID, f string) error {
-		require.Equal(t, commentID, id)
+	repo.ToggleReactionFunc = func(ctx context.Context, r *model.Reaction, f string) (bool, error) {
+		require.Equal(t, commentID, r.CommentID)
+		require.Equal(t, userID, r.UserID)
+		require.Equal(t, reactionType, r.Type)
 		require.Equal(t, field, f)
-		return nil
+		return false, nil // simulate already exists
 	}
 
 	cache.UpdateCommentScoreFunc = func(ctx context.Context, id uuid.UUID, f string, delta int) error {
@@ -97,11 +85,965 @@ func TestToggleReaction_DBInsertError(t *testing.T) {
 	cache := &mocks.CommentCacheMock{}
 	svc := service.NewCommentService(repo, cache)
 
-	repo.AddReactionFunc = func(ctx context.Context, r *model.Reaction) (bool, error) {
+	repo.ToggleReactionFunc = func(ctx context.Context, r *model.Reaction, f string) (bool, error) {
 		return false, errors.New("db error")
 	}
 
 	err := svc.ToggleReaction(ctx, commentID, userID, reactionType, field)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "db error")
+	require.Empty(t, cache.UpdateCommentScoreCalls())
+}
+
+func TestUpdateComment_Success(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.GetCommentByIDFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+		return &model.Comment{ID: id, UserID: "alice", Content: "old"}, nil
+	}
+
+	repo.UpdateCommentFunc = func(ctx context.Context, id uuid.UUID, content, contentHTML string, reports []model.Report) (*model.Comment, error) {
+		require.Equal(t, commentID, id)
+		require.Equal(t, "new", content)
+		require.Equal(t, "<p>new</p>", contentHTML)
+		return &model.Comment{ID: id, UserID: "alice", Content: content, Revision: 1}, nil
+	}
+
+	cache.UpdateCommentFunc = func(ctx context.Context, c *model.Comment) error {
+		require.Equal(t, "new", c.Content)
+		require.Equal(t, 1, c.Revision)
+		return nil
+	}
+
+	updated, err := svc.UpdateComment(ctx, commentID, "alice", "new")
+	require.NoError(t, err)
+	require.Equal(t, 1, updated.Revision)
+	require.Len(t, cache.UpdateCommentCalls(), 1)
+}
+
+func TestUpdateComment_NotAuthor(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.GetCommentByIDFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+		return &model.Comment{ID: id, UserID: "alice", Content: "old"}, nil
+	}
+
+	_, err := svc.UpdateComment(ctx, uuid.New(), "mallory", "new")
+	require.ErrorIs(t, err, model.ErrForbidden)
+	require.Empty(t, repo.UpdateCommentCalls())
+}
+
+func TestUpdateComment_Hidden(t *testing.T) {
+	ctx := context.Background()
+	hiddenAt := time.Now()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.GetCommentByIDFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+		return &model.Comment{ID: id, UserID: "alice", Content: "old", HiddenAt: &hiddenAt}, nil
+	}
+
+	_, err := svc.UpdateComment(ctx, uuid.New(), "alice", "new")
+	require.ErrorIs(t, err, model.ErrForbidden)
+	require.Empty(t, repo.UpdateCommentCalls())
+}
+
+func TestDeleteComment_ReplyAdjustsParent(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+	parentID := uuid.New()
+	threadID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.GetCommentByIDFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+		return &model.Comment{ID: id, ParentID: &parentID, ThreadID: threadID, UserID: "bob"}, nil
+	}
+
+	repo.DeleteCommentFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, bool, error) {
+		require.Equal(t, commentID, id)
+		now := time.Now()
+		return &model.Comment{ID: id, ParentID: &parentID, ThreadID: threadID, UserID: "bob", Content: model.DeletedContent, DeletedAt: &now}, true, nil
+	}
+
+	cache.DeleteCommentFunc = func(ctx context.Context, c *model.Comment) error {
+		require.Equal(t, model.DeletedContent, c.Content)
+		require.NotNil(t, c.DeletedAt)
+		return nil
+	}
+
+	cache.UpdateCommentScoreFunc = func(ctx context.Context, id uuid.UUID, f string, delta int) error {
+		require.Equal(t, parentID, id)
+		require.Equal(t, "reply_count", f)
+		require.Equal(t, -1, delta)
+		return nil
+	}
+
+	err := svc.DeleteComment(ctx, commentID, "bob")
+	require.NoError(t, err)
+	require.Len(t, cache.DeleteCommentCalls(), 1)
+	require.Len(t, cache.UpdateCommentScoreCalls(), 1)
+}
+
+func TestDeleteComment_AlreadyDeleted(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.GetCommentByIDFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+		now := time.Now()
+		return &model.Comment{ID: id, UserID: "bob", Content: model.DeletedContent, DeletedAt: &now}, nil
+	}
+
+	err := svc.DeleteComment(ctx, uuid.New(), "bob")
+	require.NoError(t, err)
+	require.Empty(t, repo.DeleteCommentCalls())
+}
+
+func TestDeleteComment_ConcurrentDeleteAdjustsParentOnce(t *testing.T) {
+	ctx := context.Background()
+	parentID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	// Both requests read the comment before either deleted it
+	repo.GetCommentByIDFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+		return &model.Comment{ID: id, ParentID: &parentID, UserID: "bob"}, nil
+	}
+	repo.DeleteCommentFunc = func(ctx context.Context, id uuid.UUID) (*model.Comment, bool, error) {
+		now := time.Now()
+		deleted := len(repo.DeleteCommentCalls()) == 1
+		return &model.Comment{ID: id, ParentID: &parentID, UserID: "bob", DeletedAt: &now}, deleted, nil
+	}
+	cache.DeleteCommentFunc = func(ctx context.Context, c *model.Comment) error { return nil }
+	cache.UpdateCommentScoreFunc = func(ctx context.Context, id uuid.UUID, f string, delta int) error { return nil }
+
+	commentID := uuid.New()
+	require.NoError(t, svc.DeleteComment(ctx, commentID, "bob"))
+	require.NoError(t, svc.DeleteComment(ctx, commentID, "bob"))
+	require.Len(t, repo.DeleteCommentCalls(), 2)
+	require.Len(t, cache.UpdateCommentScoreCalls(), 1)
+}
+
+func TestGetThreadTree_NestsAndMarksHidden(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	now := time.Now()
+
+	root := model.Comment{ID: threadID, ThreadID: threadID, CreatedAt: now}
+	older := model.Comment{ID: uuid.New(), ParentID: &root.ID, ThreadID: threadID, Depth: 1, Upvotes: 5, CreatedAt: now.Add(time.Second)}
+	newer := model.Comment{ID: uuid.New(), ParentID: &root.ID, ThreadID: threadID, Depth: 1, Upvotes: 1, CreatedAt: now.Add(2 * time.Second)}
+	nested := model.Comment{ID: uuid.New(), ParentID: &older.ID, ThreadID: threadID, Depth: 2, CreatedAt: now.Add(3 * time.Second)}
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.ListThreadTreeFunc = func(ctx context.Context, tid uuid.UUID, maxDepth, limit int) ([]model.Comment, map[uuid.UUID]int, error) {
+		require.Equal(t, threadID, tid)
+		require.Equal(t, 2, maxDepth)
+		require.Equal(t, service.MaxTreeSize+1, limit)
+		return []model.Comment{root, older, nested, newer}, map[uuid.UUID]int{nested.ID: 4}, nil
+	}
+
+	tree, truncated, err := svc.GetThreadTree(ctx, threadID, "upvotes", 2)
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Len(t, tree, 1)
+	require.Equal(t, root.ID, tree[0].ID)
+
+	replies := tree[0].Replies
+	require.Len(t, replies, 2)
+	require.Equal(t, older.ID, replies[0].ID)
+	require.Equal(t, newer.ID, replies[1].ID)
+
+	require.Len(t, replies[0].Replies, 1)
+	require.Equal(t, nested.ID, replies[0].Replies[0].ID)
+	require.NotNil(t, replies[0].Replies[0].More)
+	require.Equal(t, 4, replies[0].Replies[0].More.Count)
+}
+
+func TestGetThreadTree_InvalidSort(t *testing.T) {
+	svc := service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{})
+
+	_, _, err := svc.GetThreadTree(context.Background(), uuid.New(), "nope", 2)
+	require.ErrorIs(t, err, service.ErrInvalidSort)
+}
+
+func TestGetThreadTree_TruncatesLargeThreads(t *testing.T) {
+	threadID := uuid.New()
+	comments := make([]model.Comment, service.MaxTreeSize+1)
+	for i := range comments {
+		comments[i] = model.Comment{ID: uuid.New(), ThreadID: threadID}
+	}
+
+	repo := &mocks.CommentRepoMock{}
+	svc := service.NewCommentService(repo, &mocks.CommentCacheMock{})
+	repo.ListThreadTreeFunc = func(ctx context.Context, tid uuid.UUID, maxDepth, limit int) ([]model.Comment, map[uuid.UUID]int, error) {
+		require.Equal(t, service.MaxTreeDepth, maxDepth)
+		return comments[:min(limit, len(comments))], nil, nil
+	}
+
+	tree, truncated, err := svc.GetThreadTree(context.Background(), threadID, "date", 50)
+	require.NoError(t, err)
+	require.True(t, truncated)
+	require.Len(t, tree, service.MaxTreeSize)
+}
+
+func TestListComments_ReturnsCursors(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	now := time.Now()
+
+	page := []model.Comment{
+		{ID: uuid.New(), ThreadID: threadID, CreatedAt: now},
+		{ID: uuid.New(), ThreadID: threadID, CreatedAt: now.Add(-time.Second)},
+	}
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{GetPinnedIDsFunc: noPins}
+	svc := service.NewCommentService(repo, cache)
+
+	cache.ListCommentsFunc = func(ctx context.Context, tid uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
+		require.Equal(t, "upvotes", sortKey)
+		return page, nil
+	}
+
+	first, err := svc.ListComments(ctx, threadID, "upvotes", nil, 2, "")
+	require.NoError(t, err)
+	require.Len(t, first.Comments, 2)
+	require.Empty(t, first.PrevCursor)
+	require.NotEmpty(t, first.NextCursor)
+
+	next, err := model.DecodeCursor(first.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, page[1].ID, next.ID)
+	require.False(t, next.Backward)
+
+	second, err := svc.ListComments(ctx, threadID, "upvotes", next, 5, "")
+	require.NoError(t, err)
+	require.Empty(t, second.NextCursor)
+
+	prev, err := model.DecodeCursor(second.PrevCursor)
+	require.NoError(t, err)
+	require.Equal(t, page[0].ID, prev.ID)
+	require.True(t, prev.Backward)
+}
+
+func TestListComments_SortModes(t *testing.T) {
+	ctx := context.Background()
+
+	cases := map[string]string{
+		"likes":         "likes",
+		"score":         "score",
+		"best":          "best",
+		"controversial": "controversy",
+		"hot":           "hot",
+		"unknown":       "created_at",
+	}
+
+	for sort, field := range cases {
+		cache := &mocks.CommentCacheMock{GetPinnedIDsFunc: noPins}
+		svc := service.NewCommentService(&mocks.CommentRepoMock{}, cache)
+
+		cache.ListCommentsFunc = func(ctx context.Context, tid uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
+			require.Equal(t, field, sortKey, "sort %s", sort)
+			return nil, nil
+		}
+
+		_, err := svc.ListComments(ctx, uuid.New(), sort, nil, 10, "")
+		require.NoError(t, err)
+	}
+}
+
+func TestUpvote_SwitchesFromDownvote(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.VoteFunc = func(ctx context.Context, id uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+		require.Equal(t, 1, value)
+		require.True(t, toggle)
+		return -1, 1, nil
+	}
+
+	cache.UpdateCommentScoresFunc = func(ctx context.Context, id uuid.UUID, deltas map[string]int) error {
+		require.Equal(t, commentID, id)
+		require.Equal(t, map[string]int{"upvotes": 1, "downvotes": -1}, deltas)
+		return nil
+	}
+
+	err := svc.Upvote(ctx, commentID, "user1")
+	require.NoError(t, err)
+	require.Len(t, cache.UpdateCommentScoresCalls(), 1)
+}
+
+func TestVote_Unchanged(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.VoteFunc = func(ctx context.Context, id uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+		require.False(t, toggle)
+		return 1, 1, nil
+	}
+
+	err := svc.Vote(ctx, uuid.New(), "user1", 1)
+	require.NoError(t, err)
+	require.Empty(t, cache.UpdateCommentScoresCalls())
+}
+
+func TestVote_InvalidValue(t *testing.T) {
+	svc := service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{})
+
+	err := svc.Vote(context.Background(), uuid.New(), "user1", 2)
+	require.ErrorIs(t, err, service.ErrInvalidVote)
+}
+
+func TestListComments_ViewerReactions(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+
+	liked := model.Comment{ID: uuid.New(), ThreadID: threadID, CreatedAt: time.Now()}
+	other := model.Comment{ID: uuid.New(), ThreadID: threadID, CreatedAt: time.Now()}
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{GetPinnedIDsFunc: noPins}
+	svc := service.NewCommentService(repo, cache)
+
+	cache.ListCommentsFunc = func(ctx context.Context, tid uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
+		return []model.Comment{liked, other}, nil
+	}
+
+	repo.ListUserReactionsFunc = func(ctx context.Context, userID string, ids []uuid.UUID) (map[uuid.UUID][]string, error) {
+		require.Equal(t, "viewer", userID)
+		require.ElementsMatch(t, []uuid.UUID{liked.ID, other.ID}, ids)
+		return map[uuid.UUID][]string{liked.ID: {"like", "upvote"}}, nil
+	}
+
+	page, err := svc.ListComments(ctx, threadID, "date", nil, 10, "viewer")
+	require.NoError(t, err)
+	require.Len(t, repo.ListUserReactionsCalls(), 1)
+	require.Equal(t, []string{"like", "upvote"}, page.Comments[0].ViewerReactions)
+	require.Empty(t, page.Comments[1].ViewerReactions)
+}
+
+func TestCreateComment_CacheFailureIsNotFatal(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache)
+
+	repo.CreateCommentFunc = func(ctx context.Context, c *model.Comment, reports []model.Report) error {
+		return nil
+	}
+	cache.SetCommentFunc = func(ctx context.Context, c *model.Comment) error {
+		return errors.New("redis down")
+	}
+
+	comment := &model.Comment{UserID: "user1", Content: "hello"}
+	err := svc.CreateComment(ctx, comment)
+	require.NoError(t, err)
+	require.Len(t, repo.CreateCommentCalls(), 1)
+}
+
+func TestToggleReaction_WriteBehindRecordsDelta(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{
+		RecordReactionFunc: func(ctx context.Context, r *model.Reaction, f string) (bool, error) { return false, nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		UpdateCommentScoreFunc: func(ctx context.Context, id uuid.UUID, f string, delta int) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache, service.WithWriteBehindVotes())
+
+	require.NoError(t, svc.Like(ctx, commentID, "user1"))
+
+	require.Len(t, repo.RecordReactionCalls(), 1)
+	require.Empty(t, repo.ToggleReactionCalls())
+	calls := cache.UpdateCommentScoreCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, -1, calls[0].Delta)
+}
+
+func TestVote_WriteBehindRecordsDelta(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+
+	repo := &mocks.CommentRepoMock{
+		RecordVoteFunc: func(ctx context.Context, id uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+			return -1, 1, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		UpdateCommentScoresFunc: func(ctx context.Context, id uuid.UUID, deltas map[string]int) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache, service.WithWriteBehindVotes())
+
+	require.NoError(t, svc.Upvote(ctx, commentID, "user1"))
+
+	require.Len(t, repo.RecordVoteCalls(), 1)
+	require.Empty(t, repo.VoteCalls())
+	calls := cache.UpdateCommentScoresCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, map[string]int{"upvotes": 1, "downvotes": -1}, calls[0].Deltas)
+}
+
+func TestReportComment_InvalidReason(t *testing.T) {
+	svc := service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{})
+
+	err := svc.ReportComment(context.Background(), uuid.New(), "user1", "   ")
+	require.ErrorIs(t, err, service.ErrInvalidReason)
+}
+
+func TestModerateComment_HideDropsFromCache(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+	hiddenAt := time.Now().UTC()
+
+	repo := &mocks.CommentRepoMock{
+		ModerateCommentFunc: func(ctx context.Context, id uuid.UUID, moderatorID, action, note string) (*model.Comment, bool, error) {
+			require.Equal(t, commentID, id)
+			require.Equal(t, "mod1", moderatorID)
+			require.Equal(t, model.ModerationHide, action)
+			return &model.Comment{ID: id, ThreadID: id, HiddenAt: &hiddenAt}, true, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		DeleteCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	comment, err := svc.ModerateComment(ctx, commentID, "mod1", model.ModerationHide, "spam")
+	require.NoError(t, err)
+	require.NotNil(t, comment.HiddenAt)
+	require.Len(t, cache.DeleteCommentCalls(), 1)
+}
+
+func TestModerateComment_ApproveRestoresAndNoOpSkipsCache(t *testing.T) {
+	ctx := context.Background()
+	changed := true
+
+	repo := &mocks.CommentRepoMock{
+		ModerateCommentFunc: func(ctx context.Context, id uuid.UUID, moderatorID, action, note string) (*model.Comment, bool, error) {
+			return &model.Comment{ID: id, ThreadID: id}, changed, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	_, err := svc.ModerateComment(ctx, uuid.New(), "mod1", model.ModerationApprove, "")
+	require.NoError(t, err)
+	require.Len(t, cache.SetCommentCalls(), 1)
+
+	// Approving a comment that isn't hidden leaves the cache alone
+	changed = false
+	_, err = svc.ModerateComment(ctx, uuid.New(), "mod1", model.ModerationApprove, "")
+	require.NoError(t, err)
+	require.Len(t, cache.SetCommentCalls(), 1)
+	require.Empty(t, cache.DeleteCommentCalls())
+}
+
+func TestGetCommentByID_MasksHidden(t *testing.T) {
+	hiddenAt := time.Now().UTC()
+	repo := &mocks.CommentRepoMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, Content: "rude", ContentHTML: "<p>rude</p>", HiddenAt: &hiddenAt}, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return nil, errors.New("miss")
+		},
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	comment, err := svc.GetCommentByID(context.Background(), uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, model.HiddenContent, comment.Content)
+	require.Equal(t, model.HiddenContentHTML, comment.ContentHTML)
+}
+
+func TestGetCommentByID_RendersMissingHTML(t *testing.T) {
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			// Stored before content_html existed
+			return &model.Comment{ID: id, Content: "**old**"}, nil
+		},
+	}
+	svc := service.NewCommentService(&mocks.CommentRepoMock{}, cache)
+
+	comment, err := svc.GetCommentByID(context.Background(), uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, "<p><strong>old</strong></p>", comment.ContentHTML)
+}
+
+func TestModerateComment_InvalidAction(t *testing.T) {
+	svc := service.NewCommentService(&mocks.CommentRepoMock{}, &mocks.CommentCacheMock{})
+
+	_, err := svc.ModerateComment(context.Background(), uuid.New(), "mod1", "ban", "")
+	require.ErrorIs(t, err, service.ErrInvalidAction)
+}
+
+func TestCreateComment_PolicyRejects(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{}
+	cache := &mocks.CommentCacheMock{}
+	svc := service.NewCommentService(repo, cache, service.WithContentPolicies(
+		service.LengthPolicy{Min: 1, Max: 100},
+		service.NewBannedWordsPolicy([]string{"spam"}, service.VerdictReject),
+	))
+
+	err := svc.CreateComment(ctx, &model.Comment{UserID: "user1", Content: "buy SPAM now"})
+
+	var policyErr *service.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Equal(t, "banned_words", policyErr.Violation.Policy)
+	require.Equal(t, "banned_word", policyErr.Violation.Code)
+	require.Empty(t, repo.CreateCommentCalls())
+
+	// Whole words only
+	repo.CreateCommentFunc = func(ctx context.Context, c *model.Comment, reports []model.Report) error { return nil }
+	cache.SetCommentFunc = func(ctx context.Context, c *model.Comment) error { return nil }
+	require.NoError(t, svc.CreateComment(ctx, &model.Comment{UserID: "user1", Content: "spammy but fine"}))
+}
+
+func TestBannedWordsPolicy_UnicodeWords(t *testing.T) {
+	ctx := context.Background()
+	policy := service.NewBannedWordsPolicy([]string{"žaba", "спам", "spam"}, service.VerdictReject)
+
+	for content, banned := range map[string]bool{
+		"ŽABA!":           true,
+		"vidiš žabu":      false,
+		"(спам)":          true,
+		"спамер":          false,
+		"spam":            true,
+		"spamé":           false,
+		"éspam":           false,
+		"naïve spam_bot":  false,
+		"no spam, please": true,
+	} {
+		v, err := policy.Check(ctx, &model.Comment{Content: content})
+		require.NoError(t, err)
+		require.Equal(t, banned, v != nil, content)
+	}
+}
+
+func TestCreateComment_PolicyFlagsForModeration(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{
+		CreateCommentFunc: func(ctx context.Context, c *model.Comment, reports []model.Report) error { return nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache, service.WithContentPolicies(
+		service.LinkPolicy{Max: 5, Flag: 1},
+	))
+
+	comment := &model.Comment{UserID: "user1", Content: "see https://a.example and www.b.example"}
+	require.NoError(t, svc.CreateComment(ctx, comment))
+
+	// The report is stored with the comment
+	calls := repo.CreateCommentCalls()
+	require.Len(t, calls, 1)
+	require.Len(t, calls[0].Reports, 1)
+	require.Equal(t, comment.ID, calls[0].Reports[0].CommentID)
+	require.Equal(t, "policy:links", calls[0].Reports[0].UserID)
+}
+
+func TestUpdateComment_ChecksPolicies(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+	recent := func(ctx context.Context, userID string, since time.Time, limit int) ([]model.Comment, error) {
+		return []model.Comment{{ID: commentID, UserID: "user1", Content: "hello"}}, nil
+	}
+
+	repo := &mocks.CommentRepoMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return &model.Comment{ID: id, UserID: "user1", Content: "hello"}, nil
+		},
+		UpdateCommentFunc: func(ctx context.Context, id uuid.UUID, content, contentHTML string, reports []model.Report) (*model.Comment, error) {
+			return &model.Comment{ID: id, UserID: "user1", Content: content}, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		UpdateCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache, service.WithContentPolicies(
+		service.NewBannedWordsPolicy([]string{"spam"}, service.VerdictReject),
+		service.LinkPolicy{Max: 5, Flag: 1},
+		service.DuplicatePolicy{Recent: recent, Window: time.Minute, Limit: 10},
+	))
+
+	_, err := svc.UpdateComment(ctx, commentID, "user1", "now with spam")
+	var policyErr *service.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Empty(t, repo.UpdateCommentCalls())
+
+	// Flags are stored with the edit, and the comment isn't a duplicate of itself
+	_, err = svc.UpdateComment(ctx, commentID, "user1", "hello https://a.example www.b.example")
+	require.NoError(t, err)
+	_, err = svc.UpdateComment(ctx, commentID, "user1", "hello")
+	require.NoError(t, err)
+
+	calls := repo.UpdateCommentCalls()
+	require.Len(t, calls, 2)
+	require.Len(t, calls[0].Reports, 1)
+	require.Equal(t, "policy:links", calls[0].Reports[0].UserID)
+	require.Empty(t, calls[1].Reports)
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	policy := service.DuplicatePolicy{
+		Recent: func(ctx context.Context, userID string, since time.Time, limit int) ([]model.Comment, error) {
+			require.Equal(t, "user1", userID)
+			require.WithinDuration(t, time.Now().Add(-time.Minute), since, time.Second)
+			return []model.Comment{{Content: "Hello   World"}}, nil
+		},
+		Window: time.Minute,
+		Limit:  10,
+	}
+
+	v, err := policy.Check(ctx, &model.Comment{UserID: "user1", Content: "hello world "})
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, service.VerdictReject, v.Verdict)
+
+	v, err = policy.Check(ctx, &model.Comment{UserID: "user1", Content: "something new"})
+	require.NoError(t, err)
+	require.Nil(t, v)
+}
+
+func TestParseMentions(t *testing.T) {
+	require.Equal(t, []string{"alice", "bob.smith", "carol_1"},
+		service.ParseMentions("@alice and @bob.smith, cc @carol_1. Thanks @alice!"))
+	require.Empty(t, service.ParseMentions("mail me at dave@example.com or @@eve"))
+}
+
+func TestCreateComment_NotifiesParentAuthorAndMentions(t *testing.T) {
+	ctx := context.Background()
+	parentID := uuid.New()
+	parent := &model.Comment{ID: parentID, ThreadID: uuid.New(), UserID: "alice", Path: model.PathSegment(parentID)}
+
+	repo := &mocks.CommentRepoMock{
+		CreateCommentFunc:       func(ctx context.Context, c *model.Comment, reports []model.Report) error { return nil },
+		CreateNotificationsFunc: func(ctx context.Context, n []model.Notification) error { return nil },
+		ListExistingUsersFunc: func(ctx context.Context, userIDs []string) (map[string]bool, error) {
+			return map[string]bool{"alice": true, "bob": true, "carol": true}, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			return parent, nil
+		},
+		SetCommentFunc:      func(ctx context.Context, c *model.Comment) error { return nil },
+		IncrUnreadCountFunc: func(ctx context.Context, userID string, delta int) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	// alice gets a single reply notification and bob a mention; the author and unknown users aren't notified
+	comment := &model.Comment{ParentID: &parent.ID, UserID: "carol", Content: "@alice @bob @carol @nobody agreed"}
+	require.NoError(t, svc.CreateComment(ctx, comment))
+
+	calls := repo.CreateNotificationsCalls()
+	require.Len(t, calls, 1)
+	notifications := calls[0].Notifications
+	require.Len(t, notifications, 2)
+	require.Equal(t, "alice", notifications[0].UserID)
+	require.Equal(t, model.NotificationReply, notifications[0].Type)
+	require.Equal(t, "bob", notifications[1].UserID)
+	require.Equal(t, model.NotificationMention, notifications[1].Type)
+	require.Equal(t, "carol", notifications[1].ActorID)
+	require.Equal(t, parent.ThreadID, notifications[1].ThreadID)
+
+	require.Len(t, cache.IncrUnreadCountCalls(), 2)
+}
+
+func TestUpdateComment_NotifiesNewMentions(t *testing.T) {
+	ctx := context.Background()
+	commentID := uuid.New()
+	existing := &model.Comment{ID: commentID, ThreadID: commentID, UserID: "carol", Content: "thanks @alice", Format: model.FormatMarkdown}
+
+	repo := &mocks.CommentRepoMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) { return existing, nil },
+		UpdateCommentFunc: func(ctx context.Context, id uuid.UUID, content, html string, reports []model.Report) (*model.Comment, error) {
+			edited := *existing
+			edited.Content, edited.ContentHTML = content, html
+			return &edited, nil
+		},
+		ListExistingUsersFunc: func(ctx context.Context, userIDs []string) (map[string]bool, error) {
+			return map[string]bool{"bob": true}, nil
+		},
+		CreateNotificationsFunc: func(ctx context.Context, n []model.Notification) error { return nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		UpdateCommentFunc:   func(ctx context.Context, c *model.Comment) error { return nil },
+		IncrUnreadCountFunc: func(ctx context.Context, userID string, delta int) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	_, err := svc.UpdateComment(ctx, commentID, "carol", "thanks @alice and @bob")
+	require.NoError(t, err)
+
+	// alice was mentioned already, so only bob is looked up and notified
+	lookups := repo.ListExistingUsersCalls()
+	require.Len(t, lookups, 1)
+	require.Equal(t, []string{"bob"}, lookups[0].UserIDs)
+	calls := repo.CreateNotificationsCalls()
+	require.Len(t, calls, 1)
+	require.Len(t, calls[0].Notifications, 1)
+	require.Equal(t, "bob", calls[0].Notifications[0].UserID)
+}
+
+func TestListNotifications_CachesUnreadCount(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{
+		ListNotificationsFunc: func(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error) {
+			return []model.Notification{
+				{ID: uuid.New(), UserID: userID, CreatedAt: time.Now()},
+				{ID: uuid.New(), UserID: userID, CreatedAt: time.Now().Add(-time.Minute)},
+			}, nil
+		},
+		CountUnreadNotificationsFunc: func(ctx context.Context, userID string) (int, error) { return 7, nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		GetUnreadCountFunc:  func(ctx context.Context, userID string) (int, error) { return 0, errors.New("miss") },
+		FillUnreadCountFunc: func(ctx context.Context, userID string, count int) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	page, err := svc.ListNotifications(ctx, "alice", nil, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Notifications, 2)
+	require.NotEmpty(t, page.NextCursor)
+	require.Equal(t, 7, page.UnreadCount)
+
+	sets := cache.FillUnreadCountCalls()
+	require.Len(t, sets, 1)
+	require.Equal(t, 7, sets[0].Count)
+}
+
+func TestCreateComment_ReloadsParentCachedWithoutPath(t *testing.T) {
+	ctx := context.Background()
+	parentID := uuid.New()
+	threadID := uuid.New()
+	stale := &model.Comment{ID: parentID, ThreadID: threadID, UserID: "alice"}
+	stored := &model.Comment{ID: parentID, ThreadID: threadID, UserID: "alice", Path: model.PathSegment(parentID)}
+
+	repo := &mocks.CommentRepoMock{
+		GetCommentByIDFunc:      func(ctx context.Context, id uuid.UUID) (*model.Comment, error) { return stored, nil },
+		CreateCommentFunc:       func(ctx context.Context, c *model.Comment, reports []model.Report) error { return nil },
+		CreateNotificationsFunc: func(ctx context.Context, n []model.Notification) error { return nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc:  func(ctx context.Context, id uuid.UUID) (*model.Comment, error) { return stale, nil },
+		SetCommentFunc:      func(ctx context.Context, c *model.Comment) error { return nil },
+		IncrUnreadCountFunc: func(ctx context.Context, userID string, delta int) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	comment := &model.Comment{ParentID: &parentID, UserID: "bob", Content: "reply"}
+	require.NoError(t, svc.CreateComment(ctx, comment))
+	require.Len(t, repo.GetCommentByIDCalls(), 1)
+	require.Equal(t, stored.Path+model.PathSegment(comment.ID), comment.Path)
+	require.Equal(t, 1, comment.Depth)
+}
+
+func TestCreateComment_RendersContent(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mocks.CommentRepoMock{
+		CreateCommentFunc: func(ctx context.Context, c *model.Comment, reports []model.Report) error { return nil },
+	}
+	cache := &mocks.CommentCacheMock{
+		SetCommentFunc: func(ctx context.Context, c *model.Comment) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	comment := &model.Comment{UserID: "user1", Content: "**hi** <script>x</script>"}
+	require.NoError(t, svc.CreateComment(ctx, comment))
+	require.Equal(t, model.FormatMarkdown, comment.Format)
+	require.Equal(t, "<p><strong>hi</strong> &lt;script&gt;x&lt;/script&gt;</p>", comment.ContentHTML)
+
+	plain := &model.Comment{UserID: "user1", Content: "**hi**", Format: model.FormatPlain}
+	require.NoError(t, svc.CreateComment(ctx, plain))
+	require.Equal(t, "<p>**hi**</p>", plain.ContentHTML)
+
+	err := svc.CreateComment(ctx, &model.Comment{UserID: "user1", Content: "hi", Format: "html"})
+	require.ErrorIs(t, err, service.ErrInvalidFormat)
+	require.Len(t, repo.CreateCommentCalls(), 2)
+}
+
+func TestSearchComments_SnippetsAndCursor(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	long := strings.Repeat("filler words here ", 20) + "the <b>Votes</b> are in and the vote is final"
+
+	repo := &mocks.CommentRepoMock{
+		SearchCommentsFunc: func(ctx context.Context, query string, tid *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error) {
+			require.Equal(t, "vote", query)
+			require.Equal(t, threadID, *tid)
+			return []model.SearchResult{
+				{Comment: model.Comment{ID: uuid.New(), Content: long, CreatedAt: time.Now()}, Rank: 0.5},
+				{Comment: model.Comment{ID: uuid.New(), Content: "a devoted vote", CreatedAt: time.Now()}, Rank: 0.25},
+			}, nil
+		},
+	}
+	svc := service.NewCommentService(repo, &mocks.CommentCacheMock{})
+
+	page, err := svc.SearchComments(ctx, " vote ", &threadID, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Results, 2)
+
+	first := page.Results[0].Snippet
+	require.True(t, strings.HasPrefix(first, "…"))
+	require.Contains(t, first, "&lt;b&gt;<mark>Vote</mark>s&lt;/b&gt; are in and the <mark>vote</mark> is final")
+	require.Equal(t, "a devoted <mark>vote</mark>", page.Results[1].Snippet)
+
+	cursor, err := model.DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, 0.25, cursor.Score)
+	require.Equal(t, page.Results[1].Comment.ID, cursor.ID)
+
+	_, err = svc.SearchComments(ctx, "   ", nil, nil, 10)
+	require.ErrorIs(t, err, service.ErrInvalidQuery)
+}
+
+// noPins stands in for a cached thread without pinned comments.
+func noPins(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error) {
+	return []uuid.UUID{}, nil
+}
+
+func TestCreateComment_LockedThread(t *testing.T) {
+	thread := &model.Thread{ID: uuid.New(), SubjectKey: "article:1", Locked: true}
+	repo := &mocks.CommentRepoMock{
+		GetThreadFunc: func(ctx context.Context, threadID uuid.UUID) (*model.Thread, error) {
+			require.Equal(t, thread.ID, threadID)
+			return thread, nil
+		},
+	}
+	svc := service.NewCommentService(repo, &mocks.CommentCacheMock{})
+
+	err := svc.CreateComment(context.Background(), &model.Comment{ThreadID: thread.ID, UserID: "alice", Content: "hi"})
+	require.ErrorIs(t, err, model.ErrThreadLocked)
+	require.Empty(t, repo.CreateCommentCalls())
+}
+
+func TestPinComment_UpdatesCachedPins(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	comment := &model.Comment{ID: uuid.New(), ThreadID: threadID}
+
+	repo := &mocks.CommentRepoMock{
+		PinCommentFunc: func(ctx context.Context, tid, cid uuid.UUID, maxPinned int) (*model.Thread, error) {
+			return &model.Thread{ID: tid, PinnedCommentIDs: []uuid.UUID{cid}}, nil
+		},
+	}
+	cache := &mocks.CommentCacheMock{
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) { return comment, nil },
+		SetPinnedIDsFunc:   func(ctx context.Context, tid uuid.UUID, ids []uuid.UUID) error { return nil },
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	_, err := svc.PinComment(ctx, threadID, comment.ID)
+	require.NoError(t, err)
+
+	calls := cache.SetPinnedIDsCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, []uuid.UUID{comment.ID}, calls[0].Ids)
+}
+
+func TestListComments_PinnedFirst(t *testing.T) {
+	ctx := context.Background()
+	threadID := uuid.New()
+	now := time.Now()
+
+	pinned := model.Comment{ID: uuid.New(), ThreadID: threadID, CreatedAt: now.Add(-time.Hour)}
+	removed := model.Comment{ID: uuid.New(), ThreadID: threadID, CreatedAt: now, DeletedAt: &now}
+	sorted := []model.Comment{
+		{ID: uuid.New(), ThreadID: threadID, CreatedAt: now},
+		pinned,
+	}
+
+	repo := &mocks.CommentRepoMock{
+		GetThreadFunc: func(ctx context.Context, tid uuid.UUID) (*model.Thread, error) {
+			return &model.Thread{ID: tid, PinnedCommentIDs: []uuid.UUID{removed.ID, pinned.ID}}, nil
+		},
+	}
+	var cachedPins []uuid.UUID
+	cache := &mocks.CommentCacheMock{
+		GetPinnedIDsFunc: func(ctx context.Context, tid uuid.UUID) ([]uuid.UUID, error) {
+			if cachedPins == nil {
+				return nil, errors.New("miss")
+			}
+			return cachedPins, nil
+		},
+		FillPinnedIDsFunc: func(ctx context.Context, tid uuid.UUID, ids []uuid.UUID) error {
+			cachedPins = ids
+			return nil
+		},
+		GetCommentByIDFunc: func(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+			if id == removed.ID {
+				return &removed, nil
+			}
+			return &pinned, nil
+		},
+		ListCommentsFunc: func(ctx context.Context, tid uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
+			return sorted, nil
+		},
+	}
+	svc := service.NewCommentService(repo, cache)
+
+	first, err := svc.ListComments(ctx, threadID, "date", nil, 2, "")
+	require.NoError(t, err)
+	require.Len(t, first.Comments, 2)
+	require.Equal(t, pinned.ID, first.Comments[0].ID)
+	require.True(t, first.Comments[0].Pinned)
+	require.Equal(t, sorted[0].ID, first.Comments[1].ID)
+
+	// The cursor still follows the sorted comments, pinned one included
+	next, err := model.DecodeCursor(first.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, pinned.ID, next.ID)
+
+	second, err := svc.ListComments(ctx, threadID, "date", next, 2, "")
+	require.NoError(t, err)
+	require.Len(t, second.Comments, 1)
+	require.Equal(t, sorted[0].ID, second.Comments[0].ID)
+
+	// The pins were read from the database once, then from the cache
+	require.Len(t, repo.GetThreadCalls(), 1)
 }
@@ -21,18 +21,45 @@ var _ service.CommentCache = &CommentCacheMock{}
 //
 //		// make and configure a mocked service.CommentCache
 //		mockedCommentCache := &CommentCacheMock{
+//			DeleteCommentFunc: func(ctx context.Context, comment *model.Comment) error {
+//				panic("mock out the DeleteComment method")
+//			},
+//			FillPinnedIDsFunc: func(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error {
+//				panic("mock out the FillPinnedIDs method")
+//			},
+//			FillUnreadCountFunc: func(ctx context.Context, userID string, count int) error {
+//				panic("mock out the FillUnreadCount method")
+//			},
 //			GetCommentByIDFunc: func(ctx context.Context, commentID uuid.UUID) (*model.Comment, error) {
 //				panic("mock out the GetCommentByID method")
 //			},
-//			ListCommentsFunc: func(ctx context.Context, threadID uuid.UUID, sortKey string, cursor int64, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
+//			GetPinnedIDsFunc: func(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error) {
+//				panic("mock out the GetPinnedIDs method")
+//			},
+//			GetUnreadCountFunc: func(ctx context.Context, userID string) (int, error) {
+//				panic("mock out the GetUnreadCount method")
+//			},
+//			IncrUnreadCountFunc: func(ctx context.Context, userID string, delta int) error {
+//				panic("mock out the IncrUnreadCount method")
+//			},
+//			ListCommentsFunc: func(ctx context.Context, threadID uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
 //				panic("mock out the ListComments method")
 //			},
 //			SetCommentFunc: func(ctx context.Context, comment *model.Comment) error {
 //				panic("mock out the SetComment method")
 //			},
+//			SetPinnedIDsFunc: func(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error {
+//				panic("mock out the SetPinnedIDs method")
+//			},
+//			UpdateCommentFunc: func(ctx context.Context, comment *model.Comment) error {
+//				panic("mock out the UpdateComment method")
+//			},
 //			UpdateCommentScoreFunc: func(ctx context.Context, commentID uuid.UUID, field string, delta int) error {
 //				panic("mock out the UpdateCommentScore method")
 //			},
+//			UpdateCommentScoresFunc: func(ctx context.Context, commentID uuid.UUID, deltas map[string]int) error {
+//				panic("mock out the UpdateCommentScores method")
+//			},
 //		}
 //
 //		// use mockedCommentCache in code that requires service.CommentCache
@@ -40,20 +67,72 @@ var _ service.CommentCache = &CommentCacheMock{}
 //
 //	}
 type CommentCacheMock struct {
+	// DeleteCommentFunc mocks the DeleteComment method.
+	DeleteCommentFunc func(ctx context.Context, comment *model.Comment) error
+
+	// FillPinnedIDsFunc mocks the FillPinnedIDs method.
+	FillPinnedIDsFunc func(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error
+
+	// FillUnreadCountFunc mocks the FillUnreadCount method.
+	FillUnreadCountFunc func(ctx context.Context, userID string, count int) error
+
 	// GetCommentByIDFunc mocks the GetCommentByID method.
 	GetCommentByIDFunc func(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
 
+	// GetPinnedIDsFunc mocks the GetPinnedIDs method.
+	GetPinnedIDsFunc func(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error)
+
+	// GetUnreadCountFunc mocks the GetUnreadCount method.
+	GetUnreadCountFunc func(ctx context.Context, userID string) (int, error)
+
+	// IncrUnreadCountFunc mocks the IncrUnreadCount method.
+	IncrUnreadCountFunc func(ctx context.Context, userID string, delta int) error
+
 	// ListCommentsFunc mocks the ListComments method.
-	ListCommentsFunc func(ctx context.Context, threadID uuid.UUID, sortKey string, cursor int64, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error)
+	ListCommentsFunc func(ctx context.Context, threadID uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error)
 
 	// SetCommentFunc mocks the SetComment method.
 	SetCommentFunc func(ctx context.Context, comment *model.Comment) error
 
+	// SetPinnedIDsFunc mocks the SetPinnedIDs method.
+	SetPinnedIDsFunc func(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error
+
+	// UpdateCommentFunc mocks the UpdateComment method.
+	UpdateCommentFunc func(ctx context.Context, comment *model.Comment) error
+
 	// UpdateCommentScoreFunc mocks the UpdateCommentScore method.
 	UpdateCommentScoreFunc func(ctx context.Context, commentID uuid.UUID, field string, delta int) error
 
+	// UpdateCommentScoresFunc mocks the UpdateCommentScores method.
+	UpdateCommentScoresFunc func(ctx context.Context, commentID uuid.UUID, deltas map[string]int) error
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// DeleteComment holds details about calls to the DeleteComment method.
+		DeleteComment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Comment is the comment argument value.
+			Comment *model.Comment
+		}
+		// FillPinnedIDs holds details about calls to the FillPinnedIDs method.
+		FillPinnedIDs []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+			// Ids is the ids argument value.
+			Ids []uuid.UUID
+		}
+		// FillUnreadCount holds details about calls to the FillUnreadCount method.
+		FillUnreadCount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID string
+			// Count is the count argument value.
+			Count int
+		}
 		// GetCommentByID holds details about calls to the GetCommentByID method.
 		GetCommentByID []struct {
 			// Ctx is the ctx argument value.
@@ -61,6 +140,29 @@ type CommentCacheMock struct {
 			// CommentID is the commentID argument value.
 			CommentID uuid.UUID
 		}
+		// GetPinnedIDs holds details about calls to the GetPinnedIDs method.
+		GetPinnedIDs []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+		}
+		// GetUnreadCount holds details about calls to the GetUnreadCount method.
+		GetUnreadCount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID string
+		}
+		// IncrUnreadCount holds details about calls to the IncrUnreadCount method.
+		IncrUnreadCount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID string
+			// Delta is the delta argument value.
+			Delta int
+		}
 		// ListComments holds details about calls to the ListComments method.
 		ListComments []struct {
 			// Ctx is the ctx argument value.
@@ -70,7 +172,7 @@ type CommentCacheMock struct {
 			// SortKey is the sortKey argument value.
 			SortKey string
 			// Cursor is the cursor argument value.
-			Cursor int64
+			Cursor *model.Cursor
 			// Limit is the limit argument value.
 			Limit int
 			// Fallback is the fallback argument value.
@@ -83,6 +185,22 @@ type CommentCacheMock struct {
 			// Comment is the comment argument value.
 			Comment *model.Comment
 		}
+		// SetPinnedIDs holds details about calls to the SetPinnedIDs method.
+		SetPinnedIDs []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+			// Ids is the ids argument value.
+			Ids []uuid.UUID
+		}
+		// UpdateComment holds details about calls to the UpdateComment method.
+		UpdateComment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Comment is the comment argument value.
+			Comment *model.Comment
+		}
 		// UpdateCommentScore holds details about calls to the UpdateCommentScore method.
 		UpdateCommentScore []struct {
 			// Ctx is the ctx argument value.
@@ -94,11 +212,145 @@ type CommentCacheMock struct {
 			// Delta is the delta argument value.
 			Delta int
 		}
+		// UpdateCommentScores holds details about calls to the UpdateCommentScores method.
+		UpdateCommentScores []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+			// Deltas is the deltas argument value.
+			Deltas map[string]int
+		}
 	}
-	lockGetCommentByID     sync.RWMutex
-	lockListComments       sync.RWMutex
-	lockSetComment         sync.RWMutex
-	lockUpdateCommentScore sync.RWMutex
+	lockDeleteComment       sync.RWMutex
+	lockFillPinnedIDs       sync.RWMutex
+	lockFillUnreadCount     sync.RWMutex
+	lockGetCommentByID      sync.RWMutex
+	lockGetPinnedIDs        sync.RWMutex
+	lockGetUnreadCount      sync.RWMutex
+	lockIncrUnreadCount     sync.RWMutex
+	lockListComments        sync.RWMutex
+	lockSetComment          sync.RWMutex
+	lockSetPinnedIDs        sync.RWMutex
+	lockUpdateComment       sync.RWMutex
+	lockUpdateCommentScore  sync.RWMutex
+	lockUpdateCommentScores sync.RWMutex
+}
+
+// DeleteComment calls DeleteCommentFunc.
+func (mock *CommentCacheMock) DeleteComment(ctx context.Context, comment *model.Comment) error {
+	if mock.DeleteCommentFunc == nil {
+		panic("CommentCacheMock.DeleteCommentFunc: method is nil but CommentCache.DeleteComment was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Comment *model.Comment
+	}{
+		Ctx:     ctx,
+		Comment: comment,
+	}
+	mock.lockDeleteComment.Lock()
+	mock.calls.DeleteComment = append(mock.calls.DeleteComment, callInfo)
+	mock.lockDeleteComment.Unlock()
+	return mock.DeleteCommentFunc(ctx, comment)
+}
+
+// DeleteCommentCalls gets all the calls that were made to DeleteComment.
+// Check the length with:
+//
+//	len(mockedCommentCache.DeleteCommentCalls())
+func (mock *CommentCacheMock) DeleteCommentCalls() []struct {
+	Ctx     context.Context
+	Comment *model.Comment
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Comment *model.Comment
+	}
+	mock.lockDeleteComment.RLock()
+	calls = mock.calls.DeleteComment
+	mock.lockDeleteComment.RUnlock()
+	return calls
+}
+
+// FillPinnedIDs calls FillPinnedIDsFunc.
+func (mock *CommentCacheMock) FillPinnedIDs(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error {
+	if mock.FillPinnedIDsFunc == nil {
+		panic("CommentCacheMock.FillPinnedIDsFunc: method is nil but CommentCache.FillPinnedIDs was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+		Ids      []uuid.UUID
+	}{
+		Ctx:      ctx,
+		ThreadID: threadID,
+		Ids:      ids,
+	}
+	mock.lockFillPinnedIDs.Lock()
+	mock.calls.FillPinnedIDs = append(mock.calls.FillPinnedIDs, callInfo)
+	mock.lockFillPinnedIDs.Unlock()
+	return mock.FillPinnedIDsFunc(ctx, threadID, ids)
+}
+
+// FillPinnedIDsCalls gets all the calls that were made to FillPinnedIDs.
+// Check the length with:
+//
+//	len(mockedCommentCache.FillPinnedIDsCalls())
+func (mock *CommentCacheMock) FillPinnedIDsCalls() []struct {
+	Ctx      context.Context
+	ThreadID uuid.UUID
+	Ids      []uuid.UUID
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+		Ids      []uuid.UUID
+	}
+	mock.lockFillPinnedIDs.RLock()
+	calls = mock.calls.FillPinnedIDs
+	mock.lockFillPinnedIDs.RUnlock()
+	return calls
+}
+
+// FillUnreadCount calls FillUnreadCountFunc.
+func (mock *CommentCacheMock) FillUnreadCount(ctx context.Context, userID string, count int) error {
+	if mock.FillUnreadCountFunc == nil {
+		panic("CommentCacheMock.FillUnreadCountFunc: method is nil but CommentCache.FillUnreadCount was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID string
+		Count  int
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		Count:  count,
+	}
+	mock.lockFillUnreadCount.Lock()
+	mock.calls.FillUnreadCount = append(mock.calls.FillUnreadCount, callInfo)
+	mock.lockFillUnreadCount.Unlock()
+	return mock.FillUnreadCountFunc(ctx, userID, count)
+}
+
+// FillUnreadCountCalls gets all the calls that were made to FillUnreadCount.
+// Check the length with:
+//
+//	len(mockedCommentCache.FillUnreadCountCalls())
+func (mock *CommentCacheMock) FillUnreadCountCalls() []struct {
+	Ctx    context.Context
+	UserID string
+	Count  int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID string
+		Count  int
+	}
+	mock.lockFillUnreadCount.RLock()
+	calls = mock.calls.FillUnreadCount
+	mock.lockFillUnreadCount.RUnlock()
+	return calls
 }
 
 // GetCommentByID calls GetCommentByIDFunc.
@@ -137,8 +389,120 @@ func (mock *CommentCacheMock) GetCommentByIDCalls() []struct {
 	return calls
 }
 
+// GetPinnedIDs calls GetPinnedIDsFunc.
+func (mock *CommentCacheMock) GetPinnedIDs(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error) {
+	if mock.GetPinnedIDsFunc == nil {
+		panic("CommentCacheMock.GetPinnedIDsFunc: method is nil but CommentCache.GetPinnedIDs was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+	}{
+		Ctx:      ctx,
+		ThreadID: threadID,
+	}
+	mock.lockGetPinnedIDs.Lock()
+	mock.calls.GetPinnedIDs = append(mock.calls.GetPinnedIDs, callInfo)
+	mock.lockGetPinnedIDs.Unlock()
+	return mock.GetPinnedIDsFunc(ctx, threadID)
+}
+
+// GetPinnedIDsCalls gets all the calls that were made to GetPinnedIDs.
+// Check the length with:
+//
+//	len(mockedCommentCache.GetPinnedIDsCalls())
+func (mock *CommentCacheMock) GetPinnedIDsCalls() []struct {
+	Ctx      context.Context
+	ThreadID uuid.UUID
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+	}
+	mock.lockGetPinnedIDs.RLock()
+	calls = mock.calls.GetPinnedIDs
+	mock.lockGetPinnedIDs.RUnlock()
+	return calls
+}
+
+// GetUnreadCount calls GetUnreadCountFunc.
+func (mock *CommentCacheMock) GetUnreadCount(ctx context.Context, userID string) (int, error) {
+	if mock.GetUnreadCountFunc == nil {
+		panic("CommentCacheMock.GetUnreadCountFunc: method is nil but CommentCache.GetUnreadCount was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID string
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+	}
+	mock.lockGetUnreadCount.Lock()
+	mock.calls.GetUnreadCount = append(mock.calls.GetUnreadCount, callInfo)
+	mock.lockGetUnreadCount.Unlock()
+	return mock.GetUnreadCountFunc(ctx, userID)
+}
+
+// GetUnreadCountCalls gets all the calls that were made to GetUnreadCount.
+// Check the length with:
+//
+//	len(mockedCommentCache.GetUnreadCountCalls())
+func (mock *CommentCacheMock) GetUnreadCountCalls() []struct {
+	Ctx    context.Context
+	UserID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID string
+	}
+	mock.lockGetUnreadCount.RLock()
+	calls = mock.calls.GetUnreadCount
+	mock.lockGetUnreadCount.RUnlock()
+	return calls
+}
+
+// IncrUnreadCount calls IncrUnreadCountFunc.
+func (mock *CommentCacheMock) IncrUnreadCount(ctx context.Context, userID string, delta int) error {
+	if mock.IncrUnreadCountFunc == nil {
+		panic("CommentCacheMock.IncrUnreadCountFunc: method is nil but CommentCache.IncrUnreadCount was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID string
+		Delta  int
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		Delta:  delta,
+	}
+	mock.lockIncrUnreadCount.Lock()
+	mock.calls.IncrUnreadCount = append(mock.calls.IncrUnreadCount, callInfo)
+	mock.lockIncrUnreadCount.Unlock()
+	return mock.IncrUnreadCountFunc(ctx, userID, delta)
+}
+
+// IncrUnreadCountCalls gets all the calls that were made to IncrUnreadCount.
+// Check the length with:
+//
+//	len(mockedCommentCache.IncrUnreadCountCalls())
+func (mock *CommentCacheMock) IncrUnreadCountCalls() []struct {
+	Ctx    context.Context
+	UserID string
+	Delta  int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID string
+		Delta  int
+	}
+	mock.lockIncrUnreadCount.RLock()
+	calls = mock.calls.IncrUnreadCount
+	mock.lockIncrUnreadCount.RUnlock()
+	return calls
+}
+
 // ListComments calls ListCommentsFunc.
-func (mock *CommentCacheMock) ListComments(ctx context.Context, threadID uuid.UUID, sortKey string, cursor int64, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
+func (mock *CommentCacheMock) ListComments(ctx context.Context, threadID uuid.UUID, sortKey string, cursor *model.Cursor, limit int, fallback model.QueryCommentsFunc) ([]model.Comment, error) {
 	if mock.ListCommentsFunc == nil {
 		panic("CommentCacheMock.ListCommentsFunc: method is nil but CommentCache.ListComments was just called")
 	}
@@ -146,7 +510,7 @@ func (mock *CommentCacheMock) ListComments(ctx context.Context, threadID uuid.UU
 		Ctx      context.Context
 		ThreadID uuid.UUID
 		SortKey  string
-		Cursor   int64
+		Cursor   *model.Cursor
 		Limit    int
 		Fallback model.QueryCommentsFunc
 	}{
@@ -171,7 +535,7 @@ func (mock *CommentCacheMock) ListCommentsCalls() []struct {
 	Ctx      context.Context
 	ThreadID uuid.UUID
 	SortKey  string
-	Cursor   int64
+	Cursor   *model.Cursor
 	Limit    int
 	Fallback model.QueryCommentsFunc
 } {
@@ -179,7 +543,7 @@ func (mock *CommentCacheMock) ListCommentsCalls() []struct {
 		Ctx      context.Context
 		ThreadID uuid.UUID
 		SortKey  string
-		Cursor   int64
+		Cursor   *model.Cursor
 		Limit    int
 		Fallback model.QueryCommentsFunc
 	}
@@ -225,6 +589,82 @@ func (mock *CommentCacheMock) SetCommentCalls() []struct {
 	return calls
 }
 
+// SetPinnedIDs calls SetPinnedIDsFunc.
+func (mock *CommentCacheMock) SetPinnedIDs(ctx context.Context, threadID uuid.UUID, ids []uuid.UUID) error {
+	if mock.SetPinnedIDsFunc == nil {
+		panic("CommentCacheMock.SetPinnedIDsFunc: method is nil but CommentCache.SetPinnedIDs was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+		Ids      []uuid.UUID
+	}{
+		Ctx:      ctx,
+		ThreadID: threadID,
+		Ids:      ids,
+	}
+	mock.lockSetPinnedIDs.Lock()
+	mock.calls.SetPinnedIDs = append(mock.calls.SetPinnedIDs, callInfo)
+	mock.lockSetPinnedIDs.Unlock()
+	return mock.SetPinnedIDsFunc(ctx, threadID, ids)
+}
+
+// SetPinnedIDsCalls gets all the calls that were made to SetPinnedIDs.
+// Check the length with:
+//
+//	len(mockedCommentCache.SetPinnedIDsCalls())
+func (mock *CommentCacheMock) SetPinnedIDsCalls() []struct {
+	Ctx      context.Context
+	ThreadID uuid.UUID
+	Ids      []uuid.UUID
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+		Ids      []uuid.UUID
+	}
+	mock.lockSetPinnedIDs.RLock()
+	calls = mock.calls.SetPinnedIDs
+	mock.lockSetPinnedIDs.RUnlock()
+	return calls
+}
+
+// UpdateComment calls UpdateCommentFunc.
+func (mock *CommentCacheMock) UpdateComment(ctx context.Context, comment *model.Comment) error {
+	if mock.UpdateCommentFunc == nil {
+		panic("CommentCacheMock.UpdateCommentFunc: method is nil but CommentCache.UpdateComment was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Comment *model.Comment
+	}{
+		Ctx:     ctx,
+		Comment: comment,
+	}
+	mock.lockUpdateComment.Lock()
+	mock.calls.UpdateComment = append(mock.calls.UpdateComment, callInfo)
+	mock.lockUpdateComment.Unlock()
+	return mock.UpdateCommentFunc(ctx, comment)
+}
+
+// UpdateCommentCalls gets all the calls that were made to UpdateComment.
+// Check the length with:
+//
+//	len(mockedCommentCache.UpdateCommentCalls())
+func (mock *CommentCacheMock) UpdateCommentCalls() []struct {
+	Ctx     context.Context
+	Comment *model.Comment
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Comment *model.Comment
+	}
+	mock.lockUpdateComment.RLock()
+	calls = mock.calls.UpdateComment
+	mock.lockUpdateComment.RUnlock()
+	return calls
+}
+
 // UpdateCommentScore calls UpdateCommentScoreFunc.
 func (mock *CommentCacheMock) UpdateCommentScore(ctx context.Context, commentID uuid.UUID, field string, delta int) error {
 	if mock.UpdateCommentScoreFunc == nil {
@@ -268,3 +708,43 @@ func (mock *CommentCacheMock) UpdateCommentScoreCalls() []struct {
 	mock.lockUpdateCommentScore.RUnlock()
 	return calls
 }
+
+// UpdateCommentScores calls UpdateCommentScoresFunc.
+func (mock *CommentCacheMock) UpdateCommentScores(ctx context.Context, commentID uuid.UUID, deltas map[string]int) error {
+	if mock.UpdateCommentScoresFunc == nil {
+		panic("CommentCacheMock.UpdateCommentScoresFunc: method is nil but CommentCache.UpdateCommentScores was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+		Deltas    map[string]int
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+		Deltas:    deltas,
+	}
+	mock.lockUpdateCommentScores.Lock()
+	mock.calls.UpdateCommentScores = append(mock.calls.UpdateCommentScores, callInfo)
+	mock.lockUpdateCommentScores.Unlock()
+	return mock.UpdateCommentScoresFunc(ctx, commentID, deltas)
+}
+
+// UpdateCommentScoresCalls gets all the calls that were made to UpdateCommentScores.
+// Check the length with:
+//
+//	len(mockedCommentCache.UpdateCommentScoresCalls())
+func (mock *CommentCacheMock) UpdateCommentScoresCalls() []struct {
+	Ctx       context.Context
+	CommentID uuid.UUID
+	Deltas    map[string]int
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+		Deltas    map[string]int
+	}
+	mock.lockUpdateCommentScores.RLock()
+	calls = mock.calls.UpdateCommentScores
+	mock.lockUpdateCommentScores.RUnlock()
+	return calls
+}
@@ -21,30 +21,93 @@ var _ service.CommentRepo = &CommentRepoMock{}
 //
 //		// make and configure a mocked service.CommentRepo
 //		mockedCommentRepo := &CommentRepoMock{
-//			AddReactionFunc: func(ctx context.Context, reaction *model.Reaction) (bool, error) {
-//				panic("mock out the AddReaction method")
+//			CountUnreadNotificationsFunc: func(ctx context.Context, userID string) (int, error) {
+//				panic("mock out the CountUnreadNotifications method")
 //			},
-//			CreateCommentFunc: func(ctx context.Context, comment *model.Comment) error {
+//			CreateCommentFunc: func(ctx context.Context, comment *model.Comment, reports []model.Report) error {
 //				panic("mock out the CreateComment method")
 //			},
-//			DecrementReactionCountFunc: func(ctx context.Context, commentID uuid.UUID, field string) error {
-//				panic("mock out the DecrementReactionCount method")
+//			CreateNotificationsFunc: func(ctx context.Context, notifications []model.Notification) error {
+//				panic("mock out the CreateNotifications method")
 //			},
-//			DeleteReactionFunc: func(ctx context.Context, commentID uuid.UUID, userID string, reactionType string) error {
-//				panic("mock out the DeleteReaction method")
+//			CreateReportFunc: func(ctx context.Context, report *model.Report) error {
+//				panic("mock out the CreateReport method")
+//			},
+//			CreateThreadFunc: func(ctx context.Context, thread *model.Thread) (bool, error) {
+//				panic("mock out the CreateThread method")
+//			},
+//			DeleteCommentFunc: func(ctx context.Context, commentID uuid.UUID) (*model.Comment, bool, error) {
+//				panic("mock out the DeleteComment method")
 //			},
 //			GetCommentByIDFunc: func(ctx context.Context, commentID uuid.UUID) (*model.Comment, error) {
 //				panic("mock out the GetCommentByID method")
 //			},
-//			IncrementReactionCountFunc: func(ctx context.Context, commentID uuid.UUID, field string) error {
-//				panic("mock out the IncrementReactionCount method")
+//			GetThreadFunc: func(ctx context.Context, threadID uuid.UUID) (*model.Thread, error) {
+//				panic("mock out the GetThread method")
 //			},
-//			IncrementReplyCountFunc: func(ctx context.Context, parentID uuid.UUID) error {
-//				panic("mock out the IncrementReplyCount method")
+//			GetThreadBySubjectFunc: func(ctx context.Context, subjectKey string) (*model.Thread, error) {
+//				panic("mock out the GetThreadBySubject method")
 //			},
-//			ListCommentsSortedFunc: func(ctx context.Context, threadID uuid.UUID, sortField string, cursor int64, limit int) ([]model.Comment, error) {
+//			ListCommentsSortedFunc: func(ctx context.Context, threadID uuid.UUID, sortField string, cursor *model.Cursor, limit int) ([]model.Comment, error) {
 //				panic("mock out the ListCommentsSorted method")
 //			},
+//			ListExistingUsersFunc: func(ctx context.Context, userIDs []string) (map[string]bool, error) {
+//				panic("mock out the ListExistingUsers method")
+//			},
+//			ListModerationActionsFunc: func(ctx context.Context, commentID uuid.UUID) ([]model.ModerationAction, error) {
+//				panic("mock out the ListModerationActions method")
+//			},
+//			ListModerationQueueFunc: func(ctx context.Context, limit int) ([]model.QueueItem, error) {
+//				panic("mock out the ListModerationQueue method")
+//			},
+//			ListNotificationsFunc: func(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error) {
+//				panic("mock out the ListNotifications method")
+//			},
+//			ListReactionsFunc: func(ctx context.Context, commentID uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error) {
+//				panic("mock out the ListReactions method")
+//			},
+//			ListRevisionsFunc: func(ctx context.Context, commentID uuid.UUID) ([]model.Revision, error) {
+//				panic("mock out the ListRevisions method")
+//			},
+//			ListThreadTreeFunc: func(ctx context.Context, threadID uuid.UUID, maxDepth int, limit int) ([]model.Comment, map[uuid.UUID]int, error) {
+//				panic("mock out the ListThreadTree method")
+//			},
+//			ListUserReactionsFunc: func(ctx context.Context, userID string, commentIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+//				panic("mock out the ListUserReactions method")
+//			},
+//			MarkNotificationsReadFunc: func(ctx context.Context, userID string, ids []uuid.UUID) (int, error) {
+//				panic("mock out the MarkNotificationsRead method")
+//			},
+//			ModerateCommentFunc: func(ctx context.Context, commentID uuid.UUID, moderatorID string, action string, note string) (*model.Comment, bool, error) {
+//				panic("mock out the ModerateComment method")
+//			},
+//			PinCommentFunc: func(ctx context.Context, threadID uuid.UUID, commentID uuid.UUID, maxPinned int) (*model.Thread, error) {
+//				panic("mock out the PinComment method")
+//			},
+//			RecordReactionFunc: func(ctx context.Context, reaction *model.Reaction, field string) (bool, error) {
+//				panic("mock out the RecordReaction method")
+//			},
+//			RecordVoteFunc: func(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+//				panic("mock out the RecordVote method")
+//			},
+//			SearchCommentsFunc: func(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error) {
+//				panic("mock out the SearchComments method")
+//			},
+//			ToggleReactionFunc: func(ctx context.Context, reaction *model.Reaction, field string) (bool, error) {
+//				panic("mock out the ToggleReaction method")
+//			},
+//			UnpinCommentFunc: func(ctx context.Context, threadID uuid.UUID, commentID uuid.UUID) (*model.Thread, error) {
+//				panic("mock out the UnpinComment method")
+//			},
+//			UpdateCommentFunc: func(ctx context.Context, commentID uuid.UUID, content string, contentHTML string, reports []model.Report) (*model.Comment, error) {
+//				panic("mock out the UpdateComment method")
+//			},
+//			UpdateThreadFunc: func(ctx context.Context, thread *model.Thread) error {
+//				panic("mock out the UpdateThread method")
+//			},
+//			VoteFunc: func(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+//				panic("mock out the Vote method")
+//			},
 //		}
 //
 //		// use mockedCommentRepo in code that requires service.CommentRepo
@@ -52,38 +115,101 @@ var _ service.CommentRepo = &CommentRepoMock{}
 //
 //	}
 type CommentRepoMock struct {
-	// AddReactionFunc mocks the AddReaction method.
-	AddReactionFunc func(ctx context.Context, reaction *model.Reaction) (bool, error)
+	// CountUnreadNotificationsFunc mocks the CountUnreadNotifications method.
+	CountUnreadNotificationsFunc func(ctx context.Context, userID string) (int, error)
 
 	// CreateCommentFunc mocks the CreateComment method.
-	CreateCommentFunc func(ctx context.Context, comment *model.Comment) error
+	CreateCommentFunc func(ctx context.Context, comment *model.Comment, reports []model.Report) error
+
+	// CreateNotificationsFunc mocks the CreateNotifications method.
+	CreateNotificationsFunc func(ctx context.Context, notifications []model.Notification) error
 
-	// DecrementReactionCountFunc mocks the DecrementReactionCount method.
-	DecrementReactionCountFunc func(ctx context.Context, commentID uuid.UUID, field string) error
+	// CreateReportFunc mocks the CreateReport method.
+	CreateReportFunc func(ctx context.Context, report *model.Report) error
 
-	// DeleteReactionFunc mocks the DeleteReaction method.
-	DeleteReactionFunc func(ctx context.Context, commentID uuid.UUID, userID string, reactionType string) error
+	// CreateThreadFunc mocks the CreateThread method.
+	CreateThreadFunc func(ctx context.Context, thread *model.Thread) (bool, error)
+
+	// DeleteCommentFunc mocks the DeleteComment method.
+	DeleteCommentFunc func(ctx context.Context, commentID uuid.UUID) (*model.Comment, bool, error)
 
 	// GetCommentByIDFunc mocks the GetCommentByID method.
 	GetCommentByIDFunc func(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
 
-	// IncrementReactionCountFunc mocks the IncrementReactionCount method.
-	IncrementReactionCountFunc func(ctx context.Context, commentID uuid.UUID, field string) error
+	// GetThreadFunc mocks the GetThread method.
+	GetThreadFunc func(ctx context.Context, threadID uuid.UUID) (*model.Thread, error)
 
-	// IncrementReplyCountFunc mocks the IncrementReplyCount method.
-	IncrementReplyCountFunc func(ctx context.Context, parentID uuid.UUID) error
+	// GetThreadBySubjectFunc mocks the GetThreadBySubject method.
+	GetThreadBySubjectFunc func(ctx context.Context, subjectKey string) (*model.Thread, error)
 
 	// ListCommentsSortedFunc mocks the ListCommentsSorted method.
-	ListCommentsSortedFunc func(ctx context.Context, threadID uuid.UUID, sortField string, cursor int64, limit int) ([]model.Comment, error)
+	ListCommentsSortedFunc func(ctx context.Context, threadID uuid.UUID, sortField string, cursor *model.Cursor, limit int) ([]model.Comment, error)
+
+	// ListExistingUsersFunc mocks the ListExistingUsers method.
+	ListExistingUsersFunc func(ctx context.Context, userIDs []string) (map[string]bool, error)
+
+	// ListModerationActionsFunc mocks the ListModerationActions method.
+	ListModerationActionsFunc func(ctx context.Context, commentID uuid.UUID) ([]model.ModerationAction, error)
+
+	// ListModerationQueueFunc mocks the ListModerationQueue method.
+	ListModerationQueueFunc func(ctx context.Context, limit int) ([]model.QueueItem, error)
+
+	// ListNotificationsFunc mocks the ListNotifications method.
+	ListNotificationsFunc func(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error)
+
+	// ListReactionsFunc mocks the ListReactions method.
+	ListReactionsFunc func(ctx context.Context, commentID uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error)
+
+	// ListRevisionsFunc mocks the ListRevisions method.
+	ListRevisionsFunc func(ctx context.Context, commentID uuid.UUID) ([]model.Revision, error)
+
+	// ListThreadTreeFunc mocks the ListThreadTree method.
+	ListThreadTreeFunc func(ctx context.Context, threadID uuid.UUID, maxDepth int, limit int) ([]model.Comment, map[uuid.UUID]int, error)
+
+	// ListUserReactionsFunc mocks the ListUserReactions method.
+	ListUserReactionsFunc func(ctx context.Context, userID string, commentIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+
+	// MarkNotificationsReadFunc mocks the MarkNotificationsRead method.
+	MarkNotificationsReadFunc func(ctx context.Context, userID string, ids []uuid.UUID) (int, error)
+
+	// ModerateCommentFunc mocks the ModerateComment method.
+	ModerateCommentFunc func(ctx context.Context, commentID uuid.UUID, moderatorID string, action string, note string) (*model.Comment, bool, error)
+
+	// PinCommentFunc mocks the PinComment method.
+	PinCommentFunc func(ctx context.Context, threadID uuid.UUID, commentID uuid.UUID, maxPinned int) (*model.Thread, error)
+
+	// RecordReactionFunc mocks the RecordReaction method.
+	RecordReactionFunc func(ctx context.Context, reaction *model.Reaction, field string) (bool, error)
+
+	// RecordVoteFunc mocks the RecordVote method.
+	RecordVoteFunc func(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error)
+
+	// SearchCommentsFunc mocks the SearchComments method.
+	SearchCommentsFunc func(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error)
+
+	// ToggleReactionFunc mocks the ToggleReaction method.
+	ToggleReactionFunc func(ctx context.Context, reaction *model.Reaction, field string) (bool, error)
+
+	// UnpinCommentFunc mocks the UnpinComment method.
+	UnpinCommentFunc func(ctx context.Context, threadID uuid.UUID, commentID uuid.UUID) (*model.Thread, error)
+
+	// UpdateCommentFunc mocks the UpdateComment method.
+	UpdateCommentFunc func(ctx context.Context, commentID uuid.UUID, content string, contentHTML string, reports []model.Report) (*model.Comment, error)
+
+	// UpdateThreadFunc mocks the UpdateThread method.
+	UpdateThreadFunc func(ctx context.Context, thread *model.Thread) error
+
+	// VoteFunc mocks the Vote method.
+	VoteFunc func(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
-		// AddReaction holds details about calls to the AddReaction method.
-		AddReaction []struct {
+		// CountUnreadNotifications holds details about calls to the CountUnreadNotifications method.
+		CountUnreadNotifications []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// Reaction is the reaction argument value.
-			Reaction *model.Reaction
+			// UserID is the userID argument value.
+			UserID string
 		}
 		// CreateComment holds details about calls to the CreateComment method.
 		CreateComment []struct {
@@ -91,126 +217,348 @@ type CommentRepoMock struct {
 			Ctx context.Context
 			// Comment is the comment argument value.
 			Comment *model.Comment
+			// Reports is the reports argument value.
+			Reports []model.Report
+		}
+		// CreateNotifications holds details about calls to the CreateNotifications method.
+		CreateNotifications []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Notifications is the notifications argument value.
+			Notifications []model.Notification
+		}
+		// CreateReport holds details about calls to the CreateReport method.
+		CreateReport []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Report is the report argument value.
+			Report *model.Report
 		}
-		// DecrementReactionCount holds details about calls to the DecrementReactionCount method.
-		DecrementReactionCount []struct {
+		// CreateThread holds details about calls to the CreateThread method.
+		CreateThread []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Thread is the thread argument value.
+			Thread *model.Thread
+		}
+		// DeleteComment holds details about calls to the DeleteComment method.
+		DeleteComment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+		}
+		// GetCommentByID holds details about calls to the GetCommentByID method.
+		GetCommentByID []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// CommentID is the commentID argument value.
 			CommentID uuid.UUID
-			// Field is the field argument value.
-			Field string
 		}
-		// DeleteReaction holds details about calls to the DeleteReaction method.
-		DeleteReaction []struct {
+		// GetThread holds details about calls to the GetThread method.
+		GetThread []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+		}
+		// GetThreadBySubject holds details about calls to the GetThreadBySubject method.
+		GetThreadBySubject []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// SubjectKey is the subjectKey argument value.
+			SubjectKey string
+		}
+		// ListCommentsSorted holds details about calls to the ListCommentsSorted method.
+		ListCommentsSorted []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+			// SortField is the sortField argument value.
+			SortField string
+			// Cursor is the cursor argument value.
+			Cursor *model.Cursor
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListExistingUsers holds details about calls to the ListExistingUsers method.
+		ListExistingUsers []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserIDs is the userIDs argument value.
+			UserIDs []string
+		}
+		// ListModerationActions holds details about calls to the ListModerationActions method.
+		ListModerationActions []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// CommentID is the commentID argument value.
 			CommentID uuid.UUID
+		}
+		// ListModerationQueue holds details about calls to the ListModerationQueue method.
+		ListModerationQueue []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListNotifications holds details about calls to the ListNotifications method.
+		ListNotifications []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
 			// UserID is the userID argument value.
 			UserID string
+			// Cursor is the cursor argument value.
+			Cursor *model.Cursor
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListReactions holds details about calls to the ListReactions method.
+		ListReactions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
 			// ReactionType is the reactionType argument value.
 			ReactionType string
+			// Cursor is the cursor argument value.
+			Cursor *model.Cursor
+			// Limit is the limit argument value.
+			Limit int
 		}
-		// GetCommentByID holds details about calls to the GetCommentByID method.
-		GetCommentByID []struct {
+		// ListRevisions holds details about calls to the ListRevisions method.
+		ListRevisions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+		}
+		// ListThreadTree holds details about calls to the ListThreadTree method.
+		ListThreadTree []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+			// MaxDepth is the maxDepth argument value.
+			MaxDepth int
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListUserReactions holds details about calls to the ListUserReactions method.
+		ListUserReactions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID string
+			// CommentIDs is the commentIDs argument value.
+			CommentIDs []uuid.UUID
+		}
+		// MarkNotificationsRead holds details about calls to the MarkNotificationsRead method.
+		MarkNotificationsRead []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID string
+			// Ids is the ids argument value.
+			Ids []uuid.UUID
+		}
+		// ModerateComment holds details about calls to the ModerateComment method.
+		ModerateComment []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// CommentID is the commentID argument value.
 			CommentID uuid.UUID
+			// ModeratorID is the moderatorID argument value.
+			ModeratorID string
+			// Action is the action argument value.
+			Action string
+			// Note is the note argument value.
+			Note string
 		}
-		// IncrementReactionCount holds details about calls to the IncrementReactionCount method.
-		IncrementReactionCount []struct {
+		// PinComment holds details about calls to the PinComment method.
+		PinComment []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
 			// CommentID is the commentID argument value.
 			CommentID uuid.UUID
+			// MaxPinned is the maxPinned argument value.
+			MaxPinned int
+		}
+		// RecordReaction holds details about calls to the RecordReaction method.
+		RecordReaction []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Reaction is the reaction argument value.
+			Reaction *model.Reaction
 			// Field is the field argument value.
 			Field string
 		}
-		// IncrementReplyCount holds details about calls to the IncrementReplyCount method.
-		IncrementReplyCount []struct {
+		// RecordVote holds details about calls to the RecordVote method.
+		RecordVote []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// ParentID is the parentID argument value.
-			ParentID uuid.UUID
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+			// UserID is the userID argument value.
+			UserID string
+			// Value is the value argument value.
+			Value int
+			// Toggle is the toggle argument value.
+			Toggle bool
 		}
-		// ListCommentsSorted holds details about calls to the ListCommentsSorted method.
-		ListCommentsSorted []struct {
+		// SearchComments holds details about calls to the SearchComments method.
+		SearchComments []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
+			// Query is the query argument value.
+			Query string
 			// ThreadID is the threadID argument value.
-			ThreadID uuid.UUID
-			// SortField is the sortField argument value.
-			SortField string
+			ThreadID *uuid.UUID
 			// Cursor is the cursor argument value.
-			Cursor int64
+			Cursor *model.Cursor
 			// Limit is the limit argument value.
 			Limit int
 		}
+		// ToggleReaction holds details about calls to the ToggleReaction method.
+		ToggleReaction []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Reaction is the reaction argument value.
+			Reaction *model.Reaction
+			// Field is the field argument value.
+			Field string
+		}
+		// UnpinComment holds details about calls to the UnpinComment method.
+		UnpinComment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ThreadID is the threadID argument value.
+			ThreadID uuid.UUID
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+		}
+		// UpdateComment holds details about calls to the UpdateComment method.
+		UpdateComment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+			// Content is the content argument value.
+			Content string
+			// ContentHTML is the contentHTML argument value.
+			ContentHTML string
+			// Reports is the reports argument value.
+			Reports []model.Report
+		}
+		// UpdateThread holds details about calls to the UpdateThread method.
+		UpdateThread []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Thread is the thread argument value.
+			Thread *model.Thread
+		}
+		// Vote holds details about calls to the Vote method.
+		Vote []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID uuid.UUID
+			// UserID is the userID argument value.
+			UserID string
+			// Value is the value argument value.
+			Value int
+			// Toggle is the toggle argument value.
+			Toggle bool
+		}
 	}
-	lockAddReaction            sync.RWMutex
-	lockCreateComment          sync.RWMutex
-	lockDecrementReactionCount sync.RWMutex
-	lockDeleteReaction         sync.RWMutex
-	lockGetCommentByID         sync.RWMutex
-	lockIncrementReactionCount sync.RWMutex
-	lockIncrementReplyCount    sync.RWMutex
-	lockListCommentsSorted     sync.RWMutex
+	lockCountUnreadNotifications sync.RWMutex
+	lockCreateComment            sync.RWMutex
+	lockCreateNotifications      sync.RWMutex
+	lockCreateReport             sync.RWMutex
+	lockCreateThread             sync.RWMutex
+	lockDeleteComment            sync.RWMutex
+	lockGetCommentByID           sync.RWMutex
+	lockGetThread                sync.RWMutex
+	lockGetThreadBySubject       sync.RWMutex
+	lockListCommentsSorted       sync.RWMutex
+	lockListExistingUsers        sync.RWMutex
+	lockListModerationActions    sync.RWMutex
+	lockListModerationQueue      sync.RWMutex
+	lockListNotifications        sync.RWMutex
+	lockListReactions            sync.RWMutex
+	lockListRevisions            sync.RWMutex
+	lockListThreadTree           sync.RWMutex
+	lockListUserReactions        sync.RWMutex
+	lockMarkNotificationsRead    sync.RWMutex
+	lockModerateComment          sync.RWMutex
+	lockPinComment               sync.RWMutex
+	lockRecordReaction           sync.RWMutex
+	lockRecordVote               sync.RWMutex
+	lockSearchComments           sync.RWMutex
+	lockToggleReaction           sync.RWMutex
+	lockUnpinComment             sync.RWMutex
+	lockUpdateComment            sync.RWMutex
+	lockUpdateThread             sync.RWMutex
+	lockVote                     sync.RWMutex
 }
 
-// AddReaction calls AddReactionFunc.
-func (mock *CommentRepoMock) AddReaction(ctx context.Context, reaction *model.Reaction) (bool, error) {
-	if mock.AddReactionFunc == nil {
-		panic("CommentRepoMock.AddReactionFunc: method is nil but CommentRepo.AddReaction was just called")
+// CountUnreadNotifications calls CountUnreadNotificationsFunc.
+func (mock *CommentRepoMock) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	if mock.CountUnreadNotificationsFunc == nil {
+		panic("CommentRepoMock.CountUnreadNotificationsFunc: method is nil but CommentRepo.CountUnreadNotifications was just called")
 	}
 	callInfo := struct {
-		Ctx      context.Context
-		Reaction *model.Reaction
+		Ctx    context.Context
+		UserID string
 	}{
-		Ctx:      ctx,
-		Reaction: reaction,
+		Ctx:    ctx,
+		UserID: userID,
 	}
-	mock.lockAddReaction.Lock()
-	mock.calls.AddReaction = append(mock.calls.AddReaction, callInfo)
-	mock.lockAddReaction.Unlock()
-	return mock.AddReactionFunc(ctx, reaction)
+	mock.lockCountUnreadNotifications.Lock()
+	mock.calls.CountUnreadNotifications = append(mock.calls.CountUnreadNotifications, callInfo)
+	mock.lockCountUnreadNotifications.Unlock()
+	return mock.CountUnreadNotificationsFunc(ctx, userID)
 }
 
-// AddReactionCalls gets all the calls that were made to AddReaction.
+// CountUnreadNotificationsCalls gets all the calls that were made to CountUnreadNotifications.
 // Check the length with:
 //
-//	len(mockedCommentRepo.AddReactionCalls())
-func (mock *CommentRepoMock) AddReactionCalls() []struct {
-	Ctx      context.Context
-	Reaction *model.Reaction
+//	len(mockedCommentRepo.CountUnreadNotificationsCalls())
+func (mock *CommentRepoMock) CountUnreadNotificationsCalls() []struct {
+	Ctx    context.Context
+	UserID string
 } {
 	var calls []struct {
-		Ctx      context.Context
-		Reaction *model.Reaction
+		Ctx    context.Context
+		UserID string
 	}
-	mock.lockAddReaction.RLock()
-	calls = mock.calls.AddReaction
-	mock.lockAddReaction.RUnlock()
+	mock.lockCountUnreadNotifications.RLock()
+	calls = mock.calls.CountUnreadNotifications
+	mock.lockCountUnreadNotifications.RUnlock()
 	return calls
 }
 
 // CreateComment calls CreateCommentFunc.
-func (mock *CommentRepoMock) CreateComment(ctx context.Context, comment *model.Comment) error {
+func (mock *CommentRepoMock) CreateComment(ctx context.Context, comment *model.Comment, reports []model.Report) error {
 	if mock.CreateCommentFunc == nil {
 		panic("CommentRepoMock.CreateCommentFunc: method is nil but CommentRepo.CreateComment was just called")
 	}
 	callInfo := struct {
 		Ctx     context.Context
 		Comment *model.Comment
+		Reports []model.Report
 	}{
 		Ctx:     ctx,
 		Comment: comment,
+		Reports: reports,
 	}
 	mock.lockCreateComment.Lock()
 	mock.calls.CreateComment = append(mock.calls.CreateComment, callInfo)
 	mock.lockCreateComment.Unlock()
-	return mock.CreateCommentFunc(ctx, comment)
+	return mock.CreateCommentFunc(ctx, comment, reports)
 }
 
 // CreateCommentCalls gets all the calls that were made to CreateComment.
@@ -220,10 +568,12 @@ func (mock *CommentRepoMock) CreateComment(ctx context.Context, comment *model.C
 func (mock *CommentRepoMock) CreateCommentCalls() []struct {
 	Ctx     context.Context
 	Comment *model.Comment
+	Reports []model.Report
 } {
 	var calls []struct {
 		Ctx     context.Context
 		Comment *model.Comment
+		Reports []model.Report
 	}
 	mock.lockCreateComment.RLock()
 	calls = mock.calls.CreateComment
@@ -231,87 +581,147 @@ func (mock *CommentRepoMock) CreateCommentCalls() []struct {
 	return calls
 }
 
-// DecrementReactionCount calls DecrementReactionCountFunc.
-func (mock *CommentRepoMock) DecrementReactionCount(ctx context.Context, commentID uuid.UUID, field string) error {
-	if mock.DecrementReactionCountFunc == nil {
-		panic("CommentRepoMock.DecrementReactionCountFunc: method is nil but CommentRepo.DecrementReactionCount was just called")
+// CreateNotifications calls CreateNotificationsFunc.
+func (mock *CommentRepoMock) CreateNotifications(ctx context.Context, notifications []model.Notification) error {
+	if mock.CreateNotificationsFunc == nil {
+		panic("CommentRepoMock.CreateNotificationsFunc: method is nil but CommentRepo.CreateNotifications was just called")
 	}
 	callInfo := struct {
-		Ctx       context.Context
-		CommentID uuid.UUID
-		Field     string
+		Ctx           context.Context
+		Notifications []model.Notification
 	}{
-		Ctx:       ctx,
-		CommentID: commentID,
-		Field:     field,
+		Ctx:           ctx,
+		Notifications: notifications,
 	}
-	mock.lockDecrementReactionCount.Lock()
-	mock.calls.DecrementReactionCount = append(mock.calls.DecrementReactionCount, callInfo)
-	mock.lockDecrementReactionCount.Unlock()
-	return mock.DecrementReactionCountFunc(ctx, commentID, field)
+	mock.lockCreateNotifications.Lock()
+	mock.calls.CreateNotifications = append(mock.calls.CreateNotifications, callInfo)
+	mock.lockCreateNotifications.Unlock()
+	return mock.CreateNotificationsFunc(ctx, notifications)
 }
 
-// DecrementReactionCountCalls gets all the calls that were made to DecrementReactionCount.
+// CreateNotificationsCalls gets all the calls that were made to CreateNotifications.
 // Check the length with:
 //
-//	len(mockedCommentRepo.DecrementReactionCountCalls())
-func (mock *CommentRepoMock) DecrementReactionCountCalls() []struct {
-	Ctx       context.Context
-	CommentID uuid.UUID
-	Field     string
+//	len(mockedCommentRepo.CreateNotificationsCalls())
+func (mock *CommentRepoMock) CreateNotificationsCalls() []struct {
+	Ctx           context.Context
+	Notifications []model.Notification
 } {
 	var calls []struct {
-		Ctx       context.Context
-		CommentID uuid.UUID
-		Field     string
+		Ctx           context.Context
+		Notifications []model.Notification
 	}
-	mock.lockDecrementReactionCount.RLock()
-	calls = mock.calls.DecrementReactionCount
-	mock.lockDecrementReactionCount.RUnlock()
+	mock.lockCreateNotifications.RLock()
+	calls = mock.calls.CreateNotifications
+	mock.lockCreateNotifications.RUnlock()
 	return calls
 }
 
-// DeleteReaction calls DeleteReactionFunc.
-func (mock *CommentRepoMock) DeleteReaction(ctx context.Context, commentID uuid.UUID, userID string, reactionType string) error {
-	if mock.DeleteReactionFunc == nil {
-		panic("CommentRepoMock.DeleteReactionFunc: method is nil but CommentRepo.DeleteReaction was just called")
+// CreateReport calls CreateReportFunc.
+func (mock *CommentRepoMock) CreateReport(ctx context.Context, report *model.Report) error {
+	if mock.CreateReportFunc == nil {
+		panic("CommentRepoMock.CreateReportFunc: method is nil but CommentRepo.CreateReport was just called")
 	}
 	callInfo := struct {
-		Ctx          context.Context
-		CommentID    uuid.UUID
-		UserID       string
-		ReactionType string
+		Ctx    context.Context
+		Report *model.Report
 	}{
-		Ctx:          ctx,
-		CommentID:    commentID,
-		UserID:       userID,
-		ReactionType: reactionType,
+		Ctx:    ctx,
+		Report: report,
 	}
-	mock.lockDeleteReaction.Lock()
-	mock.calls.DeleteReaction = append(mock.calls.DeleteReaction, callInfo)
-	mock.lockDeleteReaction.Unlock()
-	return mock.DeleteReactionFunc(ctx, commentID, userID, reactionType)
+	mock.lockCreateReport.Lock()
+	mock.calls.CreateReport = append(mock.calls.CreateReport, callInfo)
+	mock.lockCreateReport.Unlock()
+	return mock.CreateReportFunc(ctx, report)
 }
 
-// DeleteReactionCalls gets all the calls that were made to DeleteReaction.
+// CreateReportCalls gets all the calls that were made to CreateReport.
 // Check the length with:
 //
-//	len(mockedCommentRepo.DeleteReactionCalls())
-func (mock *CommentRepoMock) DeleteReactionCalls() []struct {
-	Ctx          context.Context
-	CommentID    uuid.UUID
-	UserID       string
-	ReactionType string
+//	len(mockedCommentRepo.CreateReportCalls())
+func (mock *CommentRepoMock) CreateReportCalls() []struct {
+	Ctx    context.Context
+	Report *model.Report
 } {
 	var calls []struct {
-		Ctx          context.Context
-		CommentID    uuid.UUID
-		UserID       string
-		ReactionType string
+		Ctx    context.Context
+		Report *model.Report
+	}
+	mock.lockCreateReport.RLock()
+	calls = mock.calls.CreateReport
+	mock.lockCreateReport.RUnlock()
+	return calls
+}
+
+// CreateThread calls CreateThreadFunc.
+func (mock *CommentRepoMock) CreateThread(ctx context.Context, thread *model.Thread) (bool, error) {
+	if mock.CreateThreadFunc == nil {
+		panic("CommentRepoMock.CreateThreadFunc: method is nil but CommentRepo.CreateThread was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Thread *model.Thread
+	}{
+		Ctx:    ctx,
+		Thread: thread,
+	}
+	mock.lockCreateThread.Lock()
+	mock.calls.CreateThread = append(mock.calls.CreateThread, callInfo)
+	mock.lockCreateThread.Unlock()
+	return mock.CreateThreadFunc(ctx, thread)
+}
+
+// CreateThreadCalls gets all the calls that were made to CreateThread.
+// Check the length with:
+//
+//	len(mockedCommentRepo.CreateThreadCalls())
+func (mock *CommentRepoMock) CreateThreadCalls() []struct {
+	Ctx    context.Context
+	Thread *model.Thread
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Thread *model.Thread
+	}
+	mock.lockCreateThread.RLock()
+	calls = mock.calls.CreateThread
+	mock.lockCreateThread.RUnlock()
+	return calls
+}
+
+// DeleteComment calls DeleteCommentFunc.
+func (mock *CommentRepoMock) DeleteComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, bool, error) {
+	if mock.DeleteCommentFunc == nil {
+		panic("CommentRepoMock.DeleteCommentFunc: method is nil but CommentRepo.DeleteComment was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+	}
+	mock.lockDeleteComment.Lock()
+	mock.calls.DeleteComment = append(mock.calls.DeleteComment, callInfo)
+	mock.lockDeleteComment.Unlock()
+	return mock.DeleteCommentFunc(ctx, commentID)
+}
+
+// DeleteCommentCalls gets all the calls that were made to DeleteComment.
+// Check the length with:
+//
+//	len(mockedCommentRepo.DeleteCommentCalls())
+func (mock *CommentRepoMock) DeleteCommentCalls() []struct {
+	Ctx       context.Context
+	CommentID uuid.UUID
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
 	}
-	mock.lockDeleteReaction.RLock()
-	calls = mock.calls.DeleteReaction
-	mock.lockDeleteReaction.RUnlock()
+	mock.lockDeleteComment.RLock()
+	calls = mock.calls.DeleteComment
+	mock.lockDeleteComment.RUnlock()
 	return calls
 }
 
@@ -351,84 +761,80 @@ func (mock *CommentRepoMock) GetCommentByIDCalls() []struct {
 	return calls
 }
 
-// IncrementReactionCount calls IncrementReactionCountFunc.
-func (mock *CommentRepoMock) IncrementReactionCount(ctx context.Context, commentID uuid.UUID, field string) error {
-	if mock.IncrementReactionCountFunc == nil {
-		panic("CommentRepoMock.IncrementReactionCountFunc: method is nil but CommentRepo.IncrementReactionCount was just called")
+// GetThread calls GetThreadFunc.
+func (mock *CommentRepoMock) GetThread(ctx context.Context, threadID uuid.UUID) (*model.Thread, error) {
+	if mock.GetThreadFunc == nil {
+		panic("CommentRepoMock.GetThreadFunc: method is nil but CommentRepo.GetThread was just called")
 	}
 	callInfo := struct {
-		Ctx       context.Context
-		CommentID uuid.UUID
-		Field     string
+		Ctx      context.Context
+		ThreadID uuid.UUID
 	}{
-		Ctx:       ctx,
-		CommentID: commentID,
-		Field:     field,
+		Ctx:      ctx,
+		ThreadID: threadID,
 	}
-	mock.lockIncrementReactionCount.Lock()
-	mock.calls.IncrementReactionCount = append(mock.calls.IncrementReactionCount, callInfo)
-	mock.lockIncrementReactionCount.Unlock()
-	return mock.IncrementReactionCountFunc(ctx, commentID, field)
+	mock.lockGetThread.Lock()
+	mock.calls.GetThread = append(mock.calls.GetThread, callInfo)
+	mock.lockGetThread.Unlock()
+	return mock.GetThreadFunc(ctx, threadID)
 }
 
-// IncrementReactionCountCalls gets all the calls that were made to IncrementReactionCount.
+// GetThreadCalls gets all the calls that were made to GetThread.
 // Check the length with:
 //
-//	len(mockedCommentRepo.IncrementReactionCountCalls())
-func (mock *CommentRepoMock) IncrementReactionCountCalls() []struct {
-	Ctx       context.Context
-	CommentID uuid.UUID
-	Field     string
+//	len(mockedCommentRepo.GetThreadCalls())
+func (mock *CommentRepoMock) GetThreadCalls() []struct {
+	Ctx      context.Context
+	ThreadID uuid.UUID
 } {
 	var calls []struct {
-		Ctx       context.Context
-		CommentID uuid.UUID
-		Field     string
+		Ctx      context.Context
+		ThreadID uuid.UUID
 	}
-	mock.lockIncrementReactionCount.RLock()
-	calls = mock.calls.IncrementReactionCount
-	mock.lockIncrementReactionCount.RUnlock()
+	mock.lockGetThread.RLock()
+	calls = mock.calls.GetThread
+	mock.lockGetThread.RUnlock()
 	return calls
 }
 
-// IncrementReplyCount calls IncrementReplyCountFunc.
-func (mock *CommentRepoMock) IncrementReplyCount(ctx context.Context, parentID uuid.UUID) error {
-	if mock.IncrementReplyCountFunc == nil {
-		panic("CommentRepoMock.IncrementReplyCountFunc: method is nil but CommentRepo.IncrementReplyCount was just called")
+// GetThreadBySubject calls GetThreadBySubjectFunc.
+func (mock *CommentRepoMock) GetThreadBySubject(ctx context.Context, subjectKey string) (*model.Thread, error) {
+	if mock.GetThreadBySubjectFunc == nil {
+		panic("CommentRepoMock.GetThreadBySubjectFunc: method is nil but CommentRepo.GetThreadBySubject was just called")
 	}
 	callInfo := struct {
-		Ctx      context.Context
-		ParentID uuid.UUID
+		Ctx        context.Context
+		SubjectKey string
 	}{
-		Ctx:      ctx,
-		ParentID: parentID,
+		Ctx:        ctx,
+		SubjectKey: subjectKey,
 	}
-	mock.lockIncrementReplyCount.Lock()
-	mock.calls.IncrementReplyCount = append(mock.calls.IncrementReplyCount, callInfo)
-	mock.lockIncrementReplyCount.Unlock()
-	return mock.IncrementReplyCountFunc(ctx, parentID)
+	mock.lockGetThreadBySubject.Lock()
+	mock.calls.GetThreadBySubject = append(mock.calls.GetThreadBySubject, callInfo)
+	mock.lockGetThreadBySubject.Unlock()
+	return mock.GetThreadBySubjectFunc(ctx, subjectKey)
 }
 
-// IncrementReplyCountCalls gets all the calls that were made to IncrementReplyCount.
+// GetThreadBySubjectCalls gets all the calls that were made to GetThreadBySubject.
 // Check the length with:
 //
-//	len(mockedCommentRepo.IncrementReplyCountCalls())
-func (mock *CommentRepoMock) IncrementReplyCountCalls() []struct {
-	Ctx      context.Context
-	ParentID uuid.UUID
+//	len(mockedCommentRepo.GetThreadBySubjectCalls())
+func (mock *CommentRepoMock) GetThreadBySubjectCalls() []struct {
+	Ctx        context.Context
+	SubjectKey string
 } {
 	var calls []struct {
-		Ctx      context.Context
-		ParentID uuid.UUID
+		Ctx        context.Context
+		SubjectKey string
 	}
-	mock.lockIncrementReplyCount.RLock()
-	calls = mock.calls.IncrementReplyCount
-	mock.lockIncrementReplyCount.RUnlock()
+	mock.lockGetThreadBySubject.RLock()
+	calls = mock.calls.GetThreadBySubject
+	mock.lockGetThreadBySubject.RUnlock()
 	return calls
 }
 
 // ListCommentsSorted calls ListCommentsSortedFunc.
-func (mock *CommentRepoMock) ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor int64, limit int) ([]model.Comment, error) {
+func (mock *CommentRepoMock) ListCommentsSorted(ctx context.Context, threadID uuid.UUID, sortField string, cursor *model.Cursor, limit int) ([]model.Comment, error) {
 	if mock.ListCommentsSortedFunc == nil {
 		panic("CommentRepoMock.ListCommentsSortedFunc: method is nil but CommentRepo.ListCommentsSorted was just called")
 	}
@@ -436,7 +842,7 @@ func (mock *CommentRepoMock) ListCommentsSorted(ctx context.Context, threadID uu
 		Ctx       context.Context
 		ThreadID  uuid.UUID
 		SortField string
-		Cursor    int64
+		Cursor    *model.Cursor
 		Limit     int
 	}{
 		Ctx:       ctx,
@@ -459,14 +865,14 @@ func (mock *CommentRepoMock) ListCommentsSortedCalls() []struct {
 	Ctx       context.Context
 	ThreadID  uuid.UUID
 	SortField string
-	Cursor    int64
+	Cursor    *model.Cursor
 	Limit     int
 } {
 	var calls []struct {
 		Ctx       context.Context
 		ThreadID  uuid.UUID
 		SortField string
-		Cursor    int64
+		Cursor    *model.Cursor
 		Limit     int
 	}
 	mock.lockListCommentsSorted.RLock()
@@ -474,3 +880,803 @@ func (mock *CommentRepoMock) ListCommentsSortedCalls() []struct {
 	mock.lockListCommentsSorted.RUnlock()
 	return calls
 }
+
+// ListExistingUsers calls ListExistingUsersFunc.
+func (mock *CommentRepoMock) ListExistingUsers(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	if mock.ListExistingUsersFunc == nil {
+		panic("CommentRepoMock.ListExistingUsersFunc: method is nil but CommentRepo.ListExistingUsers was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		UserIDs []string
+	}{
+		Ctx:     ctx,
+		UserIDs: userIDs,
+	}
+	mock.lockListExistingUsers.Lock()
+	mock.calls.ListExistingUsers = append(mock.calls.ListExistingUsers, callInfo)
+	mock.lockListExistingUsers.Unlock()
+	return mock.ListExistingUsersFunc(ctx, userIDs)
+}
+
+// ListExistingUsersCalls gets all the calls that were made to ListExistingUsers.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListExistingUsersCalls())
+func (mock *CommentRepoMock) ListExistingUsersCalls() []struct {
+	Ctx     context.Context
+	UserIDs []string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		UserIDs []string
+	}
+	mock.lockListExistingUsers.RLock()
+	calls = mock.calls.ListExistingUsers
+	mock.lockListExistingUsers.RUnlock()
+	return calls
+}
+
+// ListModerationActions calls ListModerationActionsFunc.
+func (mock *CommentRepoMock) ListModerationActions(ctx context.Context, commentID uuid.UUID) ([]model.ModerationAction, error) {
+	if mock.ListModerationActionsFunc == nil {
+		panic("CommentRepoMock.ListModerationActionsFunc: method is nil but CommentRepo.ListModerationActions was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+	}
+	mock.lockListModerationActions.Lock()
+	mock.calls.ListModerationActions = append(mock.calls.ListModerationActions, callInfo)
+	mock.lockListModerationActions.Unlock()
+	return mock.ListModerationActionsFunc(ctx, commentID)
+}
+
+// ListModerationActionsCalls gets all the calls that were made to ListModerationActions.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListModerationActionsCalls())
+func (mock *CommentRepoMock) ListModerationActionsCalls() []struct {
+	Ctx       context.Context
+	CommentID uuid.UUID
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+	}
+	mock.lockListModerationActions.RLock()
+	calls = mock.calls.ListModerationActions
+	mock.lockListModerationActions.RUnlock()
+	return calls
+}
+
+// ListModerationQueue calls ListModerationQueueFunc.
+func (mock *CommentRepoMock) ListModerationQueue(ctx context.Context, limit int) ([]model.QueueItem, error) {
+	if mock.ListModerationQueueFunc == nil {
+		panic("CommentRepoMock.ListModerationQueueFunc: method is nil but CommentRepo.ListModerationQueue was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Limit int
+	}{
+		Ctx:   ctx,
+		Limit: limit,
+	}
+	mock.lockListModerationQueue.Lock()
+	mock.calls.ListModerationQueue = append(mock.calls.ListModerationQueue, callInfo)
+	mock.lockListModerationQueue.Unlock()
+	return mock.ListModerationQueueFunc(ctx, limit)
+}
+
+// ListModerationQueueCalls gets all the calls that were made to ListModerationQueue.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListModerationQueueCalls())
+func (mock *CommentRepoMock) ListModerationQueueCalls() []struct {
+	Ctx   context.Context
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Limit int
+	}
+	mock.lockListModerationQueue.RLock()
+	calls = mock.calls.ListModerationQueue
+	mock.lockListModerationQueue.RUnlock()
+	return calls
+}
+
+// ListNotifications calls ListNotificationsFunc.
+func (mock *CommentRepoMock) ListNotifications(ctx context.Context, userID string, cursor *model.Cursor, limit int) ([]model.Notification, error) {
+	if mock.ListNotificationsFunc == nil {
+		panic("CommentRepoMock.ListNotificationsFunc: method is nil but CommentRepo.ListNotifications was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID string
+		Cursor *model.Cursor
+		Limit  int
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		Cursor: cursor,
+		Limit:  limit,
+	}
+	mock.lockListNotifications.Lock()
+	mock.calls.ListNotifications = append(mock.calls.ListNotifications, callInfo)
+	mock.lockListNotifications.Unlock()
+	return mock.ListNotificationsFunc(ctx, userID, cursor, limit)
+}
+
+// ListNotificationsCalls gets all the calls that were made to ListNotifications.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListNotificationsCalls())
+func (mock *CommentRepoMock) ListNotificationsCalls() []struct {
+	Ctx    context.Context
+	UserID string
+	Cursor *model.Cursor
+	Limit  int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID string
+		Cursor *model.Cursor
+		Limit  int
+	}
+	mock.lockListNotifications.RLock()
+	calls = mock.calls.ListNotifications
+	mock.lockListNotifications.RUnlock()
+	return calls
+}
+
+// ListReactions calls ListReactionsFunc.
+func (mock *CommentRepoMock) ListReactions(ctx context.Context, commentID uuid.UUID, reactionType string, cursor *model.Cursor, limit int) ([]model.Reaction, error) {
+	if mock.ListReactionsFunc == nil {
+		panic("CommentRepoMock.ListReactionsFunc: method is nil but CommentRepo.ListReactions was just called")
+	}
+	callInfo := struct {
+		Ctx          context.Context
+		CommentID    uuid.UUID
+		ReactionType string
+		Cursor       *model.Cursor
+		Limit        int
+	}{
+		Ctx:          ctx,
+		CommentID:    commentID,
+		ReactionType: reactionType,
+		Cursor:       cursor,
+		Limit:        limit,
+	}
+	mock.lockListReactions.Lock()
+	mock.calls.ListReactions = append(mock.calls.ListReactions, callInfo)
+	mock.lockListReactions.Unlock()
+	return mock.ListReactionsFunc(ctx, commentID, reactionType, cursor, limit)
+}
+
+// ListReactionsCalls gets all the calls that were made to ListReactions.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListReactionsCalls())
+func (mock *CommentRepoMock) ListReactionsCalls() []struct {
+	Ctx          context.Context
+	CommentID    uuid.UUID
+	ReactionType string
+	Cursor       *model.Cursor
+	Limit        int
+} {
+	var calls []struct {
+		Ctx          context.Context
+		CommentID    uuid.UUID
+		ReactionType string
+		Cursor       *model.Cursor
+		Limit        int
+	}
+	mock.lockListReactions.RLock()
+	calls = mock.calls.ListReactions
+	mock.lockListReactions.RUnlock()
+	return calls
+}
+
+// ListRevisions calls ListRevisionsFunc.
+func (mock *CommentRepoMock) ListRevisions(ctx context.Context, commentID uuid.UUID) ([]model.Revision, error) {
+	if mock.ListRevisionsFunc == nil {
+		panic("CommentRepoMock.ListRevisionsFunc: method is nil but CommentRepo.ListRevisions was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+	}
+	mock.lockListRevisions.Lock()
+	mock.calls.ListRevisions = append(mock.calls.ListRevisions, callInfo)
+	mock.lockListRevisions.Unlock()
+	return mock.ListRevisionsFunc(ctx, commentID)
+}
+
+// ListRevisionsCalls gets all the calls that were made to ListRevisions.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListRevisionsCalls())
+func (mock *CommentRepoMock) ListRevisionsCalls() []struct {
+	Ctx       context.Context
+	CommentID uuid.UUID
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+	}
+	mock.lockListRevisions.RLock()
+	calls = mock.calls.ListRevisions
+	mock.lockListRevisions.RUnlock()
+	return calls
+}
+
+// ListThreadTree calls ListThreadTreeFunc.
+func (mock *CommentRepoMock) ListThreadTree(ctx context.Context, threadID uuid.UUID, maxDepth int, limit int) ([]model.Comment, map[uuid.UUID]int, error) {
+	if mock.ListThreadTreeFunc == nil {
+		panic("CommentRepoMock.ListThreadTreeFunc: method is nil but CommentRepo.ListThreadTree was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+		MaxDepth int
+		Limit    int
+	}{
+		Ctx:      ctx,
+		ThreadID: threadID,
+		MaxDepth: maxDepth,
+		Limit:    limit,
+	}
+	mock.lockListThreadTree.Lock()
+	mock.calls.ListThreadTree = append(mock.calls.ListThreadTree, callInfo)
+	mock.lockListThreadTree.Unlock()
+	return mock.ListThreadTreeFunc(ctx, threadID, maxDepth, limit)
+}
+
+// ListThreadTreeCalls gets all the calls that were made to ListThreadTree.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListThreadTreeCalls())
+func (mock *CommentRepoMock) ListThreadTreeCalls() []struct {
+	Ctx      context.Context
+	ThreadID uuid.UUID
+	MaxDepth int
+	Limit    int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ThreadID uuid.UUID
+		MaxDepth int
+		Limit    int
+	}
+	mock.lockListThreadTree.RLock()
+	calls = mock.calls.ListThreadTree
+	mock.lockListThreadTree.RUnlock()
+	return calls
+}
+
+// ListUserReactions calls ListUserReactionsFunc.
+func (mock *CommentRepoMock) ListUserReactions(ctx context.Context, userID string, commentIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	if mock.ListUserReactionsFunc == nil {
+		panic("CommentRepoMock.ListUserReactionsFunc: method is nil but CommentRepo.ListUserReactions was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		UserID     string
+		CommentIDs []uuid.UUID
+	}{
+		Ctx:        ctx,
+		UserID:     userID,
+		CommentIDs: commentIDs,
+	}
+	mock.lockListUserReactions.Lock()
+	mock.calls.ListUserReactions = append(mock.calls.ListUserReactions, callInfo)
+	mock.lockListUserReactions.Unlock()
+	return mock.ListUserReactionsFunc(ctx, userID, commentIDs)
+}
+
+// ListUserReactionsCalls gets all the calls that were made to ListUserReactions.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ListUserReactionsCalls())
+func (mock *CommentRepoMock) ListUserReactionsCalls() []struct {
+	Ctx        context.Context
+	UserID     string
+	CommentIDs []uuid.UUID
+} {
+	var calls []struct {
+		Ctx        context.Context
+		UserID     string
+		CommentIDs []uuid.UUID
+	}
+	mock.lockListUserReactions.RLock()
+	calls = mock.calls.ListUserReactions
+	mock.lockListUserReactions.RUnlock()
+	return calls
+}
+
+// MarkNotificationsRead calls MarkNotificationsReadFunc.
+func (mock *CommentRepoMock) MarkNotificationsRead(ctx context.Context, userID string, ids []uuid.UUID) (int, error) {
+	if mock.MarkNotificationsReadFunc == nil {
+		panic("CommentRepoMock.MarkNotificationsReadFunc: method is nil but CommentRepo.MarkNotificationsRead was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID string
+		Ids    []uuid.UUID
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		Ids:    ids,
+	}
+	mock.lockMarkNotificationsRead.Lock()
+	mock.calls.MarkNotificationsRead = append(mock.calls.MarkNotificationsRead, callInfo)
+	mock.lockMarkNotificationsRead.Unlock()
+	return mock.MarkNotificationsReadFunc(ctx, userID, ids)
+}
+
+// MarkNotificationsReadCalls gets all the calls that were made to MarkNotificationsRead.
+// Check the length with:
+//
+//	len(mockedCommentRepo.MarkNotificationsReadCalls())
+func (mock *CommentRepoMock) MarkNotificationsReadCalls() []struct {
+	Ctx    context.Context
+	UserID string
+	Ids    []uuid.UUID
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID string
+		Ids    []uuid.UUID
+	}
+	mock.lockMarkNotificationsRead.RLock()
+	calls = mock.calls.MarkNotificationsRead
+	mock.lockMarkNotificationsRead.RUnlock()
+	return calls
+}
+
+// ModerateComment calls ModerateCommentFunc.
+func (mock *CommentRepoMock) ModerateComment(ctx context.Context, commentID uuid.UUID, moderatorID string, action string, note string) (*model.Comment, bool, error) {
+	if mock.ModerateCommentFunc == nil {
+		panic("CommentRepoMock.ModerateCommentFunc: method is nil but CommentRepo.ModerateComment was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		CommentID   uuid.UUID
+		ModeratorID string
+		Action      string
+		Note        string
+	}{
+		Ctx:         ctx,
+		CommentID:   commentID,
+		ModeratorID: moderatorID,
+		Action:      action,
+		Note:        note,
+	}
+	mock.lockModerateComment.Lock()
+	mock.calls.ModerateComment = append(mock.calls.ModerateComment, callInfo)
+	mock.lockModerateComment.Unlock()
+	return mock.ModerateCommentFunc(ctx, commentID, moderatorID, action, note)
+}
+
+// ModerateCommentCalls gets all the calls that were made to ModerateComment.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ModerateCommentCalls())
+func (mock *CommentRepoMock) ModerateCommentCalls() []struct {
+	Ctx         context.Context
+	CommentID   uuid.UUID
+	ModeratorID string
+	Action      string
+	Note        string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		CommentID   uuid.UUID
+		ModeratorID string
+		Action      string
+		Note        string
+	}
+	mock.lockModerateComment.RLock()
+	calls = mock.calls.ModerateComment
+	mock.lockModerateComment.RUnlock()
+	return calls
+}
+
+// PinComment calls PinCommentFunc.
+func (mock *CommentRepoMock) PinComment(ctx context.Context, threadID uuid.UUID, commentID uuid.UUID, maxPinned int) (*model.Thread, error) {
+	if mock.PinCommentFunc == nil {
+		panic("CommentRepoMock.PinCommentFunc: method is nil but CommentRepo.PinComment was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		ThreadID  uuid.UUID
+		CommentID uuid.UUID
+		MaxPinned int
+	}{
+		Ctx:       ctx,
+		ThreadID:  threadID,
+		CommentID: commentID,
+		MaxPinned: maxPinned,
+	}
+	mock.lockPinComment.Lock()
+	mock.calls.PinComment = append(mock.calls.PinComment, callInfo)
+	mock.lockPinComment.Unlock()
+	return mock.PinCommentFunc(ctx, threadID, commentID, maxPinned)
+}
+
+// PinCommentCalls gets all the calls that were made to PinComment.
+// Check the length with:
+//
+//	len(mockedCommentRepo.PinCommentCalls())
+func (mock *CommentRepoMock) PinCommentCalls() []struct {
+	Ctx       context.Context
+	ThreadID  uuid.UUID
+	CommentID uuid.UUID
+	MaxPinned int
+} {
+	var calls []struct {
+		Ctx       context.Context
+		ThreadID  uuid.UUID
+		CommentID uuid.UUID
+		MaxPinned int
+	}
+	mock.lockPinComment.RLock()
+	calls = mock.calls.PinComment
+	mock.lockPinComment.RUnlock()
+	return calls
+}
+
+// RecordReaction calls RecordReactionFunc.
+func (mock *CommentRepoMock) RecordReaction(ctx context.Context, reaction *model.Reaction, field string) (bool, error) {
+	if mock.RecordReactionFunc == nil {
+		panic("CommentRepoMock.RecordReactionFunc: method is nil but CommentRepo.RecordReaction was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Reaction *model.Reaction
+		Field    string
+	}{
+		Ctx:      ctx,
+		Reaction: reaction,
+		Field:    field,
+	}
+	mock.lockRecordReaction.Lock()
+	mock.calls.RecordReaction = append(mock.calls.RecordReaction, callInfo)
+	mock.lockRecordReaction.Unlock()
+	return mock.RecordReactionFunc(ctx, reaction, field)
+}
+
+// RecordReactionCalls gets all the calls that were made to RecordReaction.
+// Check the length with:
+//
+//	len(mockedCommentRepo.RecordReactionCalls())
+func (mock *CommentRepoMock) RecordReactionCalls() []struct {
+	Ctx      context.Context
+	Reaction *model.Reaction
+	Field    string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Reaction *model.Reaction
+		Field    string
+	}
+	mock.lockRecordReaction.RLock()
+	calls = mock.calls.RecordReaction
+	mock.lockRecordReaction.RUnlock()
+	return calls
+}
+
+// RecordVote calls RecordVoteFunc.
+func (mock *CommentRepoMock) RecordVote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+	if mock.RecordVoteFunc == nil {
+		panic("CommentRepoMock.RecordVoteFunc: method is nil but CommentRepo.RecordVote was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+		UserID    string
+		Value     int
+		Toggle    bool
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+		UserID:    userID,
+		Value:     value,
+		Toggle:    toggle,
+	}
+	mock.lockRecordVote.Lock()
+	mock.calls.RecordVote = append(mock.calls.RecordVote, callInfo)
+	mock.lockRecordVote.Unlock()
+	return mock.RecordVoteFunc(ctx, commentID, userID, value, toggle)
+}
+
+// RecordVoteCalls gets all the calls that were made to RecordVote.
+// Check the length with:
+//
+//	len(mockedCommentRepo.RecordVoteCalls())
+func (mock *CommentRepoMock) RecordVoteCalls() []struct {
+	Ctx       context.Context
+	CommentID uuid.UUID
+	UserID    string
+	Value     int
+	Toggle    bool
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+		UserID    string
+		Value     int
+		Toggle    bool
+	}
+	mock.lockRecordVote.RLock()
+	calls = mock.calls.RecordVote
+	mock.lockRecordVote.RUnlock()
+	return calls
+}
+
+// SearchComments calls SearchCommentsFunc.
+func (mock *CommentRepoMock) SearchComments(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) ([]model.SearchResult, error) {
+	if mock.SearchCommentsFunc == nil {
+		panic("CommentRepoMock.SearchCommentsFunc: method is nil but CommentRepo.SearchComments was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Query    string
+		ThreadID *uuid.UUID
+		Cursor   *model.Cursor
+		Limit    int
+	}{
+		Ctx:      ctx,
+		Query:    query,
+		ThreadID: threadID,
+		Cursor:   cursor,
+		Limit:    limit,
+	}
+	mock.lockSearchComments.Lock()
+	mock.calls.SearchComments = append(mock.calls.SearchComments, callInfo)
+	mock.lockSearchComments.Unlock()
+	return mock.SearchCommentsFunc(ctx, query, threadID, cursor, limit)
+}
+
+// SearchCommentsCalls gets all the calls that were made to SearchComments.
+// Check the length with:
+//
+//	len(mockedCommentRepo.SearchCommentsCalls())
+func (mock *CommentRepoMock) SearchCommentsCalls() []struct {
+	Ctx      context.Context
+	Query    string
+	ThreadID *uuid.UUID
+	Cursor   *model.Cursor
+	Limit    int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Query    string
+		ThreadID *uuid.UUID
+		Cursor   *model.Cursor
+		Limit    int
+	}
+	mock.lockSearchComments.RLock()
+	calls = mock.calls.SearchComments
+	mock.lockSearchComments.RUnlock()
+	return calls
+}
+
+// ToggleReaction calls ToggleReactionFunc.
+func (mock *CommentRepoMock) ToggleReaction(ctx context.Context, reaction *model.Reaction, field string) (bool, error) {
+	if mock.ToggleReactionFunc == nil {
+		panic("CommentRepoMock.ToggleReactionFunc: method is nil but CommentRepo.ToggleReaction was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Reaction *model.Reaction
+		Field    string
+	}{
+		Ctx:      ctx,
+		Reaction: reaction,
+		Field:    field,
+	}
+	mock.lockToggleReaction.Lock()
+	mock.calls.ToggleReaction = append(mock.calls.ToggleReaction, callInfo)
+	mock.lockToggleReaction.Unlock()
+	return mock.ToggleReactionFunc(ctx, reaction, field)
+}
+
+// ToggleReactionCalls gets all the calls that were made to ToggleReaction.
+// Check the length with:
+//
+//	len(mockedCommentRepo.ToggleReactionCalls())
+func (mock *CommentRepoMock) ToggleReactionCalls() []struct {
+	Ctx      context.Context
+	Reaction *model.Reaction
+	Field    string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Reaction *model.Reaction
+		Field    string
+	}
+	mock.lockToggleReaction.RLock()
+	calls = mock.calls.ToggleReaction
+	mock.lockToggleReaction.RUnlock()
+	return calls
+}
+
+// UnpinComment calls UnpinCommentFunc.
+func (mock *CommentRepoMock) UnpinComment(ctx context.Context, threadID uuid.UUID, commentID uuid.UUID) (*model.Thread, error) {
+	if mock.UnpinCommentFunc == nil {
+		panic("CommentRepoMock.UnpinCommentFunc: method is nil but CommentRepo.UnpinComment was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		ThreadID  uuid.UUID
+		CommentID uuid.UUID
+	}{
+		Ctx:       ctx,
+		ThreadID:  threadID,
+		CommentID: commentID,
+	}
+	mock.lockUnpinComment.Lock()
+	mock.calls.UnpinComment = append(mock.calls.UnpinComment, callInfo)
+	mock.lockUnpinComment.Unlock()
+	return mock.UnpinCommentFunc(ctx, threadID, commentID)
+}
+
+// UnpinCommentCalls gets all the calls that were made to UnpinComment.
+// Check the length with:
+//
+//	len(mockedCommentRepo.UnpinCommentCalls())
+func (mock *CommentRepoMock) UnpinCommentCalls() []struct {
+	Ctx       context.Context
+	ThreadID  uuid.UUID
+	CommentID uuid.UUID
+} {
+	var calls []struct {
+		Ctx       context.Context
+		ThreadID  uuid.UUID
+		CommentID uuid.UUID
+	}
+	mock.lockUnpinComment.RLock()
+	calls = mock.calls.UnpinComment
+	mock.lockUnpinComment.RUnlock()
+	return calls
+}
+
+// UpdateComment calls UpdateCommentFunc.
+func (mock *CommentRepoMock) UpdateComment(ctx context.Context, commentID uuid.UUID, content string, contentHTML string, reports []model.Report) (*model.Comment, error) {
+	if mock.UpdateCommentFunc == nil {
+		panic("CommentRepoMock.UpdateCommentFunc: method is nil but CommentRepo.UpdateComment was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		CommentID   uuid.UUID
+		Content     string
+		ContentHTML string
+		Reports     []model.Report
+	}{
+		Ctx:         ctx,
+		CommentID:   commentID,
+		Content:     content,
+		ContentHTML: contentHTML,
+		Reports:     reports,
+	}
+	mock.lockUpdateComment.Lock()
+	mock.calls.UpdateComment = append(mock.calls.UpdateComment, callInfo)
+	mock.lockUpdateComment.Unlock()
+	return mock.UpdateCommentFunc(ctx, commentID, content, contentHTML, reports)
+}
+
+// UpdateCommentCalls gets all the calls that were made to UpdateComment.
+// Check the length with:
+//
+//	len(mockedCommentRepo.UpdateCommentCalls())
+func (mock *CommentRepoMock) UpdateCommentCalls() []struct {
+	Ctx         context.Context
+	CommentID   uuid.UUID
+	Content     string
+	ContentHTML string
+	Reports     []model.Report
+} {
+	var calls []struct {
+		Ctx         context.Context
+		CommentID   uuid.UUID
+		Content     string
+		ContentHTML string
+		Reports     []model.Report
+	}
+	mock.lockUpdateComment.RLock()
+	calls = mock.calls.UpdateComment
+	mock.lockUpdateComment.RUnlock()
+	return calls
+}
+
+// UpdateThread calls UpdateThreadFunc.
+func (mock *CommentRepoMock) UpdateThread(ctx context.Context, thread *model.Thread) error {
+	if mock.UpdateThreadFunc == nil {
+		panic("CommentRepoMock.UpdateThreadFunc: method is nil but CommentRepo.UpdateThread was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Thread *model.Thread
+	}{
+		Ctx:    ctx,
+		Thread: thread,
+	}
+	mock.lockUpdateThread.Lock()
+	mock.calls.UpdateThread = append(mock.calls.UpdateThread, callInfo)
+	mock.lockUpdateThread.Unlock()
+	return mock.UpdateThreadFunc(ctx, thread)
+}
+
+// UpdateThreadCalls gets all the calls that were made to UpdateThread.
+// Check the length with:
+//
+//	len(mockedCommentRepo.UpdateThreadCalls())
+func (mock *CommentRepoMock) UpdateThreadCalls() []struct {
+	Ctx    context.Context
+	Thread *model.Thread
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Thread *model.Thread
+	}
+	mock.lockUpdateThread.RLock()
+	calls = mock.calls.UpdateThread
+	mock.lockUpdateThread.RUnlock()
+	return calls
+}
+
+// Vote calls VoteFunc.
+func (mock *CommentRepoMock) Vote(ctx context.Context, commentID uuid.UUID, userID string, value int, toggle bool) (int, int, error) {
+	if mock.VoteFunc == nil {
+		panic("CommentRepoMock.VoteFunc: method is nil but CommentRepo.Vote was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+		UserID    string
+		Value     int
+		Toggle    bool
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+		UserID:    userID,
+		Value:     value,
+		Toggle:    toggle,
+	}
+	mock.lockVote.Lock()
+	mock.calls.Vote = append(mock.calls.Vote, callInfo)
+	mock.lockVote.Unlock()
+	return mock.VoteFunc(ctx, commentID, userID, value, toggle)
+}
+
+// VoteCalls gets all the calls that were made to Vote.
+// Check the length with:
+//
+//	len(mockedCommentRepo.VoteCalls())
+func (mock *CommentRepoMock) VoteCalls() []struct {
+	Ctx       context.Context
+	CommentID uuid.UUID
+	UserID    string
+	Value     int
+	Toggle    bool
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID uuid.UUID
+		UserID    string
+		Value     int
+		Toggle    bool
+	}
+	mock.lockVote.RLock()
+	calls = mock.calls.Vote
+	mock.lockVote.RUnlock()
+	return calls
+}
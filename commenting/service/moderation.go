@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+var (
+	ErrInvalidReason = errors.New("invalid report reason")
+	ErrInvalidAction = errors.New("invalid moderation action")
+)
+
+// maxReasonLength caps the free-text reason of a report.
+const maxReasonLength = 500
+
+// validModerationActions are the decisions a moderator can take on a comment.
+var validModerationActions = map[string]bool{
+	model.ModerationApprove: true,
+	model.ModerationHide:    true,
+	model.ModerationRemove:  true,
+}
+
+// ReportComment flags a comment for moderation with the user's reason.
+func (s *CommentService) ReportComment(ctx context.Context, commentID uuid.UUID, userID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLength {
+		return ErrInvalidReason
+	}
+
+	comment, err := s.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.DeletedAt != nil {
+		return model.ErrNotFound
+	}
+
+	return s.repo.CreateReport(ctx, &model.Report{
+		ID:        uuid.New(),
+		CommentID: commentID,
+		UserID:    userID,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// ListModerationQueue returns reported comments awaiting a decision, most reported first.
+func (s *CommentService) ListModerationQueue(ctx context.Context, limit int) ([]model.QueueItem, error) {
+	queue, err := s.repo.ListModerationQueue(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range queue {
+		fillHTML(&queue[i].Comment)
+	}
+	return queue, nil
+}
+
+// ModerateComment records a moderator's decision on a comment and resolves its open reports.
+// Hidden and removed comments are dropped from the cached listings, and approved ones put back.
+// A decision that leaves the comment as it was, like hiding it twice, only resolves the reports.
+func (s *CommentService) ModerateComment(ctx context.Context, commentID uuid.UUID, moderatorID, action, note string) (*model.Comment, error) {
+	if !validModerationActions[action] {
+		return nil, ErrInvalidAction
+	}
+
+	comment, changed, err := s.repo.ModerateComment(ctx, commentID, moderatorID, action, note)
+	if err != nil {
+		return nil, err
+	}
+	fillHTML(comment)
+	if !changed {
+		return comment, nil
+	}
+
+	if comment.Listed() {
+		_ = s.cache.SetComment(ctx, comment)
+	} else {
+		_ = s.cache.DeleteComment(ctx, comment)
+	}
+	return comment, nil
+}
+
+// ListModerationActions returns the audit trail of moderator decisions on a comment, newest first.
+func (s *CommentService) ListModerationActions(ctx context.Context, commentID uuid.UUID) ([]model.ModerationAction, error) {
+	if _, err := s.repo.GetCommentByID(ctx, commentID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListModerationActions(ctx, commentID)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// mentionPattern matches @user mentions that aren't part of a word or an email address.
+// User IDs may contain dots and dashes, but not end with them.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9_](?:[A-Za-z0-9_.-]{0,62}[A-Za-z0-9_])?)`)
+
+// maxMentions caps how many users a single comment can notify through mentions.
+const maxMentions = 10
+
+// ParseMentions returns the distinct users mentioned in content, in order of appearance.
+func ParseMentions(content string) []string {
+	var users []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if user := m[1]; !seen[user] {
+			seen[user] = true
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// newMentions returns the users mentioned in content that weren't mentioned in the previous version.
+func newMentions(previous, content string) []string {
+	before := make(map[string]bool)
+	for _, user := range ParseMentions(previous) {
+		before[user] = true
+	}
+
+	var users []string
+	for _, user := range ParseMentions(content) {
+		if !before[user] {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// notify fans out notifications for a new or edited comment: a reply notification to the parent's
+// author and a mention notification to every mentioned user who has commented before, since only
+// they are known to exist. Each user is notified once, and never for their own comment. Like the
+// cache, notifications are best-effort: failures are logged and don't fail the comment.
+func (s *CommentService) notify(ctx context.Context, comment *model.Comment, parent *model.Comment, mentions []string) {
+	var notifications []model.Notification
+	notified := map[string]bool{comment.UserID: true}
+
+	// An edit notifies as of the edit, so its mentions don't land among older notifications
+	createdAt := comment.CreatedAt
+	if comment.EditedAt != nil {
+		createdAt = *comment.EditedAt
+	}
+
+	add := func(userID, notificationType string) {
+		if notified[userID] {
+			return
+		}
+		notified[userID] = true
+		notifications = append(notifications, model.Notification{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Type:      notificationType,
+			ActorID:   comment.UserID,
+			CommentID: comment.ID,
+			ThreadID:  comment.ThreadID,
+			CreatedAt: createdAt,
+		})
+	}
+
+	if parent != nil && parent.DeletedAt == nil {
+		add(parent.UserID, model.NotificationReply)
+	}
+	if len(mentions) > maxMentions {
+		mentions = mentions[:maxMentions]
+	}
+	if len(mentions) > 0 {
+		known, err := s.repo.ListExistingUsers(ctx, mentions)
+		if err != nil {
+			s.logger.Error("failed to look up mentioned users",
+				slog.String("comment_id", comment.ID.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+		for _, user := range mentions {
+			if known[user] {
+				add(user, model.NotificationMention)
+			}
+		}
+	}
+
+	if len(notifications) == 0 {
+		return
+	}
+	if err := s.repo.CreateNotifications(ctx, notifications); err != nil {
+		s.logger.Error("failed to create notifications",
+			slog.String("comment_id", comment.ID.String()),
+			slog.Int("count", len(notifications)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	for _, n := range notifications {
+		_ = s.cache.IncrUnreadCount(ctx, n.UserID, 1)
+	}
+}
+
+// ListNotifications returns one page of a user's notifications, newest first, with the unread count.
+func (s *CommentService) ListNotifications(ctx context.Context, userID string, cursor *model.Cursor, limit int) (model.NotificationPage, error) {
+	notifications, err := s.repo.ListNotifications(ctx, userID, cursor, limit)
+	if err != nil {
+		return model.NotificationPage{}, err
+	}
+
+	unread, err := s.UnreadCount(ctx, userID)
+	if err != nil {
+		return model.NotificationPage{}, err
+	}
+
+	page := model.NotificationPage{Notifications: notifications, UnreadCount: unread}
+	if len(notifications) > 0 && len(notifications) >= limit {
+		page.NextCursor = model.NewNotificationCursor(&notifications[len(notifications)-1]).Encode()
+	}
+	return page, nil
+}
+
+// UnreadCount returns how many unread notifications a user has.
+// Tries cache, fallbacks to DB and caches the count, unless a concurrent request cached it first
+// and notifications may have been counted on top of it since.
+func (s *CommentService) UnreadCount(ctx context.Context, userID string) (int, error) {
+	if n, err := s.cache.GetUnreadCount(ctx, userID); err == nil {
+		return n, nil
+	}
+
+	n, err := s.repo.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	_ = s.cache.FillUnreadCount(ctx, userID, n)
+	return n, nil
+}
+
+// MarkNotificationsRead marks the given notifications of a user as read, or all of them when ids is empty,
+// and returns the remaining unread count.
+func (s *CommentService) MarkNotificationsRead(ctx context.Context, userID string, ids []uuid.UUID) (int, error) {
+	n, err := s.repo.MarkNotificationsRead(ctx, userID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > 0 {
+		_ = s.cache.IncrUnreadCount(ctx, userID, -n)
+	}
+	return s.UnreadCount(ctx, userID)
+}
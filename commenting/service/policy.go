@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// ContentPolicy checks a new or edited comment before it is stored. It returns nil to allow the comment,
+// or a violation that either rejects it or flags it for moderation. An error means the check
+// itself could not run.
+type ContentPolicy interface {
+	Check(ctx context.Context, comment *model.Comment) (*PolicyViolation, error)
+}
+
+// Policy verdicts other than allowing the comment.
+const (
+	VerdictReject = "reject"
+	VerdictFlag   = "flag"
+)
+
+// PolicyViolation is the structured reason a policy gave for rejecting or flagging a comment.
+type PolicyViolation struct {
+	Policy  string `json:"policy"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Verdict string `json:"-"`
+}
+
+// PolicyError is returned by CreateComment and UpdateComment when a policy rejects the comment.
+type PolicyError struct {
+	Violation PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("rejected by %s policy: %s", e.Violation.Policy, e.Violation.Message)
+}
+
+// flagReports turns the flags policies raised on a comment into reports for the moderation queue.
+func flagReports(commentID uuid.UUID, flags []PolicyViolation) []model.Report {
+	reports := make([]model.Report, 0, len(flags))
+	for _, v := range flags {
+		reports = append(reports, model.Report{
+			ID:        uuid.New(),
+			CommentID: commentID,
+			UserID:    "policy:" + v.Policy,
+			Reason:    v.Message,
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+	return reports
+}
+
+// checkPolicies runs the policies in order and stops at the first rejection.
+// Flags from the policies that ran before it are returned as well.
+func (s *CommentService) checkPolicies(ctx context.Context, comment *model.Comment) ([]PolicyViolation, error) {
+	var flags []PolicyViolation
+	for _, p := range s.policies {
+		v, err := p.Check(ctx, comment)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if v.Verdict == VerdictReject {
+			return nil, &PolicyError{Violation: *v}
+		}
+		flags = append(flags, *v)
+	}
+	return flags, nil
+}
+
+// LengthPolicy rejects comments whose trimmed content is shorter than Min or longer than Max characters.
+type LengthPolicy struct {
+	Min int
+	Max int
+}
+
+func (p LengthPolicy) Check(_ context.Context, comment *model.Comment) (*PolicyViolation, error) {
+	n := utf8.RuneCountInString(strings.TrimSpace(comment.Content))
+	switch {
+	case n < p.Min:
+		return &PolicyViolation{
+			Policy:  "length",
+			Code:    "too_short",
+			Message: fmt.Sprintf("content must be at least %d characters", p.Min),
+			Verdict: VerdictReject,
+		}, nil
+	case n > p.Max:
+		return &PolicyViolation{
+			Policy:  "length",
+			Code:    "too_long",
+			Message: fmt.Sprintf("content must be at most %d characters", p.Max),
+			Verdict: VerdictReject,
+		}, nil
+	}
+	return nil, nil
+}
+
+// BannedWordsPolicy matches whole words case-insensitively against a list
+// and rejects or flags comments that use any of them, depending on Verdict.
+// Word boundaries are Unicode-aware, so "žaba" doesn't match inside "žabac".
+type BannedWordsPolicy struct {
+	pattern *regexp.Regexp
+	verdict string
+}
+
+func NewBannedWordsPolicy(words []string, verdict string) *BannedWordsPolicy {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+
+	p := &BannedWordsPolicy{verdict: verdict}
+	if len(quoted) > 0 {
+		// \b only knows ASCII word characters, so the boundaries are spelled out with Unicode classes
+		p.pattern = regexp.MustCompile(`(?i)(?:^|[^\pL\pM\pN_])(?:` + strings.Join(quoted, "|") + `)(?:$|[^\pL\pM\pN_])`)
+	}
+	return p
+}
+
+func (p *BannedWordsPolicy) Check(_ context.Context, comment *model.Comment) (*PolicyViolation, error) {
+	if p.pattern == nil || !p.pattern.MatchString(comment.Content) {
+		return nil, nil
+	}
+	return &PolicyViolation{
+		Policy:  "banned_words",
+		Code:    "banned_word",
+		Message: "content contains a banned word",
+		Verdict: p.verdict,
+	}, nil
+}
+
+// linkPattern matches http(s) URLs and bare www. links.
+var linkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+`)
+
+// LinkPolicy rejects comments with more than Max links and flags those with more than Flag links.
+// A zero Flag disables flagging.
+type LinkPolicy struct {
+	Max  int
+	Flag int
+}
+
+func (p LinkPolicy) Check(_ context.Context, comment *model.Comment) (*PolicyViolation, error) {
+	n := len(linkPattern.FindAllStringIndex(comment.Content, -1))
+	switch {
+	case n > p.Max:
+		return &PolicyViolation{
+			Policy:  "links",
+			Code:    "too_many_links",
+			Message: fmt.Sprintf("content may contain at most %d links", p.Max),
+			Verdict: VerdictReject,
+		}, nil
+	case p.Flag > 0 && n > p.Flag:
+		return &PolicyViolation{
+			Policy:  "links",
+			Code:    "many_links",
+			Message: fmt.Sprintf("content contains %d links", n),
+			Verdict: VerdictFlag,
+		}, nil
+	}
+	return nil, nil
+}
+
+// RecentCommentsFunc lists a user's comments created since the given time, newest first.
+type RecentCommentsFunc func(ctx context.Context, userID string, since time.Time, limit int) ([]model.Comment, error)
+
+// DuplicatePolicy rejects a comment whose content matches another of the user's comments from the last Window,
+// ignoring case and whitespace. Only the Limit most recent comments are compared.
+type DuplicatePolicy struct {
+	Recent RecentCommentsFunc
+	Window time.Duration
+	Limit  int
+}
+
+func (p DuplicatePolicy) Check(ctx context.Context, comment *model.Comment) (*PolicyViolation, error) {
+	recent, err := p.Recent(ctx, comment.UserID, time.Now().Add(-p.Window), p.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	content := normalizeContent(comment.Content)
+	for _, c := range recent {
+		// An edited comment is not a duplicate of itself
+		self := comment.ID != uuid.Nil && c.ID == comment.ID
+		if !self && c.DeletedAt == nil && normalizeContent(c.Content) == content {
+			return &PolicyViolation{
+				Policy:  "duplicate",
+				Code:    "duplicate_content",
+				Message: "you already posted this comment recently",
+				Verdict: VerdictReject,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeContent lowercases the content and collapses whitespace for duplicate comparison.
+func normalizeContent(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
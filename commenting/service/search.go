@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+var ErrInvalidQuery = errors.New("invalid search query")
+
+const (
+	// maxQueryLength caps the length of a search query in characters.
+	maxQueryLength = 200
+	// snippetLength is roughly how many characters of content a snippet shows.
+	snippetLength = 160
+)
+
+// SearchComments finds listed comments matching a keyword query, most relevant first,
+// within one thread when threadID is set or across all threads otherwise.
+func (s *CommentService) SearchComments(ctx context.Context, query string, threadID *uuid.UUID, cursor *model.Cursor, limit int) (model.SearchPage, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > maxQueryLength {
+		return model.SearchPage{}, ErrInvalidQuery
+	}
+
+	results, err := s.repo.SearchComments(ctx, query, threadID, cursor, limit)
+	if err != nil {
+		return model.SearchPage{}, err
+	}
+
+	terms := searchTerms(query)
+	for i := range results {
+		fillHTML(&results[i].Comment)
+		results[i].Snippet = snippet(results[i].Comment.Content, terms)
+	}
+
+	page := model.SearchPage{Results: results}
+	if len(results) > 0 && len(results) >= limit {
+		page.NextCursor = model.NewSearchCursor(&results[len(results)-1]).Encode()
+	}
+	return page, nil
+}
+
+// searchTerms splits a query into lowercase words.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// snippet returns an HTML-escaped excerpt of content around the first matched term, with every term
+// occurrence in it wrapped in <mark>. Terms match at the start of a word, so "vote" also marks
+// "votes"; stemmed matches the excerpt can't find fall back to the start of the content.
+func snippet(content string, terms []string) string {
+	text := []rune(content)
+	lower := []rune(strings.ToLower(content))
+	if len(lower) != len(text) {
+		// Lowercasing changed the length, so offsets wouldn't line up; skip highlighting
+		lower, terms = text, nil
+	}
+
+	type match struct{ start, end int }
+	var matches []match
+	for i := 0; i < len(lower); i++ {
+		if i > 0 && isWordRune(lower[i-1]) {
+			continue
+		}
+		for _, t := range terms {
+			if hasPrefixAt(lower, i, t) {
+				n := utf8.RuneCountInString(t)
+				matches = append(matches, match{i, i + n})
+				i += n - 1
+				break
+			}
+		}
+	}
+
+	// Center the window on the first match
+	start := 0
+	if len(matches) > 0 {
+		start = max(matches[0].start-snippetLength/3, 0)
+	}
+	end := min(start+snippetLength, len(text))
+	start = max(min(start, end-snippetLength), 0)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(text[pos:m.start])))
+		b.WriteString("<mark>" + html.EscapeString(string(text[m.start:m.end])) + "</mark>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(text[pos:end])))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func hasPrefixAt(s []rune, i int, prefix string) bool {
+	for _, r := range prefix {
+		if i >= len(s) || s[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+var ErrInvalidThread = errors.New("invalid thread")
+
+const (
+	// maxSubjectKeyLength caps the external subject key a thread is attached to, enough for a long URL.
+	maxSubjectKeyLength = 2048
+	// maxTitleLength caps a thread's title in characters.
+	maxTitleLength = 300
+)
+
+// CreateThread creates the thread of a subject key. If the subject already has a thread,
+// it is returned unchanged instead. It reports whether the thread was created.
+func (s *CommentService) CreateThread(ctx context.Context, thread *model.Thread) (bool, error) {
+	thread.SubjectKey = strings.TrimSpace(thread.SubjectKey)
+	thread.Title = strings.TrimSpace(thread.Title)
+	if thread.SubjectKey == "" || len(thread.SubjectKey) > maxSubjectKeyLength {
+		return false, ErrInvalidThread
+	}
+	if utf8.RuneCountInString(thread.Title) > maxTitleLength {
+		return false, ErrInvalidThread
+	}
+
+	if thread.ID == uuid.Nil {
+		thread.ID = uuid.New()
+	}
+	if thread.CreatedAt.IsZero() {
+		thread.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+	// A new thread starts empty and open
+	thread.CommentCount = 0
+	thread.Locked = false
+	thread.PinnedCommentIDs = []uuid.UUID{}
+
+	return s.repo.CreateThread(ctx, thread)
+}
+
+// GetThread retrieves a thread by its ID.
+func (s *CommentService) GetThread(ctx context.Context, threadID uuid.UUID) (*model.Thread, error) {
+	return s.repo.GetThread(ctx, threadID)
+}
+
+// GetThreadBySubject retrieves the thread attached to a subject key.
+func (s *CommentService) GetThreadBySubject(ctx context.Context, subjectKey string) (*model.Thread, error) {
+	return s.repo.GetThreadBySubject(ctx, strings.TrimSpace(subjectKey))
+}
+
+// UpdateThread changes the title of a thread and locks or unlocks it. Nil fields are left unchanged.
+// A locked thread rejects new comments and replies; existing comments stay visible.
+func (s *CommentService) UpdateThread(ctx context.Context, threadID uuid.UUID, title *string, locked *bool) (*model.Thread, error) {
+	thread, err := s.repo.GetThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if title != nil {
+		thread.Title = strings.TrimSpace(*title)
+		if utf8.RuneCountInString(thread.Title) > maxTitleLength {
+			return nil, ErrInvalidThread
+		}
+	}
+	if locked != nil {
+		thread.Locked = *locked
+	}
+
+	if err := s.repo.UpdateThread(ctx, thread); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// PinComment pins one of the thread's listed comments, so it leads the first page of the thread's listings.
+// A thread pins at most model.MaxPinnedComments comments; pinning the same comment twice is a no-op.
+func (s *CommentService) PinComment(ctx context.Context, threadID, commentID uuid.UUID) (*model.Thread, error) {
+	comment, err := s.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ThreadID != threadID || !comment.Listed() {
+		return nil, model.ErrNotFound
+	}
+	thread, err := s.repo.PinComment(ctx, threadID, commentID, model.MaxPinnedComments)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.SetPinnedIDs(ctx, threadID, thread.PinnedCommentIDs)
+	return thread, nil
+}
+
+// UnpinComment removes a comment from the thread's pinned comments.
+func (s *CommentService) UnpinComment(ctx context.Context, threadID, commentID uuid.UUID) (*model.Thread, error) {
+	thread, err := s.repo.UnpinComment(ctx, threadID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.SetPinnedIDs(ctx, threadID, thread.PinnedCommentIDs)
+	return thread, nil
+}
+
+// pinnedComments returns the listed comments pinned to a thread, in pin order.
+// The pin list is cached with the thread, so listings don't read the thread from the database.
+func (s *CommentService) pinnedComments(ctx context.Context, threadID uuid.UUID) ([]model.Comment, error) {
+	ids, err := s.cache.GetPinnedIDs(ctx, threadID)
+	if err != nil {
+		if ids, err = s.loadPinnedIDs(ctx, threadID); err != nil {
+			return nil, err
+		}
+		_ = s.cache.FillPinnedIDs(ctx, threadID, ids)
+	}
+
+	pinned := make([]model.Comment, 0, len(ids))
+	for _, id := range ids {
+		comment, err := s.GetCommentByID(ctx, id)
+		if errors.Is(err, model.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Pins of comments deleted or hidden since are kept, but not shown
+		if !comment.Listed() {
+			continue
+		}
+		comment.Pinned = true
+		pinned = append(pinned, *comment)
+	}
+	return pinned, nil
+}
+
+// loadPinnedIDs reads the pinned comment IDs of a thread from the database.
+// Threads from before the threads table existed have no pins.
+func (s *CommentService) loadPinnedIDs(ctx context.Context, threadID uuid.UUID) ([]uuid.UUID, error) {
+	thread, err := s.repo.GetThread(ctx, threadID)
+	if errors.Is(err, model.ErrNotFound) {
+		return []uuid.UUID{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return thread.PinnedCommentIDs, nil
+}
+
+// checkThread verifies that a thread exists: either a thread record or,
+// for threads from before the threads table existed, their root comment.
+func (s *CommentService) checkThread(ctx context.Context, threadID uuid.UUID) error {
+	_, err := s.repo.GetThread(ctx, threadID)
+	if errors.Is(err, model.ErrNotFound) {
+		_, err = s.GetCommentByID(ctx, threadID)
+	}
+	return err
+}
@@ -0,0 +1,258 @@
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// Source reads the per-thread event streams the outbox relay publishes to.
+type Source interface {
+	ReadThreadEvents(ctx context.Context, after map[uuid.UUID]string, block time.Duration) ([]model.StreamEvent, map[uuid.UUID]string, error)
+	LastThreadEventID(ctx context.Context, threadID uuid.UUID) (string, error)
+	ThreadEventsBetween(ctx context.Context, threadID uuid.UUID, afterID, untilID string) ([]model.StreamEvent, error)
+}
+
+const (
+	defaultBlock = 2 * time.Second
+	retryDelay   = time.Second
+
+	// subscriberBuffer is how many events a subscriber can fall behind before it is dropped.
+	subscriberBuffer = 64
+)
+
+// Hub fans thread events out to live clients. A single reader per process follows the streams of all
+// threads that have subscribers, so clients don't each hold a blocking read on the Redis pool.
+// A thread's stream is first read up to Block after its first subscriber joins.
+// Subscribers that fall more than subscriberBuffer events behind are dropped; they can reconnect
+// with the ID of the last event they received.
+type Hub struct {
+	source Source
+	logger *slog.Logger
+
+	Block time.Duration
+
+	mu      sync.Mutex
+	threads map[uuid.UUID]*thread
+	wake    chan struct{}
+}
+
+// thread is the stream position of a followed thread and its subscribers.
+type thread struct {
+	position string
+	subs     map[chan model.StreamEvent]struct{}
+}
+
+func NewHub(source Source, logger *slog.Logger) *Hub {
+	return &Hub{
+		source:  source,
+		logger:  logger,
+		Block:   defaultBlock,
+		threads: make(map[uuid.UUID]*thread),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Subscribe returns the events of a thread from now on. With lastEventID set, the events after it
+// that are still in the stream come first. The channel is closed when ctx is cancelled, when the
+// subscriber falls behind, or when the replay can't be read.
+func (h *Hub) Subscribe(ctx context.Context, threadID uuid.UUID, lastEventID string) (<-chan model.StreamEvent, error) {
+	live, from, err := h.join(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan model.StreamEvent)
+	go func() {
+		defer close(out)
+		defer h.leave(threadID, live)
+
+		// Events up to the position the subscriber joined at are replayed, later ones arrive live
+		var replay []model.StreamEvent
+		if lastEventID != "" {
+			var err error
+			if replay, err = h.source.ThreadEventsBetween(ctx, threadID, lastEventID, from); err != nil {
+				h.logger.Error("failed to replay thread events",
+					slog.String("thread_id", threadID.String()), slog.Any("error", err))
+				return
+			}
+		}
+
+		sent := lastEventID
+		forward := func(e model.StreamEvent) bool {
+			// A client that resumes from another replica may already have seen what this one sends next
+			if sent != "" && !After(e.StreamID, sent) {
+				return true
+			}
+			select {
+			case out <- e:
+				sent = e.StreamID
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, e := range replay {
+			if !forward(e) {
+				return
+			}
+		}
+		for {
+			select {
+			case e, ok := <-live:
+				if !ok || !forward(e) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// join adds a subscriber to a thread, following the thread from the end of its stream if it is new.
+// It returns the subscriber's channel and the stream position from which events arrive on it.
+func (h *Hub) join(ctx context.Context, threadID uuid.UUID) (chan model.StreamEvent, string, error) {
+	h.mu.Lock()
+	_, followed := h.threads[threadID]
+	h.mu.Unlock()
+
+	var last string
+	if !followed {
+		var err error
+		if last, err = h.source.LastThreadEventID(ctx, threadID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Another subscriber may have started following the thread in the meantime
+	t := h.threads[threadID]
+	if t == nil {
+		t = &thread{position: last, subs: make(map[chan model.StreamEvent]struct{})}
+		h.threads[threadID] = t
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	live := make(chan model.StreamEvent, subscriberBuffer)
+	t.subs[live] = struct{}{}
+	return live, t.position, nil
+}
+
+// leave removes a subscriber, and stops following the thread once it has none.
+func (h *Hub) leave(threadID uuid.UUID, live chan model.StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.threads[threadID]
+	if t == nil {
+		return
+	}
+	if _, ok := t.subs[live]; ok {
+		delete(t.subs, live)
+		close(live)
+	}
+	if len(t.subs) == 0 {
+		delete(h.threads, threadID)
+	}
+}
+
+// Run follows the streams of the threads with subscribers until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		after := h.positions()
+		if len(after) == 0 {
+			select {
+			case <-h.wake:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		events, positions, err := h.source.ReadThreadEvents(ctx, after, h.Block)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.Error("failed to read thread events", slog.Any("error", err))
+				select {
+				case <-time.After(retryDelay):
+				case <-ctx.Done():
+				}
+			}
+			continue
+		}
+		h.deliver(events, positions)
+	}
+}
+
+// positions returns the stream position of every followed thread.
+func (h *Hub) positions() map[uuid.UUID]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	after := make(map[uuid.UUID]string, len(h.threads))
+	for id, t := range h.threads {
+		after[id] = t.position
+	}
+	return after
+}
+
+// deliver hands events to the subscribers of their thread and moves the threads' positions on.
+// A thread that was dropped and followed again during the read starts further on, so events
+// from before its new position are skipped.
+func (h *Hub) deliver(events []model.StreamEvent, positions map[uuid.UUID]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range events {
+		t := h.threads[e.Event.ThreadID]
+		if t == nil || !After(e.StreamID, t.position) {
+			continue
+		}
+		t.position = e.StreamID
+		for live := range t.subs {
+			select {
+			case live <- e:
+			default:
+				delete(t.subs, live)
+				close(live)
+			}
+		}
+	}
+
+	for id, position := range positions {
+		if t := h.threads[id]; t != nil && After(position, t.position) {
+			t.position = position
+		}
+	}
+}
+
+// After reports whether the stream ID a comes after b. Stream IDs are "<milliseconds>-<sequence>",
+// and a bare "0" stands for the start of a stream.
+func After(a, b string) bool {
+	aMs, aSeq := parseID(a)
+	bMs, bSeq := parseID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+func parseID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
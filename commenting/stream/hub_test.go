@@ -0,0 +1,184 @@
+package stream_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/stream"
+	"github.com/kiremitrov123/onboarding/commenting/stream/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeStreams is a Source holding thread streams in memory.
+type fakeStreams struct {
+	mu      sync.Mutex
+	streams map[uuid.UUID][]model.StreamEvent
+	added   chan struct{}
+}
+
+func newFakeStreams() *fakeStreams {
+	return &fakeStreams{streams: make(map[uuid.UUID][]model.StreamEvent), added: make(chan struct{}, 100)}
+}
+
+func (f *fakeStreams) publish(threadID uuid.UUID, id string) {
+	f.mu.Lock()
+	f.streams[threadID] = append(f.streams[threadID], model.StreamEvent{
+		StreamID: id,
+		Event:    model.Event{ID: uuid.New(), ThreadID: threadID},
+	})
+	f.mu.Unlock()
+	f.added <- struct{}{}
+}
+
+func (f *fakeStreams) source() *mocks.SourceMock {
+	return &mocks.SourceMock{
+		LastThreadEventIDFunc: func(ctx context.Context, threadID uuid.UUID) (string, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			events := f.streams[threadID]
+			if len(events) == 0 {
+				return "0", nil
+			}
+			return events[len(events)-1].StreamID, nil
+		},
+		ThreadEventsBetweenFunc: func(ctx context.Context, threadID uuid.UUID, afterID, untilID string) ([]model.StreamEvent, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var out []model.StreamEvent
+			for _, e := range f.streams[threadID] {
+				if stream.After(e.StreamID, afterID) && !stream.After(e.StreamID, untilID) {
+					out = append(out, e)
+				}
+			}
+			return out, nil
+		},
+		ReadThreadEventsFunc: func(ctx context.Context, after map[uuid.UUID]string, block time.Duration) ([]model.StreamEvent, map[uuid.UUID]string, error) {
+			for {
+				f.mu.Lock()
+				var out []model.StreamEvent
+				positions := make(map[uuid.UUID]string)
+				for threadID, afterID := range after {
+					for _, e := range f.streams[threadID] {
+						if stream.After(e.StreamID, afterID) {
+							out = append(out, e)
+							positions[threadID] = e.StreamID
+						}
+					}
+				}
+				f.mu.Unlock()
+				if len(out) > 0 {
+					return out, positions, nil
+				}
+
+				select {
+				case <-f.added:
+				case <-time.After(block):
+					return nil, nil, nil
+				case <-ctx.Done():
+					return nil, nil, ctx.Err()
+				}
+			}
+		},
+	}
+}
+
+func receive(t *testing.T, ch <-chan model.StreamEvent) model.StreamEvent {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		require.True(t, ok, "stream closed")
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return model.StreamEvent{}
+	}
+}
+
+func TestHub_FansOutWithOneReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streams := newFakeStreams()
+	source := streams.source()
+	hub := stream.NewHub(source, testLogger)
+	hub.Block = 10 * time.Millisecond
+	go hub.Run(ctx)
+
+	threadID := uuid.New()
+	streams.publish(threadID, "1-0") // before anyone subscribed, so not delivered
+
+	first, err := hub.Subscribe(ctx, threadID, "")
+	require.NoError(t, err)
+	second, err := hub.Subscribe(ctx, threadID, "")
+	require.NoError(t, err)
+
+	streams.publish(threadID, "2-0")
+	require.Equal(t, "2-0", receive(t, first).StreamID)
+	require.Equal(t, "2-0", receive(t, second).StreamID)
+
+	// Both subscribers are served by the same reads, each covering the thread once
+	for _, call := range source.ReadThreadEventsCalls() {
+		require.Len(t, call.After, 1)
+	}
+}
+
+func TestHub_ReplaysFromLastEventID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streams := newFakeStreams()
+	hub := stream.NewHub(streams.source(), testLogger)
+	hub.Block = 10 * time.Millisecond
+	go hub.Run(ctx)
+
+	threadID := uuid.New()
+	streams.publish(threadID, "1-0")
+	streams.publish(threadID, "2-0")
+	streams.publish(threadID, "3-0")
+
+	events, err := hub.Subscribe(ctx, threadID, "1-0")
+	require.NoError(t, err)
+	streams.publish(threadID, "4-0")
+
+	// The missed events come first, then live ones, each exactly once
+	for _, id := range []string{"2-0", "3-0", "4-0"} {
+		require.Equal(t, id, receive(t, events).StreamID)
+	}
+}
+
+func TestHub_ClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streams := newFakeStreams()
+	hub := stream.NewHub(streams.source(), testLogger)
+	hub.Block = 10 * time.Millisecond
+	go hub.Run(ctx)
+
+	subCtx, unsubscribe := context.WithCancel(ctx)
+	events, err := hub.Subscribe(subCtx, uuid.New(), "")
+	require.NoError(t, err)
+	unsubscribe()
+
+	select {
+	case _, ok := <-events:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("stream not closed")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	require.True(t, stream.After("1700000000001-0", "1700000000000-5"))
+	require.True(t, stream.After("1700000000000-10", "1700000000000-9"))
+	require.True(t, stream.After("1-0", "0"))
+	require.False(t, stream.After("1-0", "1-0"))
+}
@@ -0,0 +1,66 @@
+package votes
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Store adds the counter deltas recorded in write-behind mode to the comments in the database.
+type Store interface {
+	FlushVoteDeltas(ctx context.Context, limit int) (int, error)
+}
+
+const (
+	defaultInterval  = 250 * time.Millisecond
+	defaultBatchSize = 1000
+)
+
+// Flusher writes the reaction counters recorded in write-behind mode to the comments. Every Interval it
+// takes up to BatchSize of the oldest deltas and adds them to their comments in a single transaction,
+// which also deletes them, so a flush that fails leaves them for the next one and none is counted twice.
+type Flusher struct {
+	store  Store
+	logger *slog.Logger
+
+	Interval  time.Duration
+	BatchSize int
+}
+
+func NewFlusher(store Store, logger *slog.Logger) *Flusher {
+	return &Flusher{
+		store:     store,
+		logger:    logger,
+		Interval:  defaultInterval,
+		BatchSize: defaultBatchSize,
+	}
+}
+
+// Run flushes the recorded votes every Interval until ctx is cancelled,
+// without waiting while there are more than a batch of them.
+func (f *Flusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := f.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			f.logger.Error("vote flush failed", slog.Any("error", err))
+		}
+		if err == nil && n == f.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush writes one batch of recorded votes to the database.
+// It returns the number of deltas flushed, or 0 if none were pending.
+func (f *Flusher) Flush(ctx context.Context) (int, error) {
+	return f.store.FlushVoteDeltas(ctx, f.BatchSize)
+}
@@ -0,0 +1,76 @@
+package votes_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kiremitrov123/onboarding/commenting/votes"
+	"github.com/kiremitrov123/onboarding/commenting/votes/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestFlush_FlushesOneBatch(t *testing.T) {
+	store := &mocks.StoreMock{
+		FlushVoteDeltasFunc: func(ctx context.Context, limit int) (int, error) { return 3, nil },
+	}
+
+	flusher := votes.NewFlusher(store, testLogger)
+	flusher.BatchSize = 10
+	n, err := flusher.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	calls := store.FlushVoteDeltasCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, 10, calls[0].Limit)
+}
+
+func TestRun_DrainsFullBatchesWithoutWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pending := 25
+	store := &mocks.StoreMock{
+		FlushVoteDeltasFunc: func(ctx context.Context, limit int) (int, error) {
+			n := min(pending, limit)
+			pending -= n
+			if pending == 0 {
+				cancel()
+			}
+			return n, nil
+		},
+	}
+
+	flusher := votes.NewFlusher(store, testLogger)
+	flusher.Interval = time.Hour
+	flusher.BatchSize = 10
+	flusher.Run(ctx)
+
+	require.Len(t, store.FlushVoteDeltasCalls(), 3)
+}
+
+func TestRun_KeepsGoingAfterFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &mocks.StoreMock{}
+	store.FlushVoteDeltasFunc = func(ctx context.Context, limit int) (int, error) {
+		if len(store.FlushVoteDeltasCalls()) > 1 {
+			cancel()
+			return 0, nil
+		}
+		return 0, errors.New("db down")
+	}
+
+	flusher := votes.NewFlusher(store, testLogger)
+	flusher.Interval = time.Millisecond
+	flusher.Run(ctx)
+
+	require.Len(t, store.FlushVoteDeltasCalls(), 2)
+}
@@ -0,0 +1,205 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 50
+	defaultMaxAttempts = 8
+
+	// Retries back off exponentially from baseBackoff, up to maxBackoff between attempts
+	baseBackoff = 5 * time.Second
+	maxBackoff  = time.Hour
+
+	defaultConcurrency = 10
+
+	// deliveryTimeout bounds a single delivery attempt, including the receiver's response.
+	deliveryTimeout = 10 * time.Second
+	// leaseMargin is added to the time a batch takes at most to send, for recording the outcomes
+	leaseMargin = 30 * time.Second
+)
+
+// Delivery request headers. The signature covers "<timestamp>.<body>".
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the HMAC-SHA256 signature of a delivery, as sent in the signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received delivery against the subscription secret, for use by receivers.
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// Run delivers due events until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.ProcessDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("webhook dispatch failed", slog.Any("error", err))
+		}
+		if err == nil && n == s.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue claims the deliveries that are due and attempts each of them once, Concurrency at a time.
+// Failed deliveries are rescheduled with exponential backoff, or dead-lettered after MaxAttempts.
+// It returns how many deliveries were attempted.
+func (s *Service) ProcessDue(ctx context.Context) (int, error) {
+	deliveries, err := s.store.ClaimDueDeliveries(ctx, s.BatchSize, s.claimLease())
+	if err != nil {
+		return 0, err
+	}
+
+	subs := make(map[uuid.UUID]*model.WebhookSubscription)
+	for _, d := range deliveries {
+		if _, ok := subs[d.SubscriptionID]; ok {
+			continue
+		}
+		sub, err := s.store.GetSubscription(ctx, d.SubscriptionID)
+		if errors.Is(err, model.ErrNotFound) {
+			sub = nil // deleted since; its deliveries go with it
+		} else if err != nil {
+			return 0, err
+		}
+		subs[d.SubscriptionID] = sub
+	}
+
+	var g errgroup.Group
+	g.SetLimit(s.Concurrency)
+	for _, d := range deliveries {
+		sub := subs[d.SubscriptionID]
+		g.Go(func() error { return s.attempt(ctx, sub, d) })
+	}
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+// claimLease is how long claimed deliveries are hidden from other dispatchers. It covers sending
+// the whole batch with every attempt running into its timeout, so that a slow batch isn't claimed
+// and delivered a second time by another dispatcher while it is still being sent.
+func (s *Service) claimLease() time.Duration {
+	waves := (s.BatchSize + s.Concurrency - 1) / s.Concurrency
+	return time.Duration(waves)*deliveryTimeout + leaseMargin
+}
+
+// attempt sends one delivery and records the outcome.
+func (s *Service) attempt(ctx context.Context, sub *model.WebhookSubscription, d model.WebhookDelivery) error {
+	if sub == nil {
+		return s.store.CompleteDelivery(ctx, d.ID)
+	}
+
+	if !sub.Active {
+		// Kept as a dead letter so it can be replayed once the subscription is active again
+		d.LastError = "subscription inactive"
+		return s.store.DeadLetterDelivery(ctx, d)
+	}
+
+	sendErr := s.send(ctx, sub, d)
+	if sendErr == nil {
+		return s.store.CompleteDelivery(ctx, d.ID)
+	}
+
+	d.Attempts++
+	d.LastError = sendErr.Error()
+	s.logger.Warn("webhook delivery failed",
+		slog.String("delivery_id", d.ID.String()),
+		slog.String("subscription_id", sub.ID.String()),
+		slog.Int("attempts", d.Attempts),
+		slog.String("error", d.LastError),
+	)
+
+	if d.Attempts >= s.MaxAttempts {
+		return s.store.DeadLetterDelivery(ctx, d)
+	}
+	d.NextAttemptAt = time.Now().UTC().Add(backoff(d.Attempts))
+	return s.store.RescheduleDelivery(ctx, d)
+}
+
+// send POSTs the event to the subscription URL. Any 2xx response counts as delivered.
+func (s *Service) send(ctx context.Context, sub *model.WebhookSubscription, d model.WebhookDelivery) error {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, d.ID.String())
+	req.Header.Set(HeaderEvent, d.Event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the wait before the next attempt after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
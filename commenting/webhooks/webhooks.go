@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+)
+
+// Store persists subscriptions, the delivery queue and dead letters.
+type Store interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, id uuid.UUID) error
+	RescheduleDelivery(ctx context.Context, d model.WebhookDelivery) error
+	DeadLetterDelivery(ctx context.Context, d model.WebhookDelivery) error
+	ListDeadLetters(ctx context.Context, subscriptionID uuid.UUID) ([]model.WebhookDelivery, error)
+	ReplayDeadLetters(ctx context.Context, subscriptionID uuid.UUID, ids []uuid.UUID) (int, error)
+}
+
+var ErrInvalidSubscription = errors.New("invalid webhook subscription")
+
+// eventTypes are the event types a subscription can filter on.
+var eventTypes = map[string]bool{
+	model.EventCommentCreated:  true,
+	model.EventCommentUpdated:  true,
+	model.EventCommentDeleted:  true,
+	model.EventReactionChanged: true,
+}
+
+// Service manages webhook subscriptions and delivers queued events to them.
+type Service struct {
+	store  Store
+	client *http.Client
+	logger *slog.Logger
+
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Concurrency int
+}
+
+func NewService(store Store, client *http.Client, logger *slog.Logger) *Service {
+	return &Service{
+		store:       store,
+		client:      client,
+		logger:      logger,
+		Interval:    defaultInterval,
+		BatchSize:   defaultBatchSize,
+		MaxAttempts: defaultMaxAttempts,
+		Concurrency: defaultConcurrency,
+	}
+}
+
+// CreateSubscription validates and stores a subscription. A secret is generated when none is given,
+// and the returned subscription is the only place it is shown.
+func (s *Service) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	if err := validateSubscription(sub); err != nil {
+		return err
+	}
+
+	if sub.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		sub.Secret = secret
+	}
+	sub.ID = uuid.New()
+	sub.Active = true
+	sub.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	return s.store.CreateSubscription(ctx, sub)
+}
+
+// GetSubscription returns a subscription without its secret.
+func (s *Service) GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	sub, err := s.store.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// ListSubscriptions returns every subscription without their secrets.
+func (s *Service) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	subs, err := s.store.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// UpdateSubscription applies the given changes; nil fields are left as they are.
+func (s *Service) UpdateSubscription(ctx context.Context, id uuid.UUID, url *string, types []string, active *bool) (*model.WebhookSubscription, error) {
+	sub, err := s.store.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if url != nil {
+		sub.URL = *url
+	}
+	if types != nil {
+		sub.EventTypes = types
+	}
+	if active != nil {
+		sub.Active = *active
+	}
+	if err := validateSubscription(sub); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *Service) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return s.store.DeleteSubscription(ctx, id)
+}
+
+// ListDeadLetters returns the deliveries to a subscription that ran out of attempts.
+func (s *Service) ListDeadLetters(ctx context.Context, subscriptionID uuid.UUID) ([]model.WebhookDelivery, error) {
+	if _, err := s.store.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.store.ListDeadLetters(ctx, subscriptionID)
+}
+
+// ReplayDeadLetters queues dead letters for delivery again, all of them when ids is empty.
+func (s *Service) ReplayDeadLetters(ctx context.Context, subscriptionID uuid.UUID, ids []uuid.UUID) (int, error) {
+	if _, err := s.store.GetSubscription(ctx, subscriptionID); err != nil {
+		return 0, err
+	}
+	return s.store.ReplayDeadLetters(ctx, subscriptionID, ids)
+}
+
+// Enqueue queues each event for every active subscription that accepts its type, listing the
+// subscriptions once for all of them. It is called by the outbox relay for every processed batch.
+// A delivery's ID is derived from its event and subscription, so an event queued again after a
+// relay failure isn't delivered twice while its first delivery is still queued, and receivers
+// see the same webhook ID if it is.
+func (s *Service) Enqueue(ctx context.Context, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	subs, err := s.store.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var deliveries []model.WebhookDelivery
+	for _, event := range events {
+		for _, sub := range subs {
+			if !sub.Active || !sub.Accepts(event.Type) {
+				continue
+			}
+			deliveries = append(deliveries, model.WebhookDelivery{
+				ID:             uuid.NewSHA1(event.ID, sub.ID[:]),
+				SubscriptionID: sub.ID,
+				Event:          event,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			})
+		}
+	}
+	return s.store.CreateDeliveries(ctx, deliveries)
+}
+
+func validateSubscription(sub *model.WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidSubscription)
+	}
+	for _, t := range sub.EventTypes {
+		if !eventTypes[t] {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidSubscription, t)
+		}
+	}
+	return nil
+}
+
+// newSecret returns 32 random bytes, hex encoded.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,194 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiremitrov123/onboarding/commenting/model"
+	"github.com/kiremitrov123/onboarding/commenting/webhooks"
+	"github.com/kiremitrov123/onboarding/commenting/webhooks/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newDelivery(subID uuid.UUID, attempts int) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: subID,
+		Event:          model.Event{ID: uuid.New(), Type: model.EventCommentCreated, CommentID: uuid.New()},
+		Attempts:       attempts,
+	}
+}
+
+func newStore(sub *model.WebhookSubscription, deliveries ...model.WebhookDelivery) *mocks.StoreMock {
+	return &mocks.StoreMock{
+		ClaimDueDeliveriesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+			return deliveries, nil
+		},
+		GetSubscriptionFunc: func(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+			stored := *sub
+			return &stored, nil
+		},
+		CompleteDeliveryFunc:   func(ctx context.Context, id uuid.UUID) error { return nil },
+		RescheduleDeliveryFunc: func(ctx context.Context, d model.WebhookDelivery) error { return nil },
+		DeadLetterDeliveryFunc: func(ctx context.Context, d model.WebhookDelivery) error { return nil },
+	}
+}
+
+func TestProcessDue_SignsDelivery(t *testing.T) {
+	var verified bool
+	var eventHeader string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = webhooks.Verify("s3cret", r.Header.Get(webhooks.HeaderTimestamp), r.Header.Get(webhooks.HeaderSignature), body)
+		eventHeader = r.Header.Get(webhooks.HeaderEvent)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sub := &model.WebhookSubscription{ID: uuid.New(), URL: receiver.URL, Secret: "s3cret", Active: true}
+	delivery := newDelivery(sub.ID, 0)
+	store := newStore(sub, delivery)
+
+	svc := webhooks.NewService(store, receiver.Client(), testLogger)
+	n, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.True(t, verified)
+	require.Equal(t, model.EventCommentCreated, eventHeader)
+	require.Len(t, store.CompleteDeliveryCalls(), 1)
+	require.Equal(t, delivery.ID, store.CompleteDeliveryCalls()[0].Id)
+}
+
+func TestProcessDue_RetriesWithBackoff(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	sub := &model.WebhookSubscription{ID: uuid.New(), URL: receiver.URL, Secret: "s3cret", Active: true}
+	store := newStore(sub, newDelivery(sub.ID, 2))
+
+	svc := webhooks.NewService(store, receiver.Client(), testLogger)
+	before := time.Now()
+	_, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+
+	calls := store.RescheduleDeliveryCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, 3, calls[0].D.Attempts)
+	require.Equal(t, "unexpected status 502", calls[0].D.LastError)
+
+	// Third failed attempt waits 5s * 2^2
+	wait := calls[0].D.NextAttemptAt.Sub(before)
+	require.GreaterOrEqual(t, wait, 20*time.Second)
+	require.Less(t, wait, 21*time.Second)
+	require.Empty(t, store.DeadLetterDeliveryCalls())
+}
+
+func TestProcessDue_DeadLettersAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	sub := &model.WebhookSubscription{ID: uuid.New(), URL: receiver.URL, Secret: "s3cret", Active: true}
+	store := newStore(sub, newDelivery(sub.ID, 7))
+
+	svc := webhooks.NewService(store, receiver.Client(), testLogger)
+	_, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+
+	calls := store.DeadLetterDeliveryCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, 8, calls[0].D.Attempts)
+	require.Empty(t, store.RescheduleDeliveryCalls())
+}
+
+func TestEnqueue_FiltersSubscriptions(t *testing.T) {
+	all := model.WebhookSubscription{ID: uuid.New(), Active: true}
+	reactionsOnly := model.WebhookSubscription{ID: uuid.New(), Active: true, EventTypes: []string{model.EventReactionChanged}}
+	inactive := model.WebhookSubscription{ID: uuid.New(), Active: false}
+
+	store := &mocks.StoreMock{
+		ListSubscriptionsFunc: func(ctx context.Context) ([]model.WebhookSubscription, error) {
+			return []model.WebhookSubscription{all, reactionsOnly, inactive}, nil
+		},
+		CreateDeliveriesFunc: func(ctx context.Context, deliveries []model.WebhookDelivery) error { return nil },
+	}
+
+	created := model.Event{ID: uuid.New(), Type: model.EventCommentCreated}
+	reacted := model.Event{ID: uuid.New(), Type: model.EventReactionChanged}
+	svc := webhooks.NewService(store, http.DefaultClient, testLogger)
+	require.NoError(t, svc.Enqueue(context.Background(), []model.Event{created, reacted}))
+	require.NoError(t, svc.Enqueue(context.Background(), []model.Event{created}))
+
+	calls := store.CreateDeliveriesCalls()
+	require.Len(t, calls, 2)
+	require.Len(t, store.ListSubscriptionsCalls(), 2)
+
+	deliveries := calls[0].Deliveries
+	require.Len(t, deliveries, 3)
+	require.Equal(t, all.ID, deliveries[0].SubscriptionID)
+	require.Equal(t, all.ID, deliveries[1].SubscriptionID)
+	require.Equal(t, reactionsOnly.ID, deliveries[2].SubscriptionID)
+
+	// Queueing an event again yields the same delivery, which the store skips
+	require.Len(t, calls[1].Deliveries, 1)
+	require.Equal(t, deliveries[0].ID, calls[1].Deliveries[0].ID)
+}
+
+func TestProcessDue_SendsConcurrently(t *testing.T) {
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Neither request is answered until both have arrived
+		arrived.Done()
+		arrived.Wait()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sub := &model.WebhookSubscription{ID: uuid.New(), URL: receiver.URL, Secret: "s3cret", Active: true}
+	store := newStore(sub, newDelivery(sub.ID, 0), newDelivery(sub.ID, 0))
+
+	svc := webhooks.NewService(store, receiver.Client(), testLogger)
+	n, err := svc.ProcessDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Len(t, store.CompleteDeliveryCalls(), 2)
+
+	// The lease outlasts the batch even if every attempt runs into its deadline
+	lease := store.ClaimDueDeliveriesCalls()[0].Lease
+	require.Greater(t, lease, time.Duration(svc.BatchSize/svc.Concurrency)*10*time.Second)
+}
+
+func TestCreateSubscription_Validates(t *testing.T) {
+	store := &mocks.StoreMock{
+		CreateSubscriptionFunc: func(ctx context.Context, sub *model.WebhookSubscription) error { return nil },
+	}
+	svc := webhooks.NewService(store, http.DefaultClient, testLogger)
+
+	err := svc.CreateSubscription(context.Background(), &model.WebhookSubscription{URL: "ftp://example.com"})
+	require.ErrorIs(t, err, webhooks.ErrInvalidSubscription)
+
+	err = svc.CreateSubscription(context.Background(), &model.WebhookSubscription{
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"comment.liked"},
+	})
+	require.ErrorIs(t, err, webhooks.ErrInvalidSubscription)
+
+	sub := &model.WebhookSubscription{URL: "https://example.com/hook"}
+	require.NoError(t, svc.CreateSubscription(context.Background(), sub))
+	require.Len(t, sub.Secret, 64)
+	require.True(t, sub.Active)
+}